@@ -0,0 +1,139 @@
+// Package benchguard compares two `go test -bench` runs and reports
+// metrics that regressed beyond configured limits, so CI can fail on
+// performance regressions.
+package benchguard
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Results maps a benchmark name (without the -N GOMAXPROCS suffix) to its
+// metrics by unit, e.g. "ns/op", "B/op", "allocs/op" or "bytes/stream".
+// When a benchmark appears several times (-count) the values are averaged.
+type Results map[string]map[string]float64
+
+// Parse reads `go test -bench` output
+func Parse(r io.Reader) (Results, error) {
+	sums := make(map[string]map[string]float64)
+	counts := make(map[string]map[string]int)
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		name := fields[0]
+		if i := strings.LastIndex(name, "-"); i > 0 {
+			if _, err := strconv.Atoi(name[i+1:]); err == nil {
+				name = name[:i]
+			}
+		}
+		if sums[name] == nil {
+			sums[name] = make(map[string]float64)
+			counts[name] = make(map[string]int)
+		}
+		// fields[1] is the iteration count, then value/unit pairs follow
+		for i := 2; i+1 < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("benchmark %s: bad value %q", name, fields[i])
+			}
+			sums[name][fields[i+1]] += v
+			counts[name][fields[i+1]]++
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	for name, units := range sums {
+		for unit := range units {
+			units[unit] /= float64(counts[name][unit])
+		}
+	}
+	return Results(sums), nil
+}
+
+// Limits maps a unit to the largest tolerated relative increase, e.g.
+// {"ns/op": 0.10} fails when a benchmark gets more than 10% slower.
+// All units are treated as lower-is-better.
+type Limits map[string]float64
+
+// Regression is a metric that grew beyond its limit
+type Regression struct {
+	Benchmark string
+	Unit      string
+	Base      float64
+	Head      float64
+	Change    float64 // Relative change, 0.25 means 25% worse
+	Limit     float64
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s %s: %.4g -> %.4g (%+.1f%%, limit %.1f%%)",
+		r.Benchmark, r.Unit, r.Base, r.Head, r.Change*100, r.Limit*100)
+}
+
+// Compare returns the metrics of head that regressed against base beyond
+// limits. Benchmarks missing from either run are ignored.
+func Compare(base, head Results, limits Limits) []Regression {
+	var out []Regression
+	for name, headUnits := range head {
+		baseUnits, ok := base[name]
+		if !ok {
+			continue
+		}
+		for unit, limit := range limits {
+			b, okB := baseUnits[unit]
+			h, okH := headUnits[unit]
+			if !okB || !okH || b == 0 {
+				continue
+			}
+			if change := (h - b) / b; change > limit {
+				out = append(out, Regression{
+					Benchmark: name,
+					Unit:      unit,
+					Base:      b,
+					Head:      h,
+					Change:    change,
+					Limit:     limit,
+				})
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Benchmark != out[j].Benchmark {
+			return out[i].Benchmark < out[j].Benchmark
+		}
+		return out[i].Unit < out[j].Unit
+	})
+	return out
+}
+
+// Check parses two runs and returns an error listing every regression
+func Check(base, head io.Reader, limits Limits) error {
+	b, err := Parse(base)
+	if err != nil {
+		return fmt.Errorf("parse base: %w", err)
+	}
+	h, err := Parse(head)
+	if err != nil {
+		return fmt.Errorf("parse head: %w", err)
+	}
+
+	regs := Compare(b, h, limits)
+	if len(regs) == 0 {
+		return nil
+	}
+	lines := make([]string, len(regs))
+	for i, r := range regs {
+		lines[i] = r.String()
+	}
+	return fmt.Errorf("%d benchmark regressions:\n%s", len(regs), strings.Join(lines, "\n"))
+}
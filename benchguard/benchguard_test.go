@@ -0,0 +1,95 @@
+package benchguard
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const base = `goos: linux
+goarch: amd64
+pkg: github.com/kalpit-sharma-dev/math-stats/streamstats
+BenchmarkAddNumber-8        	 1000000	       100.0 ns/op	      16 B/op	       1 allocs/op
+BenchmarkAddNumber-8        	 1000000	       120.0 ns/op	      16 B/op	       1 allocs/op
+BenchmarkSnapshot-8         	   10000	      5000 ns/op
+BenchmarkMemoryPerStream    	       1	  81234 bytes/stream
+BenchmarkConcurrency/mutex/writers=4-8	 200000	  40.0 ns/op	 10000000 reads/s
+PASS
+ok  	github.com/kalpit-sharma-dev/math-stats/streamstats	3.2s
+`
+
+func TestParse(t *testing.T) {
+	res, err := Parse(strings.NewReader(base))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, unit string
+		want       float64
+	}{
+		{"BenchmarkAddNumber", "ns/op", 110}, // Averaged over -count runs
+		{"BenchmarkAddNumber", "allocs/op", 1},
+		{"BenchmarkSnapshot", "ns/op", 5000},
+		{"BenchmarkMemoryPerStream", "bytes/stream", 81234},
+		{"BenchmarkConcurrency/mutex/writers=4", "reads/s", 1e7},
+	}
+	for _, tt := range tests {
+		if got := res[tt.name][tt.unit]; got != tt.want {
+			t.Errorf("%s %s = %v, want %v", tt.name, tt.unit, got, tt.want)
+		}
+	}
+	if len(res) != 4 {
+		t.Errorf("Parse() found %d benchmarks, want 4", len(res))
+	}
+
+	if _, err := Parse(strings.NewReader("BenchmarkX-8 10 fast ns/op\n")); err == nil {
+		t.Error("Parse() of a malformed value succeeded")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseRes := Results{
+		"BenchmarkA": {"ns/op": 100, "B/op": 10},
+		"BenchmarkB": {"ns/op": 100},
+		"BenchmarkC": {"ns/op": 100},
+		"BenchmarkZ": {"ns/op": 0},
+	}
+	head := Results{
+		"BenchmarkA": {"ns/op": 109, "B/op": 20}, // Within the time limit, allocates more
+		"BenchmarkB": {"ns/op": 150},
+		"BenchmarkC": {"ns/op": 50},  // Improvements never fail
+		"BenchmarkZ": {"ns/op": 10},  // No baseline to compare with
+		"BenchmarkN": {"ns/op": 1e9}, // New benchmark
+	}
+	regs := Compare(baseRes, head, Limits{"ns/op": 0.10, "B/op": 0.5})
+	if len(regs) != 2 {
+		t.Fatalf("Compare() = %v, want 2 regressions", regs)
+	}
+	if r := regs[0]; r.Benchmark != "BenchmarkA" || r.Unit != "B/op" || r.Change != 1 {
+		t.Errorf("first regression = %+v, want BenchmarkA B/op +100%%", r)
+	}
+	if r := regs[1]; r.Benchmark != "BenchmarkB" || r.Unit != "ns/op" || r.Change != 0.5 {
+		t.Errorf("second regression = %+v, want BenchmarkB ns/op +50%%", r)
+	}
+	if got, want := regs[1].String(), "BenchmarkB ns/op: 100 -> 150 (+50.0%, limit 10.0%)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	slower := strings.ReplaceAll(base, "5000 ns/op", "6000 ns/op")
+	if err := Check(strings.NewReader(base), strings.NewReader(base), Limits{"ns/op": 0}); err != nil {
+		t.Errorf("Check() of identical runs: %v", err)
+	}
+	err := Check(strings.NewReader(base), strings.NewReader(slower), Limits{"ns/op": 0.10})
+	if err == nil || !strings.Contains(err.Error(), "BenchmarkSnapshot ns/op") {
+		t.Errorf("Check() of a 20%% slower snapshot = %v, want a regression", err)
+	}
+	if err := Check(errReader{}, strings.NewReader(base), nil); err == nil {
+		t.Error("Check() with an unreadable base succeeded")
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("read failed") }
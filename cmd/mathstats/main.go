@@ -0,0 +1,150 @@
+// Command mathstats demonstrates the streamstats package, compares two
+// datasets with "mathstats compare [-column N] a.csv b.csv" and guards
+// against benchmark regressions with
+// "mathstats benchguard [-limit ns/op=0.10] base.txt head.txt".
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kalpit-sharma-dev/math-stats/benchguard"
+	"github.com/kalpit-sharma-dev/math-stats/streamstats"
+)
+
+func main() {
+	// "compare a.csv b.csv" runs the two-sample comparison tool
+	if len(os.Args) > 1 && os.Args[1] == "compare" {
+		if err := runCompare(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, "compare:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	// "benchguard base.txt head.txt" fails on benchmark regressions
+	if len(os.Args) > 1 && os.Args[1] == "benchguard" {
+		if err := runBenchguard(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, "benchguard:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	stats := streamstats.NewDataStreamStats(1000) // Ring buffer for last 1000 elements
+
+	// Simulated data stream
+	for i := 1; i <= 100; i++ {
+		stats.AddNumber(float64(i))
+	}
+
+	// Stop background workers
+	stats.Stop()
+
+	st := stats.Stats()
+	fmt.Printf("Mean: %.2f\n", st.Mean)
+	fmt.Printf("Min: %.2f\n", st.Min)
+	fmt.Printf("Max: %.2f\n", st.Max)
+	fmt.Printf("Median: %.2f\n", st.Median)
+	fmt.Printf("95th Percentile: %.2f\n", st.P95)
+	fmt.Printf("99th Percentile: %.2f\n", st.P99)
+}
+
+// runCompare implements "compare [-column N] A B"
+func runCompare(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	column := fs.Int("column", 0, "0-based column to read from each file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: compare [-column N] A B")
+	}
+
+	var data [2][]float64
+	for i, path := range fs.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		data[i], err = streamstats.ReadSamples(f, *column)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	c := streamstats.CompareSamples(data[0], data[1])
+	fmt.Fprintf(w, "A: %s\nB: %s\n\n", fs.Arg(0), fs.Arg(1))
+	fmt.Fprintf(w, "%-2s %10s %10s %10s %10s %10s %10s\n", "", "count", "mean", "stddev", "p50", "p95", "p99")
+	for i, s := range []streamstats.SampleSummary{c.A, c.B} {
+		fmt.Fprintf(w, "%-2s %10d %10.4g %10.4g %10.4g %10.4g %10.4g\n",
+			string(rune('A'+i)), s.Count, s.Mean, s.StdDev, s.P50, s.P95, s.P99)
+	}
+	fmt.Fprintf(w, "\nWelch t-test p=%.4g  Mann-Whitney U p=%.4g  KS D=%.4f p=%.4g\n", c.WelchP, c.MannWhitneyP, c.KS, c.KSP)
+	fmt.Fprintf(w, "Cohen's d=%.3f  Cliff's delta=%.3f\n", c.CohensD, c.CliffsDelta)
+	fmt.Fprintf(w, "Verdict: %s\n", c.Verdict)
+	return nil
+}
+
+// limitsFlag collects repeated -limit unit=fraction flags
+type limitsFlag benchguard.Limits
+
+func (l limitsFlag) String() string {
+	units := make([]string, 0, len(l))
+	for unit, limit := range l {
+		units = append(units, fmt.Sprintf("%s=%g", unit, limit))
+	}
+	sort.Strings(units)
+	return strings.Join(units, ",")
+}
+
+func (l limitsFlag) Set(s string) error {
+	unit, frac, ok := strings.Cut(s, "=")
+	if !ok || unit == "" {
+		return fmt.Errorf("limit %q is not unit=fraction", s)
+	}
+	v, err := strconv.ParseFloat(frac, 64)
+	if err != nil || v < 0 {
+		return fmt.Errorf("limit %q: fraction must be a non-negative number", s)
+	}
+	l[unit] = v
+	return nil
+}
+
+// runBenchguard implements "benchguard [-limit unit=fraction]... BASE HEAD",
+// comparing two saved `go test -bench` outputs; without -limit, ns/op may
+// grow by 10%
+func runBenchguard(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("benchguard", flag.ContinueOnError)
+	limits := limitsFlag{}
+	fs.Var(limits, "limit", "largest tolerated relative increase per unit, e.g. ns/op=0.10 (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: benchguard [-limit unit=fraction]... BASE HEAD")
+	}
+	if len(limits) == 0 {
+		limits["ns/op"] = 0.10
+	}
+
+	var files [2]*os.File
+	for i, path := range fs.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		files[i] = f
+	}
+	if err := benchguard.Check(files[0], files[1], benchguard.Limits(limits)); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "no regressions beyond %s\n", limits)
+	return nil
+}
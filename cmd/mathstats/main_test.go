@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunCompare(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.csv")
+	b := filepath.Join(dir, "b.csv")
+	var sa, sb strings.Builder
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&sa, "x,%d\n", i%10)
+		fmt.Fprintf(&sb, "x,%d\n", i%10+5)
+	}
+	if err := os.WriteFile(a, []byte(sa.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte(sb.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if err := runCompare([]string{"-column", "1", a, b}, &out); err != nil {
+		t.Fatalf("runCompare: %v", err)
+	}
+	if !strings.Contains(out.String(), "Verdict:") {
+		t.Errorf("output has no verdict:\n%s", out.String())
+	}
+
+	if err := runCompare([]string{a}, &out); err == nil {
+		t.Error("runCompare with one file succeeded, want usage error")
+	}
+}
+
+func TestRunBenchguard(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, ns string) string {
+		path := filepath.Join(dir, name)
+		line := "BenchmarkAddNumber-8 1000000 " + ns + " ns/op 16 B/op\n"
+		if err := os.WriteFile(path, []byte(line), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	base, same, slower := write("base.txt", "100"), write("same.txt", "105"), write("slower.txt", "150")
+
+	var out strings.Builder
+	if err := runBenchguard([]string{base, same}, &out); err != nil {
+		t.Errorf("runBenchguard within the default limit: %v", err)
+	}
+	if !strings.Contains(out.String(), "ns/op=0.1") {
+		t.Errorf("output does not name the default limit:\n%s", out.String())
+	}
+	if err := runBenchguard([]string{base, slower}, &out); err == nil || !strings.Contains(err.Error(), "BenchmarkAddNumber") {
+		t.Errorf("runBenchguard with a 50%% regression = %v, want an error", err)
+	}
+	if err := runBenchguard([]string{"-limit", "ns/op=0.6", "-limit", "B/op=0", base, slower}, &out); err != nil {
+		t.Errorf("runBenchguard with raised limits: %v", err)
+	}
+
+	for _, args := range [][]string{{base}, {"-limit", "ns/op", base, same}, {"-limit", "ns/op=-1", base, same}} {
+		if err := runBenchguard(args, &out); err == nil {
+			t.Errorf("runBenchguard(%q) succeeded, want error", args)
+		}
+	}
+	if err := runBenchguard([]string{base, filepath.Join(dir, "missing.txt")}, &out); err == nil {
+		t.Error("runBenchguard with a missing file succeeded")
+	}
+}
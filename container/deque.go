@@ -0,0 +1,95 @@
+package container
+
+// MonotonicDeque answers the minimum or maximum of a sliding window in
+// amortized O(1): values that can never become the extreme again are
+// dropped as newer values arrive. Every value is numbered by the order it
+// was pushed, and the window is advanced with Expire.
+type MonotonicDeque struct {
+	max    bool
+	values []float64
+	seqs   []int64
+	head   int   // Index of the front in values and seqs
+	next   int64 // Sequence number of the next push
+}
+
+// NewMinDeque creates a deque whose front is the window minimum
+func NewMinDeque() *MonotonicDeque { return &MonotonicDeque{} }
+
+// NewMaxDeque creates a deque whose front is the window maximum
+func NewMaxDeque() *MonotonicDeque { return &MonotonicDeque{max: true} }
+
+// Push adds v and returns its sequence number, starting at 0
+func (d *MonotonicDeque) Push(v float64) int64 {
+	// Drop values v dominates; ties keep the newer value
+	for len(d.values) > d.head && d.dominates(v, d.values[len(d.values)-1]) {
+		d.values = d.values[:len(d.values)-1]
+		d.seqs = d.seqs[:len(d.seqs)-1]
+	}
+	d.values = append(d.values, v)
+	d.seqs = append(d.seqs, d.next)
+	d.next++
+	return d.next - 1
+}
+
+func (d *MonotonicDeque) dominates(v, old float64) bool {
+	if d.max {
+		return v >= old
+	}
+	return v <= old
+}
+
+// Expire drops the values pushed before sequence number seq, so the
+// window starts at seq
+func (d *MonotonicDeque) Expire(seq int64) {
+	for d.head < len(d.seqs) && d.seqs[d.head] < seq {
+		d.head++
+	}
+	// Compact once the dropped prefix dominates the storage
+	if d.head > 32 && d.head*2 > len(d.values) {
+		d.values = append(d.values[:0], d.values[d.head:]...)
+		d.seqs = append(d.seqs[:0], d.seqs[d.head:]...)
+		d.head = 0
+	}
+}
+
+// Front returns the minimum or maximum of the window; ok is false when the
+// window is empty
+func (d *MonotonicDeque) Front() (v float64, ok bool) {
+	if d.head == len(d.values) {
+		return 0, false
+	}
+	return d.values[d.head], true
+}
+
+// Len returns the number of candidate values held, at most the window size
+func (d *MonotonicDeque) Len() int { return len(d.values) - d.head }
+
+// SlidingExtreme tracks the minimum or maximum of the last Size values
+type SlidingExtreme struct {
+	deque *MonotonicDeque
+	size  int64
+}
+
+// NewSlidingMin tracks the minimum of the last size values, at least one
+func NewSlidingMin(size int) *SlidingExtreme {
+	return &SlidingExtreme{deque: NewMinDeque(), size: int64(max(size, 1))}
+}
+
+// NewSlidingMax tracks the maximum of the last size values, at least one
+func NewSlidingMax(size int) *SlidingExtreme {
+	return &SlidingExtreme{deque: NewMaxDeque(), size: int64(max(size, 1))}
+}
+
+// Add adds v and returns the extreme of the last size values
+func (se *SlidingExtreme) Add(v float64) float64 {
+	seq := se.deque.Push(v)
+	se.deque.Expire(seq - se.size + 1)
+	front, _ := se.deque.Front()
+	return front
+}
+
+// Value returns the current extreme, 0 before the first Add
+func (se *SlidingExtreme) Value() float64 {
+	v, _ := se.deque.Front()
+	return v
+}
@@ -0,0 +1,52 @@
+package container
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestSlidingExtremes(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, size := range []int{1, 3, 50} {
+		lo, hi := NewSlidingMin(size), NewSlidingMax(size)
+		var all []float64
+		for i := 0; i < 2000; i++ {
+			// Few distinct values exercise ties
+			v := float64(r.Intn(20))
+			all = append(all, v)
+			window := all[max(0, len(all)-size):]
+
+			if got, want := lo.Add(v), slices.Min(window); got != want {
+				t.Fatalf("size %d, sample %d: min = %v, want %v", size, i, got, want)
+			}
+			if got, want := hi.Add(v), slices.Max(window); got != want {
+				t.Fatalf("size %d, sample %d: max = %v, want %v", size, i, got, want)
+			}
+		}
+		if lo.deque.Len() > size || hi.deque.Len() > size {
+			t.Errorf("size %d: deques hold %d and %d values", size, lo.deque.Len(), hi.deque.Len())
+		}
+	}
+}
+
+func TestMonotonicDeque(t *testing.T) {
+	d := NewMaxDeque()
+	if _, ok := d.Front(); ok {
+		t.Error("Front() of an empty deque reports a value")
+	}
+	for _, v := range []float64{5, 1, 3} {
+		d.Push(v)
+	}
+	if v, _ := d.Front(); v != 5 {
+		t.Errorf("Front() = %v, want 5", v)
+	}
+	d.Expire(1)
+	if v, _ := d.Front(); v != 3 {
+		t.Errorf("Front() after Expire(1) = %v, want 3", v)
+	}
+	d.Expire(3)
+	if _, ok := d.Front(); ok {
+		t.Error("Front() after expiring everything reports a value")
+	}
+}
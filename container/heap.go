@@ -0,0 +1,104 @@
+// Package container provides the float64 data structures behind
+// streamstats for custom sliding-window logic: binary heaps, a fixed-size
+// ring and a monotonic deque for sliding minimums and maximums. None of
+// them is safe for concurrent use.
+package container
+
+// MinHeap is a binary heap of float64 whose top is the smallest value.
+// The zero value is an empty heap; a slice converted to MinHeap must be
+// Init'ed before use.
+type MinHeap []float64
+
+// Len returns the number of values
+func (h MinHeap) Len() int { return len(h) }
+
+// Peek returns the smallest value; the heap must not be empty
+func (h MinHeap) Peek() float64 { return h[0] }
+
+// Push adds v
+func (h *MinHeap) Push(v float64) { push(h, v) }
+
+// Pop removes and returns the smallest value; the heap must not be empty
+func (h *MinHeap) Pop() float64 { return pop(h) }
+
+// Init restores heap order after the slice was modified directly
+func (h MinHeap) Init() { initHeap(h) }
+
+func (h MinHeap) less(i, j int) bool { return h[i] < h[j] }
+
+// MaxHeap is a binary heap of float64 whose top is the largest value.
+// The zero value is an empty heap; a slice converted to MaxHeap must be
+// Init'ed before use.
+type MaxHeap []float64
+
+// Len returns the number of values
+func (h MaxHeap) Len() int { return len(h) }
+
+// Peek returns the largest value; the heap must not be empty
+func (h MaxHeap) Peek() float64 { return h[0] }
+
+// Push adds v
+func (h *MaxHeap) Push(v float64) { push(h, v) }
+
+// Pop removes and returns the largest value; the heap must not be empty
+func (h *MaxHeap) Pop() float64 { return pop(h) }
+
+// Init restores heap order after the slice was modified directly
+func (h MaxHeap) Init() { initHeap(h) }
+
+func (h MaxHeap) less(i, j int) bool { return h[i] > h[j] }
+
+// floatHeap is satisfied by MinHeap and MaxHeap. Operating on the slice
+// types directly avoids the interface boxing of container/heap.
+type floatHeap interface {
+	~[]float64
+	less(i, j int) bool
+}
+
+func push[H floatHeap](h *H, v float64) {
+	*h = append(*h, v)
+	up(*h, len(*h)-1)
+}
+
+func pop[H floatHeap](h *H) float64 {
+	s := *h
+	n := len(s) - 1
+	s[0], s[n] = s[n], s[0]
+	down(s[:n], 0)
+	*h = s[:n]
+	return s[n]
+}
+
+func initHeap[H floatHeap](h H) {
+	for i := len(h)/2 - 1; i >= 0; i-- {
+		down(h, i)
+	}
+}
+
+func up[H floatHeap](h H, i int) {
+	for i > 0 {
+		p := (i - 1) / 2
+		if !h.less(i, p) {
+			return
+		}
+		h[i], h[p] = h[p], h[i]
+		i = p
+	}
+}
+
+func down[H floatHeap](h H, i int) {
+	for {
+		c := 2*i + 1
+		if c >= len(h) {
+			return
+		}
+		if r := c + 1; r < len(h) && h.less(r, c) {
+			c = r
+		}
+		if !h.less(c, i) {
+			return
+		}
+		h[i], h[c] = h[c], h[i]
+		i = c
+	}
+}
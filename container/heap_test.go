@@ -0,0 +1,62 @@
+package container
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestHeaps(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var minH MinHeap
+	var maxH MaxHeap
+	var all []float64
+	for i := 0; i < 1000; i++ {
+		v := r.NormFloat64()
+		all = append(all, v)
+		minH.Push(v)
+		maxH.Push(v)
+	}
+	slices.Sort(all)
+
+	if got := minH.Peek(); got != all[0] {
+		t.Errorf("MinHeap.Peek() = %v, want %v", got, all[0])
+	}
+	if got := maxH.Peek(); got != all[len(all)-1] {
+		t.Errorf("MaxHeap.Peek() = %v, want %v", got, all[len(all)-1])
+	}
+	for i := range all {
+		if got := minH.Pop(); got != all[i] {
+			t.Fatalf("MinHeap.Pop() #%d = %v, want %v", i, got, all[i])
+		}
+		if got, want := maxH.Pop(), all[len(all)-1-i]; got != want {
+			t.Fatalf("MaxHeap.Pop() #%d = %v, want %v", i, got, want)
+		}
+	}
+	if minH.Len() != 0 || maxH.Len() != 0 {
+		t.Errorf("Len() after popping everything = %d, %d, want 0", minH.Len(), maxH.Len())
+	}
+}
+
+func TestHeapInit(t *testing.T) {
+	h := MaxHeap{3, 9, 1, 7, 5}
+	h.Init()
+	var got []float64
+	for h.Len() > 0 {
+		got = append(got, h.Pop())
+	}
+	if want := []float64{9, 7, 5, 3, 1}; !slices.Equal(got, want) {
+		t.Errorf("pops after Init() = %v, want %v", got, want)
+	}
+}
+
+func TestHeapPushDoesNotAllocate(t *testing.T) {
+	h := make(MinHeap, 0, 1024)
+	allocs := testing.AllocsPerRun(1000, func() {
+		h.Push(1)
+		h.Pop()
+	})
+	if allocs != 0 {
+		t.Errorf("Push and Pop allocate %v times, want 0", allocs)
+	}
+}
@@ -0,0 +1,71 @@
+package container
+
+import "iter"
+
+// Ring holds the last Cap float64 values added, overwriting the oldest
+type Ring struct {
+	data []float64
+	head int // Next write position
+	size int
+}
+
+// NewRing creates a ring holding up to capacity values
+func NewRing(capacity int) *Ring {
+	return &Ring{data: make([]float64, max(capacity, 0))}
+}
+
+// Add appends v, evicting the oldest value when full; it returns the
+// evicted value and whether there was one
+func (r *Ring) Add(v float64) (evicted float64, ok bool) {
+	if len(r.data) == 0 {
+		return v, true
+	}
+	if r.size == len(r.data) {
+		evicted, ok = r.data[r.head], true
+	} else {
+		r.size++
+	}
+	r.data[r.head] = v
+	r.head = (r.head + 1) % len(r.data)
+	return evicted, ok
+}
+
+// Len returns the number of values held
+func (r *Ring) Len() int { return r.size }
+
+// Cap returns the maximum number of values held
+func (r *Ring) Cap() int { return len(r.data) }
+
+// At returns the i-th oldest value, 0 <= i < Len
+func (r *Ring) At(i int) float64 {
+	if i < 0 || i >= r.size {
+		panic("container: Ring index out of range")
+	}
+	return r.data[(r.head-r.size+i+len(r.data))%len(r.data)]
+}
+
+// All iterates over the values from oldest to newest with their index,
+// without copying them
+func (r *Ring) All() iter.Seq2[int, float64] {
+	return func(yield func(int, float64) bool) {
+		for i := 0; i < r.size; i++ {
+			if !yield(i, r.At(i)) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns a copy of the values from oldest to newest
+func (r *Ring) Values() []float64 {
+	out := make([]float64, r.size)
+	for i := range out {
+		out[i] = r.At(i)
+	}
+	return out
+}
+
+// Reset empties the ring, keeping its storage
+func (r *Ring) Reset() {
+	r.head, r.size = 0, 0
+}
@@ -0,0 +1,63 @@
+package container
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	r := NewRing(3)
+	for i := 1; i <= 3; i++ {
+		if _, ok := r.Add(float64(i)); ok {
+			t.Errorf("Add(%d) evicted a value before the ring was full", i)
+		}
+	}
+	if got := r.Values(); !slices.Equal(got, []float64{1, 2, 3}) {
+		t.Errorf("Values() = %v, want [1 2 3]", got)
+	}
+
+	if evicted, ok := r.Add(4); !ok || evicted != 1 {
+		t.Errorf("Add(4) evicted %v, %v, want 1", evicted, ok)
+	}
+	if got := r.Values(); !slices.Equal(got, []float64{2, 3, 4}) {
+		t.Errorf("Values() after wrapping = %v, want [2 3 4]", got)
+	}
+	if got := r.At(0); got != 2 {
+		t.Errorf("At(0) = %v, want 2", got)
+	}
+	if r.Len() != 3 || r.Cap() != 3 {
+		t.Errorf("Len(), Cap() = %d, %d, want 3, 3", r.Len(), r.Cap())
+	}
+
+	r.Reset()
+	r.Add(9)
+	if got := r.Values(); !slices.Equal(got, []float64{9}) {
+		t.Errorf("Values() after Reset = %v, want [9]", got)
+	}
+}
+
+func TestRingAtOutOfRange(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("At() beyond Len did not panic")
+		}
+	}()
+	NewRing(3).At(0)
+}
+
+func TestRingAll(t *testing.T) {
+	r := NewRing(3)
+	for _, v := range []float64{1, 2, 3, 4} {
+		r.Add(v)
+	}
+	var got []float64
+	for i, v := range r.All() {
+		if v != r.At(i) {
+			t.Errorf("All() yielded %v at %d, At() = %v", v, i, r.At(i))
+		}
+		got = append(got, v)
+	}
+	if !slices.Equal(got, []float64{2, 3, 4}) {
+		t.Errorf("All() = %v, want [2 3 4]", got)
+	}
+}
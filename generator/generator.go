@@ -0,0 +1,117 @@
+// Package generator produces synthetic data streams for exercising
+// statistics, alerting and benchmarks with realistic distributions.
+package generator
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Generator produces the next sample of a synthetic stream
+type Generator interface {
+	Next() float64
+}
+
+// GeneratorFunc adapts a function to the Generator interface
+type GeneratorFunc func() float64
+
+// Next calls f
+func (f GeneratorFunc) Next() float64 { return f() }
+
+// NewRand returns a seeded source so generated streams are reproducible
+func NewRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
+// Normal draws from a normal distribution
+func Normal(r *rand.Rand, mean, stddev float64) Generator {
+	return GeneratorFunc(func() float64 {
+		return mean + stddev*r.NormFloat64()
+	})
+}
+
+// LogNormal draws exp(N(mu, sigma)), a common shape for latencies
+func LogNormal(r *rand.Rand, mu, sigma float64) Generator {
+	return GeneratorFunc(func() float64 {
+		return math.Exp(mu + sigma*r.NormFloat64())
+	})
+}
+
+// Exponential draws from an exponential distribution with the given rate
+func Exponential(r *rand.Rand, rate float64) Generator {
+	return GeneratorFunc(func() float64 {
+		return r.ExpFloat64() / rate
+	})
+}
+
+// Pareto draws from a Pareto distribution with scale xm and shape alpha
+func Pareto(r *rand.Rand, xm, alpha float64) Generator {
+	return GeneratorFunc(func() float64 {
+		return xm / math.Pow(1-r.Float64(), 1/alpha)
+	})
+}
+
+// Bimodal draws from a with probability p and from b otherwise
+func Bimodal(r *rand.Rand, a, b Generator, p float64) Generator {
+	return GeneratorFunc(func() float64 {
+		if r.Float64() < p {
+			return a.Next()
+		}
+		return b.Next()
+	})
+}
+
+// Step adds offset to every sample after the first n, simulating a
+// sudden level shift such as a bad deploy
+func Step(g Generator, n int, offset float64) Generator {
+	i := 0
+	return GeneratorFunc(func() float64 {
+		i++
+		if i > n {
+			return g.Next() + offset
+		}
+		return g.Next()
+	})
+}
+
+// Spikes multiplies samples by magnitude with probability p
+func Spikes(r *rand.Rand, g Generator, p, magnitude float64) Generator {
+	return GeneratorFunc(func() float64 {
+		v := g.Next()
+		if r.Float64() < p {
+			return v * magnitude
+		}
+		return v
+	})
+}
+
+// Take returns the next n samples of g
+func Take(g Generator, n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = g.Next()
+	}
+	return out
+}
+
+// Pace calls fn with samples from g at rate samples per second until n
+// samples were emitted or stop is closed. It returns at once for a rate
+// that is not positive.
+func Pace(g Generator, rate float64, n int, stop <-chan struct{}, fn func(float64)) {
+	if !(rate > 0) {
+		return
+	}
+	// Rates above one per nanosecond emit as fast as the ticker allows
+	ticker := time.NewTicker(max(time.Duration(float64(time.Second)/rate), 1))
+	defer ticker.Stop()
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-ticker.C:
+			fn(g.Next())
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package generator
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"testing"
+)
+
+// mean returns the arithmetic mean of values
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func TestDistributions(t *testing.T) {
+	const n = 100000
+	r := NewRand(1)
+
+	tests := []struct {
+		name      string
+		g         Generator
+		want, tol float64 // Expected mean and absolute tolerance
+	}{
+		{"normal", Normal(r, 10, 2), 10, 0.05},
+		{"lognormal", LogNormal(r, 0, 0.5), math.Exp(0.125), 0.02},
+		{"exponential", Exponential(r, 4), 0.25, 0.01},
+		{"pareto", Pareto(r, 1, 3), 1.5, 0.05},
+		{"bimodal", Bimodal(r, Normal(r, 0, 1), Normal(r, 100, 1), 0.25), 75, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mean(Take(tt.g, n)); math.Abs(got-tt.want) > tt.tol {
+				t.Errorf("mean = %v, want %v ± %v", got, tt.want, tt.tol)
+			}
+		})
+	}
+
+	// Pareto samples never fall below the scale
+	values := Take(Pareto(r, 2, 1.5), 1000)
+	sort.Float64s(values)
+	if values[0] < 2 {
+		t.Errorf("Pareto minimum = %v, want at least 2", values[0])
+	}
+}
+
+func TestSeedsAreReproducible(t *testing.T) {
+	a := Take(LogNormal(NewRand(7), 1, 1), 10)
+	b := Take(LogNormal(NewRand(7), 1, 1), 10)
+	if !slices.Equal(a, b) {
+		t.Errorf("same seed produced %v and %v", a, b)
+	}
+}
+
+func TestStepAndSpikes(t *testing.T) {
+	constant := GeneratorFunc(func() float64 { return 1 })
+
+	if got := Take(Step(constant, 2, 10), 4); !slices.Equal(got, []float64{1, 1, 11, 11}) {
+		t.Errorf("Step() = %v, want [1 1 11 11]", got)
+	}
+
+	spiked := 0
+	for _, v := range Take(Spikes(NewRand(1), constant, 0.1, 50), 10000) {
+		switch v {
+		case 50:
+			spiked++
+		case 1:
+		default:
+			t.Fatalf("Spikes() produced %v, want 1 or 50", v)
+		}
+	}
+	if spiked < 800 || spiked > 1200 {
+		t.Errorf("Spikes() spiked %d of 10000 samples, want about 1000", spiked)
+	}
+}
+
+func TestPace(t *testing.T) {
+	g := GeneratorFunc(func() float64 { return 3 })
+
+	var got []float64
+	Pace(g, 1e6, 5, nil, func(v float64) { got = append(got, v) })
+	if !slices.Equal(got, []float64{3, 3, 3, 3, 3}) {
+		t.Errorf("Pace() emitted %v, want five samples", got)
+	}
+
+	// Rates faster than the clock resolution must not panic
+	got = got[:0]
+	Pace(g, 1e12, 3, nil, func(v float64) { got = append(got, v) })
+	if len(got) != 3 {
+		t.Errorf("Pace() at 1e12/s emitted %d samples, want 3", len(got))
+	}
+
+	for _, rate := range []float64{0, -1, math.NaN()} {
+		Pace(g, rate, 5, nil, func(float64) { t.Errorf("Pace() at rate %v emitted a sample", rate) })
+	}
+
+	stop := make(chan struct{})
+	close(stop)
+	Pace(g, 1, 5, stop, func(float64) { t.Error("Pace() emitted after stop was closed") })
+}
@@ -0,0 +1,3 @@
+module github.com/kalpit-sharma-dev/math-stats
+
+go 1.23
@@ -0,0 +1,96 @@
+// Package hashring routes keyed samples to aggregator endpoints with
+// consistent hashing, so adding or removing an endpoint moves only about
+// 1/N of the keys, and every key can be replicated to several endpoints.
+package hashring
+
+import (
+	"hash/fnv"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// DefaultVirtualNodes is the number of ring positions per endpoint used
+// when New is given vnodes <= 0. More positions spread keys more evenly.
+const DefaultVirtualNodes = 160
+
+// Ring is an immutable consistent hash ring; build a new one when the
+// endpoints change
+type Ring struct {
+	points    []uint64 // Sorted ring positions
+	owners    []int    // Endpoint index of each position
+	endpoints []string
+}
+
+// New creates a ring placing each endpoint at vnodes positions
+func New(endpoints []string, vnodes int) *Ring {
+	if vnodes <= 0 {
+		vnodes = DefaultVirtualNodes
+	}
+	r := &Ring{endpoints: slices.Clone(endpoints)}
+	type point struct {
+		hash  uint64
+		owner int
+	}
+	points := make([]point, 0, len(endpoints)*vnodes)
+	for i, ep := range endpoints {
+		for v := 0; v < vnodes; v++ {
+			points = append(points, point{hash(ep + "#" + strconv.Itoa(v)), i})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].hash < points[j].hash })
+	for _, p := range points {
+		r.points = append(r.points, p.hash)
+		r.owners = append(r.owners, p.owner)
+	}
+	return r
+}
+
+// Endpoints returns the endpoints of the ring
+func (r *Ring) Endpoints() []string {
+	return slices.Clone(r.endpoints)
+}
+
+// Lookup returns the endpoint owning key, or "" for an empty ring
+func (r *Ring) Lookup(key string) string {
+	if eps := r.LookupN(key, 1); len(eps) > 0 {
+		return eps[0]
+	}
+	return ""
+}
+
+// LookupN returns up to replicas distinct endpoints for key, the owner
+// first, walking the ring clockwise
+func (r *Ring) LookupN(key string, replicas int) []string {
+	replicas = min(replicas, len(r.endpoints))
+	if replicas <= 0 {
+		return nil
+	}
+
+	h := hash(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	out := make([]string, 0, replicas)
+	seen := make([]bool, len(r.endpoints))
+	for n := 0; n < len(r.points) && len(out) < replicas; n++ {
+		owner := r.owners[(i+n)%len(r.points)]
+		if !seen[owner] {
+			seen[owner] = true
+			out = append(out, r.endpoints[owner])
+		}
+	}
+	return out
+}
+
+// hash returns the 64-bit FNV-1a hash of s, mixed with the MurmurHash3
+// finalizer because FNV alone clusters keys differing only in a suffix
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -0,0 +1,56 @@
+package hashring
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLookupN(t *testing.T) {
+	r := New([]string{"a", "b", "c"}, 0)
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprint("stream-", i)
+		eps := r.LookupN(key, 2)
+		if len(eps) != 2 || eps[0] == eps[1] {
+			t.Fatalf("LookupN(%q, 2) = %v, want two distinct endpoints", key, eps)
+		}
+		if eps[0] != r.Lookup(key) {
+			t.Fatalf("LookupN(%q, 2)[0] = %q, Lookup = %q", key, eps[0], r.Lookup(key))
+		}
+	}
+	if got := r.LookupN("k", 5); len(got) != 3 {
+		t.Errorf("LookupN with more replicas than endpoints = %v, want all 3", got)
+	}
+	if got := New(nil, 0).Lookup("k"); got != "" {
+		t.Errorf("Lookup on an empty ring = %q, want empty", got)
+	}
+}
+
+func TestBalanceAndStability(t *testing.T) {
+	const keys = 10000
+	before := New([]string{"a", "b", "c", "d"}, 0)
+	after := New([]string{"a", "b", "c", "d", "e"}, 0)
+
+	counts := map[string]int{}
+	moved := 0
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprint("stream-", i)
+		owner := before.Lookup(key)
+		counts[owner]++
+		if next := after.Lookup(key); next != owner {
+			if next != "e" {
+				t.Fatalf("key %q moved from %q to %q, not to the new endpoint", key, owner, next)
+			}
+			moved++
+		}
+	}
+
+	for ep, n := range counts {
+		if n < keys/4*7/10 || n > keys/4*13/10 {
+			t.Errorf("endpoint %q owns %d of %d keys, want about a quarter", ep, n, keys)
+		}
+	}
+	if moved < keys/5*6/10 || moved > keys/5*14/10 {
+		t.Errorf("adding a fifth endpoint moved %d of %d keys, want about a fifth", moved, keys)
+	}
+}
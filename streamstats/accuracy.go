@@ -0,0 +1,229 @@
+package streamstats
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	minCompression = 25
+	maxCompression = 3200
+)
+
+// accuracyQuantiles are compared against exact values when tuning
+var accuracyQuantiles = []float64{50, 90, 99}
+
+// accuracyWorker checks every interval how far a digest at the current
+// compression is from the exact percentiles of the window, and doubles the
+// compression when the error exceeds Options.AccuracyTarget or halves it
+// when the error is well below, keeping memory proportional to need
+func (ds *DataStreamStats) accuracyWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ds.tuneCompression()
+		case <-ds.stopChan:
+			return
+		}
+	}
+}
+
+// tuneCompression performs one accuracy check
+func (ds *DataStreamStats) tuneCompression() {
+	values := ds.windowValues()
+	if len(values) < 100 {
+		return
+	}
+
+	ds.epochLock.Lock()
+	compression := ds.compression
+	ds.epochLock.Unlock()
+
+	td := newTDigest(compression)
+	for _, v := range values {
+		td.add(v, 1)
+	}
+	sort.Float64s(values)
+	worst := 0.0
+	for _, p := range accuracyQuantiles {
+		exact := sortedPercentile(values, p)
+		err := math.Abs(td.quantile(p) - exact)
+		if exact != 0 {
+			err /= math.Abs(exact)
+		}
+		worst = max(worst, err)
+	}
+
+	target := ds.opts.AccuracyTarget
+	switch {
+	case worst > target:
+		compression = min(compression*2, maxCompression)
+	case worst < target/4:
+		compression = max(compression/2, minCompression)
+	default:
+		return
+	}
+
+	ds.epochLock.Lock()
+	ds.compression = compression
+	ds.epochDigest.compression = compression
+	ds.epochLock.Unlock()
+	if ds.decayed != nil {
+		ds.decayed.mu.Lock()
+		ds.decayed.digest.compression = compression
+		ds.decayed.mu.Unlock()
+	}
+}
+
+// GetCompression returns the current compression of the stream's digests
+func (ds *DataStreamStats) GetCompression() float64 {
+	ds.lazyInit()
+	ds.epochLock.Lock()
+	defer ds.epochLock.Unlock()
+	return ds.compression
+}
+
+// StatAccuracy describes whether a statistic is exact under the stream's
+// configuration and which samples it covers
+type StatAccuracy struct {
+	Statistic string
+	Exact     bool
+	Scope     string // "lifetime", "window" or "recency-weighted"
+	Detail    string // Method, error bound and caveats
+}
+
+// String formats e.g. "percentiles: approximate, window (last 1000 samples)"
+func (sa StatAccuracy) String() string {
+	exact := "exact"
+	if !sa.Exact {
+		exact = "approximate"
+	}
+	s := sa.Statistic + ": " + exact + ", " + sa.Scope
+	if sa.Detail != "" {
+		s += " (" + sa.Detail + ")"
+	}
+	return s
+}
+
+// AccuracyReport lists how each family of statistics is computed
+type AccuracyReport []StatAccuracy
+
+// String formats one statistic per line
+func (ar AccuracyReport) String() string {
+	lines := make([]string, len(ar))
+	for i, sa := range ar {
+		lines[i] = sa.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// AccuracyReport describes which statistics are exact and which are
+// approximate under the current configuration, and over which samples,
+// e.g. that GetPercentile covers only the window unless a sketch is set
+func (ds *DataStreamStats) AccuracyReport() AccuracyReport {
+	ds.lazyInit()
+	opts := ds.opts
+	var rounded string
+	if opts.Quantum > 0 {
+		rounded = fmt.Sprintf("values rounded to multiples of %g", opts.Quantum)
+	}
+	join := func(parts ...string) string {
+		return strings.Join(slices.DeleteFunc(parts, func(s string) bool { return s == "" }), "; ")
+	}
+
+	var accepted []string
+	if opts.Limiter != nil {
+		accepted = append(accepted, "samples shed by the rate limiter excluded")
+	}
+	if opts.NonNegative {
+		accepted = append(accepted, "negative samples rejected")
+	}
+	report := AccuracyReport{
+		{Statistic: "count, sum, min, max", Exact: true, Scope: "lifetime", Detail: join(accepted...)},
+		{Statistic: "mean, stddev, variance, skewness, kurtosis", Exact: true, Scope: "lifetime",
+			Detail: "streaming moments, subject to float64 rounding"},
+	}
+
+	// Checkpoint restores replace these under percentileLock
+	ds.percentileLock.Lock()
+	quantiles, nonNegative := ds.quantiles, ds.nonNegative
+	ds.percentileLock.Unlock()
+
+	sketch, exact := describeSketch(quantiles), false
+	if ee, ok := quantiles.(*ExactEstimator); ok {
+		ds.percentileLock.Lock()
+		st := ee.Status()
+		if !st.Exact {
+			sketch = join(describeSketch(ee.sketch), fmt.Sprintf("switched from exact at %d samples", st.SwitchedAt))
+		}
+		ds.percentileLock.Unlock()
+		exact = st.Exact && rounded == ""
+	}
+
+	if sketch != "" {
+		report = append(report,
+			StatAccuracy{Statistic: "median", Exact: exact, Scope: "lifetime", Detail: join(sketch, rounded)},
+			StatAccuracy{Statistic: "percentiles", Exact: exact, Scope: "lifetime", Detail: join(sketch, rounded)})
+	} else {
+		report = append(report, StatAccuracy{Statistic: "median", Exact: rounded == "", Scope: "lifetime",
+			Detail: join("median heaps holding every sample", rounded)})
+		if nonNegative != nil {
+			acc := (nonNegative.gamma - 1) / (nonNegative.gamma + 1) * 100
+			report = append(report, StatAccuracy{Statistic: "percentiles", Scope: "lifetime",
+				Detail: join(fmt.Sprintf("log buckets, within %.3g%% relative error", acc), rounded)})
+		} else {
+			window := fmt.Sprintf("last %d samples", opts.Capacity)
+			if opts.TimeWindow > 0 {
+				window = fmt.Sprintf("samples of the last %v, at most %d", opts.TimeWindow, opts.Capacity)
+			}
+			var compact string
+			if opts.CompactWindow {
+				compact = "stored as float32"
+			}
+			report = append(report, StatAccuracy{Statistic: "percentiles", Exact: rounded == "" && compact == "",
+				Scope: "window", Detail: join(window, compact, rounded)})
+		}
+	}
+
+	ds.epochLock.Lock()
+	compression := ds.compression
+	ds.epochLock.Unlock()
+	lifetime := fmt.Sprintf("t-digest, compression %g", compression)
+	if opts.AccuracyTarget > 0 {
+		lifetime += fmt.Sprintf(" auto-tuned for %.3g%% error", opts.AccuracyTarget*100)
+	}
+	report = append(report, StatAccuracy{Statistic: "ApproxLifetimePercentile", Scope: "lifetime", Detail: join(lifetime, rounded)})
+
+	if opts.DecayHalfLife > 0 {
+		report = append(report, StatAccuracy{Statistic: "decayed mean, decayed percentiles", Scope: "recency-weighted",
+			Detail: join(fmt.Sprintf("t-digest, half-life %v", opts.DecayHalfLife), rounded)})
+	}
+	if h := ds.histogram.Load(); h != nil {
+		report = append(report, StatAccuracy{Statistic: "histogram", Exact: true, Scope: "lifetime",
+			Detail: join(fmt.Sprintf("counts per bucket, resolution of %d buckets", len(h.bounds)+1), rounded)})
+	}
+	return report
+}
+
+// describeSketch names a quantile estimator and its accuracy, or returns
+// "" for none
+func describeSketch(q QuantileEstimator) string {
+	switch q := q.(type) {
+	case *TDigestEstimator:
+		return fmt.Sprintf("t-digest, compression %g", q.td.compression)
+	case *DDSketchEstimator:
+		return fmt.Sprintf("DDSketch, within %.3g%% relative error", (q.pos.gamma-1)/(q.pos.gamma+1)*100)
+	case *ExactEstimator:
+		return fmt.Sprintf("every sample kept below %d samples", q.limit)
+	case nil:
+		return ""
+	}
+	return fmt.Sprintf("%T", q)
+}
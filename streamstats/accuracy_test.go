@@ -0,0 +1,89 @@
+package streamstats
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccuracyReport(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{"default", Options{Capacity: 1000}, []string{
+			"count, sum, min, max: exact, lifetime",
+			"median: exact, lifetime (median heaps holding every sample)",
+			"percentiles: exact, window (last 1000 samples)",
+		}},
+		{"tdigest", Options{Capacity: 10, Quantiles: TDigest(200), Quantum: 0.5}, []string{
+			"median: approximate, lifetime (t-digest, compression 200; values rounded to multiples of 0.5)",
+			"percentiles: approximate, lifetime (t-digest, compression 200; values rounded to multiples of 0.5)",
+		}},
+		{"nonnegative", Options{Capacity: 10, NonNegative: true, Limiter: NewTokenBucket(1, 1)}, []string{
+			"count, sum, min, max: exact, lifetime (samples shed by the rate limiter excluded; negative samples rejected)",
+			"percentiles: approximate, lifetime (log buckets, within 1% relative error)",
+		}},
+		{"timed", Options{Capacity: 500, TimeWindow: time.Minute, CompactWindow: true, DecayHalfLife: time.Second,
+			Histogram: LinearBuckets(0, 1, 3)}, []string{
+			"percentiles: approximate, window (samples of the last 1m0s, at most 500; stored as float32)",
+			"decayed mean, decayed percentiles: approximate, recency-weighted (t-digest, half-life 1s)",
+			"histogram: exact, lifetime (counts per bucket, resolution of 4 buckets)",
+		}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			ds := NewDataStreamStatsWithOptions(c.opts)
+			defer ds.Stop()
+			report := ds.AccuracyReport().String()
+			for _, line := range c.want {
+				if !strings.Contains(report, line+"\n") && !strings.HasSuffix(report, line) {
+					t.Errorf("AccuracyReport() lacks %q:\n%s", line, report)
+				}
+			}
+		})
+	}
+}
+
+func TestTuneCompression(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	tuned := func(target float64) *DataStreamStats {
+		ds := NewDataStreamStatsWithOptions(Options{
+			Capacity:         5000,
+			AccuracyTarget:   target,
+			AccuracyInterval: time.Hour, // Tuned by hand below
+			DecayHalfLife:    time.Minute,
+		})
+		t.Cleanup(ds.Stop)
+		for i := 0; i < 5000; i++ {
+			ds.AddNumber(r.ExpFloat64())
+		}
+		return ds
+	}
+
+	// A loose target lets compression fall to the minimum
+	loose := tuned(0.5)
+	start := loose.GetCompression()
+	for i := 0; i < 10; i++ {
+		loose.tuneCompression()
+	}
+	if got := loose.GetCompression(); got != minCompression {
+		t.Errorf("compression for a 50%% target = %v (from %v), want %v", got, start, float64(minCompression))
+	}
+
+	// An unreachable target drives it to the maximum
+	strict := tuned(1e-9)
+	for i := 0; i < 10; i++ {
+		strict.tuneCompression()
+	}
+	if got := strict.GetCompression(); got != maxCompression {
+		t.Errorf("compression for a 1e-9 target = %v, want %v", got, float64(maxCompression))
+	}
+	if got := strict.decayed.digest.compression; got != maxCompression {
+		t.Errorf("decayed digest compression = %v, want it tuned too", got)
+	}
+	if report := strict.AccuracyReport().String(); !strings.Contains(report, "auto-tuned") {
+		t.Errorf("AccuracyReport() does not mention tuning:\n%s", report)
+	}
+}
@@ -0,0 +1,73 @@
+package streamstats
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// AdaptiveLimit computes a concurrency limit from a latency stream in the
+// style of Netflix's gradient limiter. The window median is compared with a
+// slowly moving baseline: while latency stays at the baseline the limit
+// grows by a queue allowance of √limit, and as latency rises above it the
+// limit shrinks by the gradient baseline/median (at most halving per update).
+// The baseline follows sustained shifts in latency, which then stop
+// throttling.
+type AdaptiveLimit struct {
+	ds        *DataStreamStats
+	minLimit  float64
+	maxLimit  float64
+	smoothing float64 // Weight of the new estimate in each update
+
+	mu       sync.Mutex
+	limit    float64
+	baseline float64 // Long-term EWMA of the window median, 0 until set
+}
+
+// baselineWeight is the EWMA weight of each update in the baseline latency
+const baselineWeight = 0.01
+
+// NewAdaptiveLimit creates a limit over the latency samples of ds, starting
+// at initial and kept within [minLimit, maxLimit]
+func NewAdaptiveLimit(ds *DataStreamStats, initial, minLimit, maxLimit int) *AdaptiveLimit {
+	ds.lazyInit()
+	return &AdaptiveLimit{
+		ds:        ds,
+		minLimit:  float64(minLimit),
+		maxLimit:  float64(maxLimit),
+		smoothing: 0.2,
+		limit:     float64(initial),
+	}
+}
+
+// Update recomputes the limit from the current window and returns it.
+// Call it periodically, e.g. once per second or every N completed requests.
+func (al *AdaptiveLimit) Update() int {
+	values := al.ds.windowValues()
+	sort.Float64s(values)
+	short := sortedPercentile(values, 50)
+
+	al.mu.Lock()
+	defer al.mu.Unlock()
+
+	if len(values) == 0 || short <= 0 {
+		return int(al.limit)
+	}
+	if al.baseline == 0 {
+		al.baseline = short
+	}
+	al.baseline += baselineWeight * (short - al.baseline)
+
+	gradient := math.Max(0.5, math.Min(1, al.baseline/short))
+	next := al.limit*gradient + math.Sqrt(al.limit)
+	al.limit = al.limit*(1-al.smoothing) + next*al.smoothing
+	al.limit = math.Max(al.minLimit, math.Min(al.maxLimit, al.limit))
+	return int(al.limit)
+}
+
+// Limit returns the last computed limit
+func (al *AdaptiveLimit) Limit() int {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	return int(al.limit)
+}
@@ -0,0 +1,56 @@
+package streamstats
+
+import "testing"
+
+// fillWindow replaces the window of ds with v
+func fillWindow(ds *DataStreamStats, v float64, n int) {
+	for i := 0; i < n; i++ {
+		ds.AddNumber(v)
+	}
+}
+
+func TestAdaptiveLimit(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+	al := NewAdaptiveLimit(ds, 10, 5, 100)
+
+	if got := al.Update(); got != 10 {
+		t.Errorf("Update() without samples = %d, want the initial 10", got)
+	}
+
+	// Steady latency grows the limit up to the maximum
+	fillWindow(ds, 10, 10)
+	prev := al.Limit()
+	for i := 0; i < 200; i++ {
+		if got := al.Update(); got < prev {
+			t.Fatalf("limit fell from %d to %d at steady latency", prev, got)
+		}
+		prev = al.Limit()
+	}
+	if prev != 100 {
+		t.Errorf("limit after steady latency = %d, want the maximum 100", prev)
+	}
+
+	// A latency spike shrinks it, but never below the minimum
+	fillWindow(ds, 100, 10)
+	for i := 0; i < 20; i++ {
+		al.Update()
+	}
+	if got := al.Limit(); got >= 50 {
+		t.Errorf("limit after a 10x latency spike = %d, want it to shrink below 50", got)
+	}
+	for i := 0; i < 50; i++ {
+		al.Update()
+	}
+	if got := al.Limit(); got < 5 {
+		t.Errorf("limit = %d, want at least the minimum 5", got)
+	}
+
+	// The baseline adapts to a sustained shift, which stops throttling
+	for i := 0; i < 2000; i++ {
+		al.Update()
+	}
+	if got := al.Limit(); got != 100 {
+		t.Errorf("limit after a sustained shift = %d, want it back at 100", got)
+	}
+}
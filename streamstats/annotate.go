@@ -0,0 +1,72 @@
+package streamstats
+
+import (
+	"slices"
+	"time"
+)
+
+// maxAnnotations is the number of annotations a stream retains
+const maxAnnotations = 1000
+
+// Annotation is a timestamped note on a stream, such as a deploy or a
+// config change, that explains shifts in its distribution
+type Annotation struct {
+	Time time.Time `json:"time"`
+	Text string    `json:"text"`
+}
+
+// Annotate attaches text to the stream at the current time
+func (ds *DataStreamStats) Annotate(text string) {
+	ds.lazyInit()
+	ds.AnnotateAt(ds.now(), text)
+}
+
+// AnnotateAt attaches text to the stream at t. The latest 1000
+// annotations are kept, ordered by time.
+func (ds *DataStreamStats) AnnotateAt(t time.Time, text string) {
+	ds.lazyInit()
+	ds.annotationLock.Lock()
+	defer ds.annotationLock.Unlock()
+
+	a := Annotation{Time: t, Text: text}
+	i, _ := slices.BinarySearchFunc(ds.annotations, a, func(x, y Annotation) int {
+		if x.Time.After(y.Time) {
+			return 1
+		}
+		return -1 // Equal times keep insertion order
+	})
+	ds.annotations = slices.Insert(ds.annotations, i, a)
+	if len(ds.annotations) > maxAnnotations {
+		ds.annotations = slices.Delete(ds.annotations, 0, len(ds.annotations)-maxAnnotations)
+	}
+}
+
+// Annotations returns the annotations in [from, to); a zero bound leaves
+// that side open
+func (ds *DataStreamStats) Annotations(from, to time.Time) []Annotation {
+	ds.lazyInit()
+	ds.annotationLock.Lock()
+	defer ds.annotationLock.Unlock()
+	return ds.annotationsLocked(from, to)
+}
+
+// annotationsLocked returns the annotations in [from, to); callers hold
+// annotationLock
+func (ds *DataStreamStats) annotationsLocked(from, to time.Time) []Annotation {
+	var out []Annotation
+	for _, a := range ds.annotations {
+		if (from.IsZero() || !a.Time.Before(from)) && (to.IsZero() || a.Time.Before(to)) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// annotateRollups attaches to each rollup the annotations of its period
+func (ds *DataStreamStats) annotateRollups(rs []Rollup) {
+	ds.annotationLock.Lock()
+	defer ds.annotationLock.Unlock()
+	for i := range rs {
+		rs[i].Annotations = ds.annotationsLocked(rs[i].Start, rs[i].End)
+	}
+}
@@ -0,0 +1,103 @@
+package streamstats
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnnotations(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Now: func() time.Time { return t0.Add(time.Hour) }})
+	defer ds.Stop()
+
+	ds.AnnotateAt(t0.Add(2*time.Minute), "config changed")
+	ds.AnnotateAt(t0, "deploy v1")
+	ds.Annotate("deploy v2")
+
+	all := ds.Annotations(time.Time{}, time.Time{})
+	var texts []string
+	for _, a := range all {
+		texts = append(texts, a.Text)
+	}
+	if got, want := strings.Join(texts, ","), "deploy v1,config changed,deploy v2"; got != want {
+		t.Errorf("Annotations() = %s, want %s", got, want)
+	}
+	if got := ds.Annotations(t0.Add(time.Minute), t0.Add(time.Hour)); len(got) != 1 || got[0].Text != "config changed" {
+		t.Errorf("Annotations(1m, 1h) = %v, want only the config change", got)
+	}
+	if got := ds.Snapshot().Annotations; len(got) != 3 {
+		t.Errorf("snapshot has %d annotations, want 3", len(got))
+	}
+
+	for i := 0; i < maxAnnotations; i++ {
+		ds.AnnotateAt(t0.Add(time.Duration(i)*time.Second), "bulk")
+	}
+	if got := ds.Annotations(time.Time{}, time.Time{}); len(got) != maxAnnotations || got[len(got)-1].Text != "deploy v2" {
+		t.Errorf("kept %d annotations ending with %q, want the latest %d", len(got), got[len(got)-1].Text, maxAnnotations)
+	}
+}
+
+func TestAnnotationsInHistory(t *testing.T) {
+	t0 := time.Unix(1699999980, 0) // Minute-aligned
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, EventWindow: time.Minute, Now: func() time.Time { return t0.Add(time.Hour) }})
+	defer ds.Stop()
+
+	ds.AddNumberAt(t0.Add(10*time.Second), 1)
+	ds.AnnotateAt(t0.Add(30*time.Second), "deploy")
+	ds.AddNumberAt(t0.Add(90*time.Second), 2)
+	ds.AdvanceWatermark(t0.Add(3 * time.Minute))
+
+	closed := ds.ClosedWindows()
+	if len(closed) != 2 {
+		t.Fatalf("closed %d windows, want 2", len(closed))
+	}
+	if got := closed[0].Annotations; len(got) != 1 || got[0].Text != "deploy" {
+		t.Errorf("first window annotations = %v, want the deploy", got)
+	}
+	if got := closed[1].Annotations; len(got) != 0 {
+		t.Errorf("second window annotations = %v, want none", got)
+	}
+	if got := MergeRollups(closed...).Annotations; len(got) != 1 {
+		t.Errorf("merged rollup annotations = %v, want the deploy", got)
+	}
+
+	data, err := ds.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewDataStreamStatsWithOptions(Options{Capacity: 10, EventWindow: time.Minute})
+	defer restored.Stop()
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.Annotations(time.Time{}, time.Time{}); len(got) != 1 || !got[0].Time.Equal(t0.Add(30*time.Second)) {
+		t.Errorf("restored annotations = %v, want the deploy", got)
+	}
+}
+
+func TestCompareAnnotations(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	now := t0.Add(-time.Hour)
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Now: func() time.Time { return now }})
+	defer ds.Stop()
+
+	ds.AddNumber(1)
+	ds.AnnotateAt(t0.Add(-time.Minute), "before the baseline")
+	now = t0
+	ds.SetEpoch("v2")
+	ds.AnnotateAt(t0.Add(time.Minute), "deploy v2")
+	ds.AddNumber(2)
+	now = t0.Add(time.Hour)
+	rs := ds.Rollups()
+
+	c := Compare(rs[0], rs[1])
+	if len(c.Annotations) != 1 || c.Annotations[0].Text != "deploy v2" {
+		t.Errorf("Compare() annotations = %v, want the deploy", c.Annotations)
+	}
+	var sb strings.Builder
+	RenderComparison(&sb, c)
+	if !strings.Contains(sb.String(), "deploy v2") {
+		t.Errorf("RenderComparison() = %q, want the deploy", sb.String())
+	}
+}
@@ -0,0 +1,226 @@
+package streamstats
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kalpit-sharma-dev/math-stats/container"
+)
+
+// AnomalyRule selects how an AnomalyDetector judges a sample
+type AnomalyRule int
+
+const (
+	// ZScore flags samples more than Threshold (default 3) standard
+	// deviations from the running mean
+	ZScore AnomalyRule = iota
+	// IQR flags samples outside Tukey's fences, Threshold (default 1.5)
+	// interquartile ranges beyond the quartiles of the last Window samples
+	IQR
+	// EWMABand flags samples more than Threshold (default 3) exponentially
+	// weighted standard deviations from the exponentially weighted mean,
+	// which follows level shifts faster than ZScore
+	EWMABand
+)
+
+// String returns the rule name used in Anomaly and statistic names
+func (r AnomalyRule) String() string {
+	switch r {
+	case ZScore:
+		return "zscore"
+	case IQR:
+		return "iqr"
+	case EWMABand:
+		return "ewma"
+	}
+	return fmt.Sprintf("AnomalyRule(%d)", int(r))
+}
+
+// AnomalyConfig configures an AnomalyDetector
+type AnomalyConfig struct {
+	Rule      AnomalyRule
+	Threshold float64 // Rule-specific, see the rules
+	Window    int     // IQR samples, default 100
+	Lambda    float64 // EWMABand smoothing factor, default 0.2
+	WarmUp    int     // Samples observed before judging, default 30
+
+	// OnAnomaly is called for every anomaly; when the detector is attached
+	// to a stream it runs inside AddNumber and must not call back into it
+	OnAnomaly func(Anomaly)
+
+	// Anomalies receives every anomaly without blocking; anomalies the
+	// channel has no room for are counted by Dropped
+	Anomalies chan<- Anomaly
+}
+
+// Anomaly is a sample an AnomalyDetector flagged
+type Anomaly struct {
+	Value        float64
+	Time         time.Time
+	Rule         AnomalyRule
+	Lower, Upper float64 // Band the value fell outside of
+}
+
+// AnomalyDetector flags outliers as samples arrive. It implements
+// Statistic, so it can be attached with RegisterStatistic or
+// DetectAnomalies; its Value is the number of anomalies so far. Flagged
+// samples still update the baseline, so a lasting level shift stops
+// being flagged once the baseline catches up.
+type AnomalyDetector struct {
+	mu      sync.Mutex
+	cfg     AnomalyConfig
+	n       int64
+	moments moments         // ZScore baseline
+	recent  *container.Ring // IQR window
+	ewma    float64         // EWMABand mean
+	ewmVar  float64         // EWMABand variance
+	count   int64
+	dropped int64
+}
+
+// NewAnomalyDetector creates a detector, filling in defaults
+func NewAnomalyDetector(cfg AnomalyConfig) *AnomalyDetector {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = 3
+		if cfg.Rule == IQR {
+			cfg.Threshold = 1.5
+		}
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 100
+	}
+	if cfg.Lambda <= 0 || cfg.Lambda > 1 {
+		cfg.Lambda = 0.2
+	}
+	if cfg.WarmUp <= 0 {
+		cfg.WarmUp = 30
+	}
+	ad := &AnomalyDetector{cfg: cfg}
+	if cfg.Rule == IQR {
+		ad.recent = container.NewRing(cfg.Window)
+	}
+	return ad
+}
+
+// DetectAnomalies attaches a new detector to the stream
+func (ds *DataStreamStats) DetectAnomalies(cfg AnomalyConfig) (*AnomalyDetector, error) {
+	ds.lazyInit()
+	ad := NewAnomalyDetector(cfg)
+	if err := ds.RegisterStatistic(ad); err != nil {
+		return nil, err
+	}
+	return ad, nil
+}
+
+// Name implements Statistic
+func (ad *AnomalyDetector) Name() string { return "anomalies_" + ad.cfg.Rule.String() }
+
+// Observe implements Statistic
+func (ad *AnomalyDetector) Observe(x float64) {
+	if a, ok := ad.Check(x); ok {
+		if ad.cfg.OnAnomaly != nil {
+			ad.cfg.OnAnomaly(a)
+		}
+		if ad.cfg.Anomalies != nil {
+			select {
+			case ad.cfg.Anomalies <- a:
+			default:
+				ad.mu.Lock()
+				ad.dropped++
+				ad.mu.Unlock()
+			}
+		}
+	}
+}
+
+// Check judges x against the baseline, then adds it to the baseline
+func (ad *AnomalyDetector) Check(x float64) (Anomaly, bool) {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+
+	lo, hi, judged := ad.band()
+	ad.n++
+	switch ad.cfg.Rule {
+	case ZScore:
+		ad.moments.add(x)
+	case IQR:
+		ad.recent.Add(x)
+	case EWMABand:
+		if ad.n == 1 {
+			ad.ewma = x
+		} else {
+			// West's exponentially weighted variance
+			d := x - ad.ewma
+			ad.ewma += ad.cfg.Lambda * d
+			ad.ewmVar = (1 - ad.cfg.Lambda) * (ad.ewmVar + ad.cfg.Lambda*d*d)
+		}
+	}
+
+	if !judged || (x >= lo && x <= hi) {
+		return Anomaly{}, false
+	}
+	ad.count++
+	return Anomaly{Value: x, Time: time.Now(), Rule: ad.cfg.Rule, Lower: lo, Upper: hi}, true
+}
+
+// band returns the current normal range; judged is false during warm-up
+func (ad *AnomalyDetector) band() (lo, hi float64, judged bool) {
+	if ad.n < int64(ad.cfg.WarmUp) {
+		return 0, 0, false
+	}
+	k := ad.cfg.Threshold
+	switch ad.cfg.Rule {
+	case ZScore:
+		sd := math.Sqrt(ad.moments.variance(1))
+		return ad.moments.mean - k*sd, ad.moments.mean + k*sd, true
+	case IQR:
+		values := ad.recent.Values()
+		slices.Sort(values)
+		q1, q3 := sortedPercentile(values, 25), sortedPercentile(values, 75)
+		return q1 - k*(q3-q1), q3 + k*(q3-q1), true
+	case EWMABand:
+		sd := math.Sqrt(ad.ewmVar)
+		return ad.ewma - k*sd, ad.ewma + k*sd, true
+	}
+	return 0, 0, false
+}
+
+// Value implements Statistic
+func (ad *AnomalyDetector) Value() float64 {
+	return float64(ad.Count())
+}
+
+// Count returns the number of anomalies flagged so far
+func (ad *AnomalyDetector) Count() int64 {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+	return ad.count
+}
+
+// Dropped returns the anomalies AnomalyConfig.Anomalies had no room for
+func (ad *AnomalyDetector) Dropped() int64 {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+	return ad.dropped
+}
+
+// Merge implements Statistic; baselines of different streams are not
+// combined, so ad keeps its own and merging streams never fails on it
+func (ad *AnomalyDetector) Merge(Statistic) error {
+	return nil
+}
+
+// Reset implements Statistic, restarting warm-up
+func (ad *AnomalyDetector) Reset() {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+	ad.n, ad.count, ad.dropped = 0, 0, 0
+	ad.moments = moments{}
+	ad.ewma, ad.ewmVar = 0, 0
+	if ad.recent != nil {
+		ad.recent.Reset()
+	}
+}
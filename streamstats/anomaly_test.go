@@ -0,0 +1,103 @@
+package streamstats
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestAnomalyRules(t *testing.T) {
+	for _, rule := range []AnomalyRule{ZScore, IQR, EWMABand} {
+		t.Run(rule.String(), func(t *testing.T) {
+			var seen []Anomaly
+			ad := NewAnomalyDetector(AnomalyConfig{Rule: rule, OnAnomaly: func(a Anomaly) { seen = append(seen, a) }})
+
+			r := rand.New(rand.NewSource(1))
+			for i := 0; i < 500; i++ {
+				ad.Observe(100 + r.NormFloat64())
+			}
+			normal := ad.Count()
+			ad.Observe(150)
+
+			if got := ad.Count(); got != normal+1 {
+				t.Fatalf("Count() after a spike = %d, want %d", got, normal+1)
+			}
+			// Gaussian noise only rarely leaves the band
+			if normal > 10 {
+				t.Errorf("%d of 500 normal samples flagged", normal)
+			}
+			last := seen[len(seen)-1]
+			if last.Value != 150 || last.Rule != rule || last.Upper >= 150 || last.Time.IsZero() {
+				t.Errorf("anomaly = %+v, want 150 above the band", last)
+			}
+		})
+	}
+}
+
+func TestAnomalyWarmUpAndChannel(t *testing.T) {
+	ch := make(chan Anomaly, 1)
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+	ad, err := ds.DetectAnomalies(AnomalyConfig{Rule: ZScore, WarmUp: 5, Anomalies: ch})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Wild values during warm-up are not judged
+	for _, v := range []float64{1, 1000, 1, 2, 1} {
+		ds.AddNumber(v)
+	}
+	if got := ad.Count(); got != 0 {
+		t.Errorf("Count() during warm-up = %d, want 0", got)
+	}
+
+	for _, v := range []float64{1, 2, 1, 2, 1, 2, 1, 2} {
+		ds.AddNumber(v)
+	}
+	ds.AddNumber(-5000)
+	ds.AddNumber(-9000)
+	if a := <-ch; a.Value != -5000 {
+		t.Errorf("first anomaly = %v, want -5000", a.Value)
+	}
+	if got := ad.Dropped(); got != 1 {
+		t.Errorf("Dropped() = %d, want 1 with a full channel", got)
+	}
+	if got, _ := ds.GetStatistic("anomalies_zscore"); got != 2 {
+		t.Errorf("GetStatistic() = %v, want 2", got)
+	}
+
+	ad.Reset()
+	if ad.Count() != 0 || ad.Dropped() != 0 {
+		t.Error("Reset() kept counters")
+	}
+}
+
+func TestAnomalyDetectorMerge(t *testing.T) {
+	newStream := func() (*DataStreamStats, *AnomalyDetector) {
+		ds := NewDataStreamStats(10)
+		ad, err := ds.DetectAnomalies(AnomalyConfig{Rule: ZScore, WarmUp: 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range 10 {
+			ds.AddNumber(float64(i % 2))
+		}
+		return ds, ad
+	}
+	ds, ad := newStream()
+	defer ds.Stop()
+	other, _ := newStream()
+	defer other.Stop()
+	ds.AddNumber(1000)
+	before := ad.Count()
+
+	// The detector keeps its own baseline, so the streams still merge
+	if err := ds.Merge(other); err != nil {
+		t.Fatalf("Merge() = %v", err)
+	}
+	if got := ds.Count(); got != 21 {
+		t.Errorf("Count() after Merge = %d, want 21", got)
+	}
+	if got := ad.Count(); got != before {
+		t.Errorf("detector Count() after Merge = %d, want %d", got, before)
+	}
+}
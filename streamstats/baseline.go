@@ -0,0 +1,59 @@
+package streamstats
+
+import (
+	"math"
+	"time"
+)
+
+// Normalized expresses window statistics relative to a baseline snapshot,
+// e.g. a P99 of 1.35 means "1.35x of last week"
+type Normalized struct {
+	BaselineName string
+	BaselineTime time.Time
+	Mean         float64
+	P50          float64
+	P95          float64
+	P99          float64
+}
+
+// SetBaseline makes subsequent snapshots include values normalized to
+// base's window statistics
+func (ds *DataStreamStats) SetBaseline(base Snapshot) {
+	ds.lazyInit()
+	ds.cachedLock.Lock()
+	defer ds.cachedLock.Unlock()
+	ds.baseline = &base
+}
+
+// ClearBaseline stops normalizing snapshots
+func (ds *DataStreamStats) ClearBaseline() {
+	ds.lazyInit()
+	ds.cachedLock.Lock()
+	defer ds.cachedLock.Unlock()
+	ds.baseline = nil
+}
+
+// normalize divides current window values by the baseline's
+func normalize(cur WindowStats, base *Snapshot) *Normalized {
+	return &Normalized{
+		BaselineName: base.Name,
+		BaselineTime: base.Time,
+		Mean:         ratio(cur.Mean, base.Window.Mean),
+		P50:          ratio(cur.P50, base.Window.P50),
+		P95:          ratio(cur.P95, base.Window.P95),
+		P99:          ratio(cur.P99, base.Window.P99),
+	}
+}
+
+// ratio returns x/base, or NaN when base is zero
+func ratio(x, base float64) float64 {
+	if base == 0 {
+		return math.NaN()
+	}
+	return x / base
+}
+
+// PercentChange converts a normalized ratio to a percent change, e.g. 1.35 to 35
+func PercentChange(ratio float64) float64 {
+	return (ratio - 1) * 100
+}
@@ -0,0 +1,51 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBaseline(t *testing.T) {
+	lastWeek := NewDataStreamStatsWithOptions(Options{Name: "last week", Capacity: 100})
+	defer lastWeek.Stop()
+	ds := NewDataStreamStats(100)
+	defer ds.Stop()
+	for i := 1; i <= 100; i++ {
+		lastWeek.AddNumber(float64(i))
+		ds.AddNumber(float64(i) * 1.5)
+	}
+	base := lastWeek.Snapshot()
+
+	if snap := ds.Snapshot(); snap.Normalized != nil {
+		t.Errorf("Normalized without a baseline = %+v, want nil", snap.Normalized)
+	}
+	ds.SetBaseline(base)
+	n := ds.Snapshot().Normalized
+	if n == nil {
+		t.Fatal("Normalized = nil after SetBaseline")
+	}
+	if n.BaselineName != "last week" || !n.BaselineTime.Equal(base.Time) {
+		t.Errorf("baseline %q at %v, want last week at %v", n.BaselineName, n.BaselineTime, base.Time)
+	}
+	for _, f := range []struct {
+		name string
+		got  float64
+	}{{"Mean", n.Mean}, {"P50", n.P50}, {"P95", n.P95}, {"P99", n.P99}} {
+		if math.Abs(f.got-1.5) > 1e-9 {
+			t.Errorf("%s = %v, want 1.5x the baseline", f.name, f.got)
+		}
+	}
+	if got := PercentChange(n.P99); math.Abs(got-50) > 1e-9 {
+		t.Errorf("PercentChange(%v) = %v, want 50", n.P99, got)
+	}
+
+	ds.ClearBaseline()
+	if snap := ds.Snapshot(); snap.Normalized != nil {
+		t.Errorf("Normalized after ClearBaseline = %+v, want nil", snap.Normalized)
+	}
+
+	// A zero baseline has no meaningful ratio
+	if got := normalize(ds.GetWindowStats(), &Snapshot{}); !math.IsNaN(got.Mean) {
+		t.Errorf("ratio to an empty baseline = %v, want NaN", got.Mean)
+	}
+}
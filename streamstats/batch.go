@@ -0,0 +1,28 @@
+package streamstats
+
+import "time"
+
+// Number is the set of numeric types AddValues accepts
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// AddBatch adds values in order under a single acquisition of the stream
+// lock, which is much cheaper than one AddNumber per value
+func (ds *DataStreamStats) AddBatch(values []float64) {
+	ds.lazyInit()
+	AddValues(ds, values)
+}
+
+// AddValues adds values of any numeric type like AddBatch. Integers beyond
+// 2^53 lose precision in the conversion to float64.
+func AddValues[T Number](ds *DataStreamStats, values []T) {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	for _, v := range values {
+		ds.add(time.Time{}, float64(v))
+	}
+}
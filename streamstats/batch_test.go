@@ -0,0 +1,52 @@
+package streamstats
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestAddBatch(t *testing.T) {
+	one := NewDataStreamStatsWithOptions(Options{Capacity: 100, Strict: true})
+	batched := NewDataStreamStatsWithOptions(Options{Capacity: 100, Strict: true})
+	defer one.Stop()
+	defer batched.Stop()
+
+	r := rand.New(rand.NewSource(1))
+	values := make([]float64, 1000)
+	for i := range values {
+		values[i] = r.NormFloat64()
+		one.AddNumber(values[i])
+	}
+	batched.AddBatch(values[:500])
+	batched.AddBatch(values[500:])
+
+	if got, want := batched.Stats(), one.Stats(); got != want {
+		t.Errorf("Stats() after AddBatch = %+v, want %+v", got, want)
+	}
+}
+
+func TestAddValues(t *testing.T) {
+	type millis int32
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+
+	AddValues(ds, []millis{3, 1, 2})
+	AddValues(ds, []uint8{4})
+	if got := ds.Stats(); got.Count != 4 || got.Sum != 10 || got.Median != 2.5 || got.Max != 4 {
+		t.Errorf("Stats() = %+v, want 4 samples 1..4", got)
+	}
+}
+
+func TestAddNumberDoesNotAllocate(t *testing.T) {
+	ds := NewDataStreamStats(100)
+	defer ds.Stop()
+	for i := 0; i < 10000; i++ {
+		ds.AddNumber(float64(i))
+	}
+
+	// Heap growth is amortized; boxing would cost an allocation per sample
+	allocs := testing.AllocsPerRun(1000, func() { ds.AddNumber(rand.Float64()) })
+	if allocs >= 1 {
+		t.Errorf("AddNumber allocates %v times per sample, want amortized 0", allocs)
+	}
+}
@@ -0,0 +1,130 @@
+package streamstats
+
+import (
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+func BenchmarkAddNumber(b *testing.B) {
+	stats := NewDataStreamStats(1000) // Ring buffer for last 1000 elements
+
+	// Benchmark adding numbers
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stats.AddNumber(rand.Float64() * 1000)
+	}
+}
+
+func BenchmarkGetMean(b *testing.B) {
+	stats := NewDataStreamStats(1000)
+
+	// Prepopulate with random data
+	for i := 0; i < 1000; i++ {
+		stats.AddNumber(rand.Float64() * 1000)
+	}
+
+	// Benchmark getting the mean
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stats.GetMean()
+	}
+}
+
+func BenchmarkGetMedian(b *testing.B) {
+	stats := NewDataStreamStats(1000)
+
+	// Prepopulate with random data
+	for i := 0; i < 1000; i++ {
+		stats.AddNumber(rand.Float64() * 1000)
+	}
+
+	// Benchmark getting the median
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stats.GetMedian()
+	}
+}
+
+func BenchmarkGetPercentile(b *testing.B) {
+	stats := NewDataStreamStats(1000)
+
+	// Prepopulate with random data
+	for i := 0; i < 1000; i++ {
+		stats.AddNumber(rand.Float64() * 1000)
+	}
+
+	// Benchmark getting the 95th percentile
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stats.GetPercentile(95)
+	}
+}
+
+func BenchmarkAddNumberParallel(b *testing.B) {
+	stats := NewDataStreamStats(1000)
+
+	// Benchmark adding numbers from GOMAXPROCS goroutines
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			stats.AddNumber(rand.Float64() * 1000)
+		}
+	})
+}
+
+func BenchmarkAddBatch(b *testing.B) {
+	stats := NewDataStreamStats(1000)
+	batch := make([]float64, 1000)
+	for i := range batch {
+		batch[i] = rand.Float64() * 1000
+	}
+
+	// Benchmark adding numbers in batches, b.N counts samples
+	b.ResetTimer()
+	for i := 0; i < b.N; i += len(batch) {
+		stats.AddBatch(batch[:min(len(batch), b.N-i)])
+	}
+}
+
+func BenchmarkSnapshot(b *testing.B) {
+	stats := NewDataStreamStats(1000)
+
+	// Prepopulate with random data
+	for i := 0; i < 10000; i++ {
+		stats.AddNumber(rand.Float64() * 1000)
+	}
+
+	// Benchmark taking a snapshot
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stats.Snapshot()
+	}
+}
+
+func BenchmarkMemoryPerStream(b *testing.B) {
+	const streams, samples = 100, 1000
+
+	// Measure heap growth for streams holding a full window each
+	for i := 0; i < b.N; i++ {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		all := make([]*DataStreamStats, streams)
+		for s := range all {
+			all[s] = NewDataStreamStats(1000)
+			for j := 0; j < samples; j++ {
+				all[s].AddNumber(rand.Float64() * 1000)
+			}
+		}
+
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/streams, "bytes/stream")
+
+		for _, s := range all {
+			s.Stop()
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package streamstats
+
+import "math"
+
+// CountBetween returns how many samples fall in (lo, hi], e.g. how many
+// requests took between 100ms and 300ms. The median heaps answer it
+// exactly in O(n); with a sketch it comes from Options.Histogram, exact
+// when lo and hi are bucket bounds and interpolated within buckets
+// otherwise, or else from the sketch's quantiles.
+func (ds *DataStreamStats) CountBetween(lo, hi float64) float64 {
+	ds.lazyInit()
+	count, _ := ds.between(lo, hi)
+	return count
+}
+
+// SumBetween returns the sum of the samples in (lo, hi], answered like
+// CountBetween; histogram buckets contribute their overlap midpoint per
+// sample
+func (ds *DataStreamStats) SumBetween(lo, hi float64) float64 {
+	ds.lazyInit()
+	_, sum := ds.between(lo, hi)
+	return sum
+}
+
+// between returns the count and sum of the samples in (lo, hi]
+func (ds *DataStreamStats) between(lo, hi float64) (count, sum float64) {
+	if !(lo < hi) {
+		return 0, 0
+	}
+
+	ds.percentileLock.Lock()
+	quantiles := ds.quantiles
+	ds.percentileLock.Unlock()
+
+	if quantiles == nil {
+		ds.heapLock.Lock()
+		defer ds.heapLock.Unlock()
+		for _, h := range [][]float64{ds.lower, ds.upper} {
+			for _, v := range h {
+				if v > lo && v <= hi {
+					count++
+					sum += v
+				}
+			}
+		}
+		return count, sum
+	}
+
+	if h := ds.histogram.Load(); h != nil {
+		ds.minMaxLock.Lock()
+		minVal, maxVal := ds.minVal, ds.maxVal
+		ds.minMaxLock.Unlock()
+		return histogramBetween(h.Snapshot(), minVal, maxVal, lo, hi)
+	}
+
+	ds.percentileLock.Lock()
+	defer ds.percentileLock.Unlock()
+	return sketchBetween(ds.quantiles, lo, hi)
+}
+
+// histogramBetween interpolates linearly within buckets. The first bucket
+// starts at the stream minimum and the overflow bucket ends at the maximum.
+func histogramBetween(hs HistogramSnapshot, minVal, maxVal, lo, hi float64) (count, sum float64) {
+	add := func(from, to float64, n int64) {
+		if n == 0 {
+			return
+		}
+		from, to = math.Max(from, minVal), math.Min(to, maxVal)
+		a, b := math.Max(from, lo), math.Min(to, hi)
+		if a > b || (a == b && a == lo) {
+			return
+		}
+		frac := 1.0
+		if to > from {
+			frac = (b - a) / (to - from)
+		}
+		count += frac * float64(n)
+		sum += frac * float64(n) * (a + b) / 2
+	}
+
+	prev := math.Inf(-1)
+	for _, b := range hs.Buckets {
+		add(prev, b.UpperBound, b.Count)
+		prev = b.UpperBound
+	}
+	add(prev, math.Inf(1), hs.Overflow)
+	return count, sum
+}
+
+// sketchBetweenSteps is the number of quantiles SumBetween integrates
+const sketchBetweenSteps = 64
+
+// sketchBetween inverts the sketch's quantile function by bisection to
+// find the ranks of lo and hi, and integrates it between them for the sum
+func sketchBetween(q QuantileEstimator, lo, hi float64) (count, sum float64) {
+	n := float64(q.Count())
+	if n == 0 {
+		return 0, 0
+	}
+	pLo, pHi := sketchRank(q, lo), sketchRank(q, hi)
+	if pHi <= pLo {
+		return 0, 0
+	}
+	count = n * (pHi - pLo) / 100
+
+	step := (pHi - pLo) / sketchBetweenSteps
+	for i := 0; i < sketchBetweenSteps; i++ {
+		sum += q.Quantile(pLo + (float64(i)+0.5)*step)
+	}
+	return count, sum * count / sketchBetweenSteps
+}
+
+// sketchRank returns the percentile at which the sketch reaches x
+func sketchRank(q QuantileEstimator, x float64) float64 {
+	if x < q.Quantile(0) {
+		return 0
+	}
+	if x >= q.Quantile(100) {
+		return 100
+	}
+	lo, hi := 0.0, 100.0
+	for i := 0; i < 50; i++ {
+		mid := (lo + hi) / 2
+		if q.Quantile(mid) <= x {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
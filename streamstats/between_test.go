@@ -0,0 +1,72 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCountBetweenExact(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+	for i := 1; i <= 1000; i++ {
+		ds.AddNumber(float64(i))
+	}
+
+	// (100, 300] holds 101..300
+	if got := ds.CountBetween(100, 300); got != 200 {
+		t.Errorf("CountBetween(100, 300) = %v, want 200", got)
+	}
+	if got, want := ds.SumBetween(100, 300), float64(200*(101+300)/2); got != want {
+		t.Errorf("SumBetween(100, 300) = %v, want %v", got, want)
+	}
+	if got := ds.CountBetween(300, 100); got != 0 {
+		t.Errorf("CountBetween(300, 100) = %v, want 0", got)
+	}
+}
+
+func TestCountBetweenHistogram(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{
+		Capacity:  10,
+		Quantiles: TDigest(100),
+		Histogram: LinearBuckets(100, 100, 9), // 100, 200, ..., 900
+	})
+	defer ds.Stop()
+	for i := 1; i <= 1000; i++ {
+		ds.AddNumber(float64(i))
+	}
+
+	// Exact on bucket bounds
+	if got := ds.CountBetween(100, 300); got != 200 {
+		t.Errorf("CountBetween(100, 300) = %v, want 200", got)
+	}
+	// Interpolated within buckets, including the overflow bucket up to the max
+	if got := ds.CountBetween(150, 950); math.Abs(got-800) > 1 {
+		t.Errorf("CountBetween(150, 950) = %v, want about 800", got)
+	}
+	if got, want := ds.SumBetween(100, 300), float64(200*(101+300)/2); math.Abs(got-want)/want > 0.01 {
+		t.Errorf("SumBetween(100, 300) = %v, want about %v", got, want)
+	}
+}
+
+func TestCountBetweenSketch(t *testing.T) {
+	for name, q := range map[string]func() QuantileEstimator{"tdigest": TDigest(100), "ddsketch": DDSketch(0.01)} {
+		t.Run(name, func(t *testing.T) {
+			ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Quantiles: q})
+			defer ds.Stop()
+			for i := 1; i <= 10000; i++ {
+				ds.AddNumber(float64(i))
+			}
+
+			if got := ds.CountBetween(1000, 3000); math.Abs(got-2000)/2000 > 0.02 {
+				t.Errorf("CountBetween(1000, 3000) = %v, want about 2000", got)
+			}
+			want := 2000 * 2000.5
+			if got := ds.SumBetween(1000, 3000); math.Abs(got-want)/want > 0.03 {
+				t.Errorf("SumBetween(1000, 3000) = %v, want about %v", got, want)
+			}
+			if got := ds.CountBetween(-10, 1e9); math.Abs(got-10000) > 1 {
+				t.Errorf("CountBetween() over everything = %v, want 10000", got)
+			}
+		})
+	}
+}
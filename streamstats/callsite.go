@@ -0,0 +1,110 @@
+package streamstats
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// CallSite aggregates the sampled samples added from one location
+type CallSite struct {
+	Function string
+	File     string
+	Line     int
+	Samples  int64   // Sampled calls from this site
+	Sum      float64 // Sum of sampled values
+	Max      float64
+	Tail     int64 // Sampled values at or above the cached p99 when recorded
+}
+
+// Mean returns the mean of the sampled values from this site
+func (cs CallSite) Mean() float64 {
+	if cs.Samples == 0 {
+		return 0
+	}
+	return cs.Sum / float64(cs.Samples)
+}
+
+// pkgPrefix prefixes the function names of this package's frames
+var pkgPrefix = func() string {
+	pc, _, _, _ := runtime.Caller(0)
+	name := runtime.FuncForPC(pc).Name() // e.g. example.com/streamstats.init.func1
+	slash := strings.LastIndex(name, "/")
+	return name[:slash+strings.Index(name[slash:], ".")+1]
+}()
+
+// recordCaller attributes num to the first caller outside this package,
+// so wrappers such as AddValues and GaugeSampler are skipped; samples
+// with no such caller, e.g. from a sampler goroutine, are not attributed
+func (ds *DataStreamStats) recordCaller(num float64) {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	var caller runtime.Frame
+	for {
+		f, more := frames.Next()
+		if !internalFrame(f) {
+			caller = f
+			break
+		}
+		if !more {
+			return
+		}
+	}
+	if strings.HasPrefix(caller.Function, "runtime.") {
+		return
+	}
+
+	p99 := math.Float64frombits(ds.p99Bits.Load())
+	key := fmt.Sprintf("%s:%d", caller.File, caller.Line)
+
+	ds.callerLock.Lock()
+	defer ds.callerLock.Unlock()
+
+	if ds.callSites == nil {
+		ds.callSites = make(map[string]*CallSite)
+	}
+	cs, ok := ds.callSites[key]
+	if !ok {
+		cs = &CallSite{Function: caller.Function, File: caller.File, Line: caller.Line, Max: num}
+		ds.callSites[key] = cs
+	}
+	cs.Samples++
+	cs.Sum += num
+	if num > cs.Max {
+		cs.Max = num
+	}
+	if num >= p99 {
+		cs.Tail++
+	}
+}
+
+// internalFrame reports whether f is library code of this package; the
+// package's own tests count as callers
+func internalFrame(f runtime.Frame) bool {
+	return strings.HasPrefix(f.Function, pkgPrefix) && !strings.HasSuffix(f.File, "_test.go")
+}
+
+// CallSites returns the sampled call sites, most samples first
+func (ds *DataStreamStats) CallSites() []CallSite {
+	ds.lazyInit()
+	ds.callerLock.Lock()
+	defer ds.callerLock.Unlock()
+
+	out := make([]CallSite, 0, len(ds.callSites))
+	for _, cs := range ds.callSites {
+		out = append(out, *cs)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Samples > out[j].Samples })
+	return out
+}
+
+// TailCallSites returns the sampled call sites contributing the most
+// values at or above p99, most first
+func (ds *DataStreamStats) TailCallSites() []CallSite {
+	ds.lazyInit()
+	out := ds.CallSites()
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Tail > out[j].Tail })
+	return out
+}
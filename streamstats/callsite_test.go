@@ -0,0 +1,38 @@
+package streamstats
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCallSitesSkipWrappers(t *testing.T) {
+	if !strings.HasSuffix(pkgPrefix, "/streamstats.") {
+		t.Fatalf("pkgPrefix = %q, want the streamstats import path", pkgPrefix)
+	}
+
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 100, CallerSampleRate: 1})
+	defer ds.Stop()
+
+	ds.AddNumber(1)
+	AddValues(ds, []int{2, 3})
+	NewGaugeSampler(ds, func() float64 { return 4 }, 0).Sample()
+
+	sites := ds.CallSites()
+	if len(sites) != 3 {
+		t.Fatalf("CallSites() = %+v, want 3 sites", sites)
+	}
+	var total int64
+	for _, cs := range sites {
+		if filepath.Base(cs.File) != "callsite_test.go" {
+			t.Errorf("call site %s:%d (%s) is inside the package, want the test", cs.File, cs.Line, cs.Function)
+		}
+		total += cs.Samples
+	}
+	if total != 4 {
+		t.Errorf("CallSites() sampled %d calls, want 4", total)
+	}
+	if top := sites[0]; top.Samples != 2 || top.Mean() != 2.5 {
+		t.Errorf("top call site = %+v, want the AddValues call", top)
+	}
+}
@@ -0,0 +1,640 @@
+package streamstats
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/kalpit-sharma-dev/math-stats/container"
+)
+
+// checkpointVersion is written into every checkpoint. Fields are only ever
+// added, and decoders ignore fields they do not know, so checkpoints can
+// be read by both older and newer versions.
+const checkpointVersion = 7
+
+// checkpointMagic prefixes the binary encoding
+var checkpointMagic = []byte("MSSC")
+
+// checkpoint is the serialized state of a stream: lifetime aggregates,
+// median heaps or sketch, non-negative buckets, decayed digest, window,
+// closed event windows and epochs. Closed epochs keep their rollup but not
+// their snapshot. Exemplars, call sites, custom statistics and child
+// streams are not included.
+type checkpoint struct {
+	Version       int                          `json:"version"`
+	Name          string                       `json:"name"`
+	Source        Source                       `json:"source"`           // Since version 2
+	Merged        []Source                     `json:"merged,omitempty"` // Since version 2
+	Saved         time.Time                    `json:"saved"`
+	Count         int64                        `json:"count"`
+	Sum           float64                      `json:"sum"`
+	Min           float64                      `json:"min"`
+	Max           float64                      `json:"max"`
+	Moments       momentsState                 `json:"moments"`
+	ShedCount     int64                        `json:"shed_count"`
+	NegativeCount int64                        `json:"negative_count"`
+	Lanes         [LaneCritical + 1]LaneCounts `json:"lanes"`
+	FirstSample   time.Time                    `json:"first_sample"`
+	Lower         []float64                    `json:"lower,omitempty"`
+	Upper         []float64                    `json:"upper,omitempty"`
+	Sketch        *sketchState                 `json:"sketch,omitempty"`
+	NonNegative   *bucketsState                `json:"non_negative,omitempty"`
+	Decayed       *decayedState                `json:"decayed,omitempty"`
+	Histogram     *histogramState              `json:"histogram,omitempty"` // Since version 3
+	Window        []float64                    `json:"window"`
+	WindowTimes   []int64                      `json:"window_times,omitempty"` // Unix nanoseconds, with TimeWindow
+	Annotations   []Annotation                 `json:"annotations,omitempty"`  // Since version 4
+	Windows       []rollupState                `json:"windows,omitempty"`      // Closed event windows, since version 6
+	Epochs        []rollupState                `json:"epochs,omitempty"`       // Pre-epoch period, then closed epochs, since version 7
+	Epoch         *epochCheckpoint             `json:"epoch,omitempty"`        // Since version 7
+	EpochDigest   digestState                  `json:"epoch_digest"`           // Since version 7
+}
+
+// epochCheckpoint is the open epoch and the checkpoint of its stream
+type epochCheckpoint struct {
+	Label   string      `json:"label"`
+	Started time.Time   `json:"started"`
+	Stream  *checkpoint `json:"stream"`
+}
+
+type rollupState struct {
+	Label  string      `json:"label"`
+	Start  time.Time   `json:"start"`
+	End    time.Time   `json:"end"`
+	Count  int64       `json:"count"`
+	Sum    float64     `json:"sum"`
+	Min    float64     `json:"min"`
+	Max    float64     `json:"max"`
+	Digest digestState `json:"digest"`
+}
+
+type momentsState struct {
+	N    int64   `json:"n"`
+	Mean float64 `json:"mean"`
+	M2   float64 `json:"m2"`
+	M3   float64 `json:"m3"`
+	M4   float64 `json:"m4"`
+}
+
+type digestState struct {
+	Compression float64   `json:"compression"`
+	Means       []float64 `json:"means"`
+	Weights     []float64 `json:"weights"`
+}
+
+type bucketsState struct {
+	Gamma  float64       `json:"gamma"`
+	Zeros  int64         `json:"zeros"`
+	Counts map[int]int64 `json:"counts"`
+}
+
+type sketchState struct {
+	Kind   string        `json:"kind"` // "tdigest", "ddsketch" or "exact"
+	Count  int64         `json:"count,omitempty"`
+	Digest *digestState  `json:"digest,omitempty"`
+	Pos    *bucketsState `json:"pos,omitempty"`
+	Neg    *bucketsState `json:"neg,omitempty"`
+	Exact  *exactState   `json:"exact,omitempty"` // Since version 5
+}
+
+type exactState struct {
+	Values     []float64    `json:"values,omitempty"`
+	SwitchedAt int64        `json:"switched_at,omitempty"`
+	Sketch     *sketchState `json:"sketch,omitempty"` // Once switched
+}
+
+type histogramState struct {
+	Bounds []float64 `json:"bounds"`
+	Counts []int64   `json:"counts"` // One per bound plus the overflow bucket
+}
+
+type decayedState struct {
+	Landmark time.Time   `json:"landmark"`
+	Digest   digestState `json:"digest"`
+}
+
+// MarshalBinary encodes the stream state for UnmarshalBinary
+func (ds *DataStreamStats) MarshalBinary() ([]byte, error) {
+	ds.lazyInit()
+	cp, err := ds.checkpoint()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Write(checkpointMagic)
+	if err := gob.NewEncoder(&buf).Encode(cp); err != nil {
+		return nil, fmt.Errorf("encode stream %q: %w", ds.name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary replaces the state of ds with a checkpoint made by
+// MarshalBinary. Ds must be configured like the stream that was saved:
+// the same quantile engine, NonNegative and DecayHalfLife.
+func (ds *DataStreamStats) UnmarshalBinary(data []byte) error {
+	ds.lazyInit()
+	if !bytes.HasPrefix(data, checkpointMagic) {
+		return fmt.Errorf("restore stream %q: not a checkpoint", ds.name)
+	}
+	var cp checkpoint
+	if err := gob.NewDecoder(bytes.NewReader(data[len(checkpointMagic):])).Decode(&cp); err != nil {
+		return fmt.Errorf("restore stream %q: %w", ds.name, err)
+	}
+	return ds.restore(&cp)
+}
+
+// MarshalJSON encodes the stream state as a JSON checkpoint
+func (ds *DataStreamStats) MarshalJSON() ([]byte, error) {
+	ds.lazyInit()
+	cp, err := ds.checkpoint()
+	if err != nil {
+		return nil, err
+	}
+	// JSON has no infinities; an empty stream's min and max are restored
+	for c := cp; c != nil; c = c.Epoch.stream() {
+		if c.Count == 0 {
+			c.Min, c.Max = 0, 0
+		}
+	}
+	return json.Marshal(cp)
+}
+
+// UnmarshalJSON replaces the state of ds with a JSON checkpoint, see
+// UnmarshalBinary
+func (ds *DataStreamStats) UnmarshalJSON(data []byte) error {
+	ds.lazyInit()
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return fmt.Errorf("restore stream %q: %w", ds.name, err)
+	}
+	return ds.restore(&cp)
+}
+
+// SaveToFile writes a binary checkpoint to path, replacing it atomically
+func (ds *DataStreamStats) SaveToFile(path string) error {
+	ds.lazyInit()
+	data, err := ds.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// LoadFromFile creates a stream from opts and restores the checkpoint
+// written to path by SaveToFile
+func LoadFromFile(path string, opts Options) (*DataStreamStats, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ds := NewDataStreamStatsWithOptions(opts)
+	if err := ds.UnmarshalBinary(data); err != nil {
+		ds.Stop()
+		return nil, err
+	}
+	return ds, nil
+}
+
+// checkpoint captures the state of ds. Locks are taken one at a time, so
+// samples added concurrently may be missing from some structures.
+func (ds *DataStreamStats) checkpoint() (*checkpoint, error) {
+	cp := &checkpoint{
+		Version: checkpointVersion,
+		Name:    ds.name,
+		Source:  ds.source(),
+		Merged:  ds.MergedSources(),
+		Saved:   ds.now(),
+	}
+	cp.Annotations = ds.Annotations(time.Time{}, time.Time{})
+
+	ds.minMaxLock.Lock()
+	cp.Count, cp.Sum = ds.count, ds.totalSum
+	cp.Min, cp.Max = ds.minVal, ds.maxVal
+	m := ds.moments
+	cp.Moments = momentsState{N: m.n, Mean: m.mean, M2: m.m2, M3: m.m3, M4: m.m4}
+	cp.ShedCount, cp.NegativeCount = ds.shedCount, ds.negativeCount
+	cp.Lanes = ds.lanes
+	cp.FirstSample = ds.firstSample
+	if ds.events != nil {
+		for _, w := range ds.events.closed {
+			cp.Windows = append(cp.Windows, rollupStateOf(w, w.digest))
+		}
+	}
+	ds.minMaxLock.Unlock()
+
+	ds.epochLock.Lock()
+	cp.EpochDigest = digestStateOf(ds.epochDigest)
+	for i, td := range ds.closedDigests {
+		r := ds.preEpoch
+		if i > 0 {
+			ep := ds.closedEpochs[i-1]
+			s := ep.Summary
+			r = Rollup{Label: ep.Label, Start: ep.Started, End: ep.Ended, Count: s.Count, Sum: s.Sum, Min: s.Min, Max: s.Max}
+		}
+		cp.Epochs = append(cp.Epochs, rollupStateOf(r, td))
+	}
+	var err error
+	if es := ds.epoch; es != nil {
+		cp.Epoch = &epochCheckpoint{Label: es.label, Started: es.started}
+		cp.Epoch.Stream, err = es.stats.checkpoint()
+	}
+	ds.epochLock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	ds.heapLock.Lock()
+	cp.Lower = append([]float64(nil), ds.lower...)
+	cp.Upper = append([]float64(nil), ds.upper...)
+	ds.heapLock.Unlock()
+
+	ds.percentileLock.Lock()
+	if ds.quantiles != nil {
+		cp.Sketch, err = sketchStateOf(ds.quantiles)
+	}
+	if ds.nonNegative != nil {
+		cp.NonNegative = bucketsStateOf(ds.nonNegative)
+	}
+	cp.Window, cp.WindowTimes = ds.recentData.valuesWithTimes()
+	ds.percentileLock.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint stream %q: %w", ds.name, err)
+	}
+
+	if h := ds.histogram.Load(); h != nil {
+		cp.Histogram = &histogramState{Bounds: h.bounds}
+		for i := range h.counts {
+			cp.Histogram.Counts = append(cp.Histogram.Counts, h.counts[i].Load())
+		}
+	}
+
+	if dq := ds.decayed; dq != nil {
+		dq.mu.Lock()
+		cp.Decayed = &decayedState{Landmark: dq.landmark, Digest: digestStateOf(dq.digest)}
+		dq.mu.Unlock()
+	}
+	return cp, nil
+}
+
+// restore replaces the state of ds with cp
+func (ds *DataStreamStats) restore(cp *checkpoint) error {
+	if cp.Version < 1 {
+		return fmt.Errorf("restore stream %q: not a checkpoint", ds.name)
+	}
+	switch {
+	case (cp.Sketch != nil) != ds.sketched():
+		return fmt.Errorf("restore stream %q: checkpoint and stream use different quantile engines", ds.name)
+	case (cp.NonNegative != nil) != (ds.nonNegative != nil):
+		return fmt.Errorf("restore stream %q: checkpoint and stream differ in NonNegative", ds.name)
+	case (cp.Decayed != nil) != (ds.decayed != nil):
+		return fmt.Errorf("restore stream %q: checkpoint and stream differ in DecayHalfLife", ds.name)
+	case cp.Histogram != nil && len(cp.Histogram.Counts) != len(cp.Histogram.Bounds)+1,
+		ds.histogram.Load() != nil && cp.Histogram != nil && !slices.Equal(ds.histogram.Load().bounds, cp.Histogram.Bounds):
+		return fmt.Errorf("restore stream %q: checkpoint and stream differ in Histogram", ds.name)
+	}
+
+	// Build everything before taking locks
+	var sketch QuantileEstimator
+	if cp.Sketch != nil {
+		ds.percentileLock.Lock()
+		kind := sketchKind(ds.quantiles)
+		ds.percentileLock.Unlock()
+		if kind != cp.Sketch.Kind {
+			return fmt.Errorf("restore stream %q: checkpoint sketch is %s, stream uses %s", ds.name, cp.Sketch.Kind, kind)
+		}
+		var err error
+		if sketch, err = cp.Sketch.estimator(ds.opts.Quantiles); err != nil {
+			return fmt.Errorf("restore stream %q: %w", ds.name, err)
+		}
+	}
+	windows := make([]Rollup, 0, len(cp.Windows))
+	for _, w := range cp.Windows {
+		td := w.Digest.digest()
+		td.compress()
+		windows = append(windows, Rollup{Label: w.Label, Start: w.Start, End: w.End,
+			Count: w.Count, Sum: w.Sum, Min: w.Min, Max: w.Max, digest: td})
+	}
+	var buckets *logBuckets
+	if cp.NonNegative != nil {
+		buckets = cp.NonNegative.buckets()
+	}
+	lower, upper := container.MaxHeap(cp.Lower), container.MinHeap(cp.Upper)
+	lower.Init()
+	upper.Init()
+	if d := len(lower) - len(upper); d < 0 || d > 1 {
+		return fmt.Errorf("restore stream %q: unbalanced median heaps", ds.name)
+	}
+	var epochs []Rollup
+	var epoch *epochState
+	if cp.Version >= 7 {
+		for _, e := range cp.Epochs {
+			epochs = append(epochs, Rollup{Label: e.Label, Start: e.Start, End: e.End,
+				Count: e.Count, Sum: e.Sum, Min: e.Min, Max: e.Max, digest: e.Digest.digest()})
+		}
+		if ec := cp.Epoch; ec != nil && ec.Stream != nil {
+			stats := NewDataStreamStatsWithOptions(ds.summaryOptions(ds.name + "@" + ec.Label))
+			if err := stats.restore(ec.Stream); err != nil {
+				stats.Stop()
+				return fmt.Errorf("restore stream %q epoch %q: %w", ds.name, ec.Label, err)
+			}
+			epoch = &epochState{label: ec.Label, started: ec.Started, stats: stats}
+		}
+	}
+
+	ds.minMaxLock.Lock()
+	if ds.finalized != nil {
+		ds.minMaxLock.Unlock()
+		if epoch != nil {
+			epoch.stats.Stop()
+		}
+		return fmt.Errorf("restore stream %q: %w", ds.name, ErrClosed)
+	}
+	ds.count, ds.totalSum = cp.Count, cp.Sum
+	ds.generation.Add(1)
+	ds.minVal, ds.maxVal = cp.Min, cp.Max
+	if cp.Count == 0 {
+		ds.minVal, ds.maxVal = math.Inf(1), math.Inf(-1)
+	}
+	m := cp.Moments
+	ds.moments = moments{n: m.N, mean: m.Mean, m2: m.M2, m3: m.M3, m4: m.M4}
+	ds.shedCount, ds.negativeCount = cp.ShedCount, cp.NegativeCount
+	ds.lanes = cp.Lanes
+	ds.firstSample = cp.FirstSample
+	if ew := ds.events; ew != nil {
+		ew.closed = windows[max(0, len(windows)-ew.retain):]
+	}
+	// The stream keeps its ID and sequence across restarts
+	if cp.Source.Stream != "" {
+		ds.id = cp.Source.Stream
+		if ds.seq.Load() < cp.Source.Seq {
+			ds.seq.Store(cp.Source.Seq)
+		}
+	}
+	ds.merged = nil
+	for _, src := range cp.Merged {
+		ds.recordSource(src)
+	}
+
+	ds.heapLock.Lock()
+	ds.lower, ds.upper = lower, upper
+	ds.balanceCounter = len(lower) - len(upper)
+	ds.heapLock.Unlock()
+
+	ds.percentileLock.Lock()
+	if sketch != nil {
+		ds.quantiles = sketch
+	}
+	if buckets != nil {
+		ds.nonNegative = buckets
+	}
+	ds.recentData.release()
+	for i, v := range cp.Window {
+		var at time.Time
+		if i < len(cp.WindowTimes) {
+			at = time.Unix(0, cp.WindowTimes[i])
+		}
+		// Keeps the newest samples when the window shrank
+		if ds.recentData.cap > 0 {
+			ds.recentData.AddAt(at, v)
+		}
+	}
+	ds.percentileLock.Unlock()
+
+	h := ds.histogram.Load()
+	if h == nil && ds.warmUp != nil && cp.Histogram != nil {
+		// A stream in warm-up adopts the bounds learned before the checkpoint
+		h = NewHistogram(cp.Histogram.Bounds)
+		ds.histogram.Store(h)
+		ds.warmUp = nil
+	}
+	if h != nil {
+		for i := range h.counts {
+			var n int64
+			if cp.Histogram != nil {
+				n = cp.Histogram.Counts[i]
+			}
+			h.counts[i].Store(n)
+		}
+	}
+
+	if dq := ds.decayed; dq != nil {
+		dq.mu.Lock()
+		dq.landmark = cp.Decayed.Landmark
+		dq.digest = cp.Decayed.Digest.digest()
+		dq.mu.Unlock()
+	}
+
+	if cp.Version >= 7 {
+		ds.epochLock.Lock()
+		ds.restoreEpochs(epochs, epoch, cp.EpochDigest.digest())
+		ds.epochLock.Unlock()
+	}
+	ds.minMaxLock.Unlock()
+
+	ds.annotationLock.Lock()
+	ds.annotations = cp.Annotations
+	ds.annotationLock.Unlock()
+
+	select {
+	case ds.percentileChan <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// restoreEpochs replaces the epochs of ds with the closed rollups, the
+// first covering the samples before the first epoch, and the open epoch;
+// callers hold epochLock
+func (ds *DataStreamStats) restoreEpochs(closed []Rollup, open *epochState, current *tdigest) {
+	if ds.epoch != nil {
+		ds.epoch.stats.Stop()
+	}
+	ds.epoch = open
+	ds.epochDigest = current
+	ds.preEpoch = Rollup{}
+	ds.closedEpochs = nil
+	ds.closedDigests = nil
+	for i, r := range closed {
+		ds.closedDigests = append(ds.closedDigests, r.digest)
+		if i == 0 {
+			ds.preEpoch = Rollup{Start: r.Start, End: r.End, Count: r.Count, Sum: r.Sum, Min: r.Min, Max: r.Max}
+			continue
+		}
+		sum := Summary{
+			Name:      ds.name + "@" + r.Label,
+			Finalized: r.End,
+			Count:     r.Count,
+			Sum:       r.Sum,
+			Mean:      r.Mean(),
+			Min:       r.Min,
+			Max:       r.Max,
+			Median:    r.digest.quantile(50),
+		}
+		for j, p := range summaryPercentiles {
+			sum.Percentiles[j] = r.digest.quantile(p)
+		}
+		ds.closedEpochs = append(ds.closedEpochs, Epoch{Label: r.Label, Started: r.Start, Ended: r.End, Summary: sum})
+	}
+}
+
+// stream returns the checkpoint of the open epoch's stream, nil without one
+func (ec *epochCheckpoint) stream() *checkpoint {
+	if ec == nil {
+		return nil
+	}
+	return ec.Stream
+}
+
+// rollupStateOf serializes a rollup with td as its digest
+func rollupStateOf(r Rollup, td *tdigest) rollupState {
+	st := rollupState{Label: r.Label, Start: r.Start, End: r.End,
+		Count: r.Count, Sum: r.Sum, Min: r.Min, Max: r.Max, Digest: digestStateOf(td)}
+	// JSON has no infinities
+	if r.Count == 0 {
+		st.Min, st.Max = 0, 0
+	}
+	return st
+}
+
+// valuesWithTimes returns the buffered values from oldest to newest and,
+// for timed buffers, their times
+func (rb *RingBuffer) valuesWithTimes() ([]float64, []int64) {
+	values := rb.Values()
+	if rb.maxAge <= 0 || rb.times == nil {
+		return values, nil
+	}
+	times := make([]int64, 0, rb.size)
+	for i, start := 0, rb.start(); i < rb.size; i++ {
+		times = append(times, rb.times[(start+i)%rb.cap])
+	}
+	return values, times
+}
+
+func digestStateOf(td *tdigest) digestState {
+	st := digestState{Compression: td.compression}
+	for _, cs := range [][]centroid{td.centroids, td.buffer} {
+		for _, c := range cs {
+			st.Means = append(st.Means, c.mean)
+			st.Weights = append(st.Weights, c.weight)
+		}
+	}
+	return st
+}
+
+func (st digestState) digest() *tdigest {
+	td := newTDigest(st.Compression)
+	for i, mean := range st.Means {
+		if i < len(st.Weights) {
+			td.add(mean, st.Weights[i])
+		}
+	}
+	return td
+}
+
+func bucketsStateOf(lb *logBuckets) *bucketsState {
+	c := lb.clone()
+	return &bucketsState{Gamma: c.gamma, Zeros: c.zeroCount, Counts: c.counts}
+}
+
+func (st *bucketsState) buckets() *logBuckets {
+	lb := &logBuckets{
+		gamma:     st.Gamma,
+		logGamma:  math.Log(st.Gamma),
+		zeroCount: st.Zeros,
+		counts:    make(map[int]int64, len(st.Counts)),
+		total:     st.Zeros,
+	}
+	for k, n := range st.Counts {
+		lb.counts[k] = n
+		lb.total += n
+	}
+	return lb
+}
+
+// sketchKind names the built-in sketches in checkpoints
+func sketchKind(q QuantileEstimator) string {
+	switch q.(type) {
+	case *TDigestEstimator:
+		return "tdigest"
+	case *DDSketchEstimator:
+		return "ddsketch"
+	case *ExactEstimator:
+		return "exact"
+	}
+	return fmt.Sprintf("%T", q)
+}
+
+// sketchStateOf captures the built-in sketches; other estimators cannot
+// be checkpointed
+func sketchStateOf(q QuantileEstimator) (*sketchState, error) {
+	switch q := q.(type) {
+	case *TDigestEstimator:
+		d := digestStateOf(q.td)
+		return &sketchState{Kind: sketchKind(q), Count: q.count, Digest: &d}, nil
+	case *DDSketchEstimator:
+		return &sketchState{Kind: sketchKind(q), Pos: bucketsStateOf(q.pos), Neg: bucketsStateOf(q.neg)}, nil
+	case *ExactEstimator:
+		st := &exactState{Values: slices.Clone(q.values), SwitchedAt: q.switchedAt}
+		if q.sketch != nil {
+			var err error
+			if st.Sketch, err = sketchStateOf(q.sketch); err != nil {
+				return nil, err
+			}
+		}
+		return &sketchState{Kind: sketchKind(q), Exact: st}, nil
+	}
+	return nil, fmt.Errorf("quantile estimator %T cannot be checkpointed", q)
+}
+
+// estimator rebuilds the sketch; an exact estimator takes its limit and
+// sketch constructor from newQuantiles
+func (st *sketchState) estimator(newQuantiles func() QuantileEstimator) (QuantileEstimator, error) {
+	switch {
+	case st.Kind == "exact" && st.Exact != nil:
+		ee, ok := newQuantiles().(*ExactEstimator)
+		if !ok {
+			return nil, fmt.Errorf("stream does not use ExactBelow")
+		}
+		if st.Exact.Sketch == nil {
+			ee.values = st.Exact.Values
+			return ee, nil
+		}
+		if kind := sketchKind(ee.newSketch()); kind != st.Exact.Sketch.Kind {
+			return nil, fmt.Errorf("checkpoint sketch is %s, stream uses %s", st.Exact.Sketch.Kind, kind)
+		}
+		sketch, err := st.Exact.Sketch.estimator(ee.newSketch)
+		if err != nil {
+			return nil, err
+		}
+		ee.sketch, ee.switchedAt = sketch, st.Exact.SwitchedAt
+		return ee, nil
+	case st.Kind == "tdigest" && st.Digest != nil:
+		return &TDigestEstimator{td: st.Digest.digest(), count: st.Count}, nil
+	case st.Kind == "ddsketch" && st.Pos != nil && st.Neg != nil:
+		return &DDSketchEstimator{pos: st.Pos.buckets(), neg: st.Neg.buckets()}, nil
+	}
+	return nil, fmt.Errorf("unknown sketch %q", st.Kind)
+}
@@ -0,0 +1,179 @@
+package streamstats
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	for name, opts := range map[string]Options{
+		"heaps":       {Capacity: 100},
+		"tdigest":     {Capacity: 100, Quantiles: TDigest(100)},
+		"ddsketch":    {Capacity: 100, Quantiles: DDSketch(0.01)},
+		"nonnegative": {Capacity: 100, NonNegative: true, DecayHalfLife: time.Minute},
+		"timewindow":  {Capacity: 100, TimeWindow: time.Hour, CompactWindow: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			src := NewDataStreamStatsWithOptions(opts)
+			defer src.Stop()
+			r := rand.New(rand.NewSource(1))
+			for i := 0; i < 1000; i++ {
+				src.AddNumber(r.ExpFloat64() * 10)
+			}
+
+			for _, codec := range []struct {
+				name      string
+				marshal   func() ([]byte, error)
+				unmarshal func(*DataStreamStats, []byte) error
+			}{
+				{"binary", src.MarshalBinary, (*DataStreamStats).UnmarshalBinary},
+				{"json", src.MarshalJSON, (*DataStreamStats).UnmarshalJSON},
+			} {
+				data, err := codec.marshal()
+				if err != nil {
+					t.Fatalf("%s marshal: %v", codec.name, err)
+				}
+				dst := NewDataStreamStatsWithOptions(opts)
+				defer dst.Stop()
+				if err := codec.unmarshal(dst, data); err != nil {
+					t.Fatalf("%s unmarshal: %v", codec.name, err)
+				}
+
+				if got, want := dst.Stats(), src.Stats(); got != want {
+					t.Errorf("%s: Stats() = %+v, want %+v", codec.name, got, want)
+				}
+				if got, want := dst.GetSkewness(), src.GetSkewness(); got != want {
+					t.Errorf("%s: GetSkewness() = %v, want %v", codec.name, got, want)
+				}
+				if opts.DecayHalfLife > 0 {
+					if got, want := dst.GetDecayedPercentile(90), src.GetDecayedPercentile(90); math.Abs(got-want) > 1e-9*want {
+						t.Errorf("%s: GetDecayedPercentile(90) = %v, want %v", codec.name, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCheckpointResume(t *testing.T) {
+	whole := NewDataStreamStatsWithOptions(Options{Capacity: 50, Strict: true})
+	first := NewDataStreamStatsWithOptions(Options{Capacity: 50})
+	defer whole.Stop()
+	defer first.Stop()
+
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 500; i++ {
+		v := r.NormFloat64()
+		whole.AddNumber(v)
+		first.AddNumber(v)
+	}
+	path := filepath.Join(t.TempDir(), "stream.ckpt")
+	if err := first.SaveToFile(path); err != nil {
+		t.Fatalf("SaveToFile() = %v", err)
+	}
+	resumed, err := LoadFromFile(path, Options{Capacity: 50, Strict: true})
+	if err != nil {
+		t.Fatalf("LoadFromFile() = %v", err)
+	}
+	defer resumed.Stop()
+
+	for i := 0; i < 500; i++ {
+		v := r.NormFloat64()
+		whole.AddNumber(v)
+		resumed.AddNumber(v)
+	}
+	if got, want := resumed.Stats(), whole.Stats(); got != want {
+		t.Errorf("Stats() after resume = %+v, want %+v", got, want)
+	}
+}
+
+func TestCheckpointEmpty(t *testing.T) {
+	src := NewDataStreamStats(10)
+	defer src.Stop()
+	data, err := json.Marshal(src)
+	if err != nil {
+		t.Fatalf("json.Marshal() = %v", err)
+	}
+	dst := NewDataStreamStats(10)
+	defer dst.Stop()
+	if err := json.Unmarshal(data, dst); err != nil {
+		t.Fatalf("json.Unmarshal() = %v", err)
+	}
+	dst.AddNumber(-3)
+	if got := dst.GetMax(); got != -3 {
+		t.Errorf("GetMax() after restoring an empty stream = %v, want -3", got)
+	}
+}
+
+func TestCheckpointCompatibility(t *testing.T) {
+	// A newer version with fields this version does not know
+	newer := `{"version": 8, "count": 2, "sum": 3, "min": 1, "max": 2, "lower": [1], "upper": [2],
+		"window": [1, 2], "added_later": {"x": 1}}`
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+	if err := ds.UnmarshalJSON([]byte(newer)); err != nil {
+		t.Fatalf("UnmarshalJSON() of a newer checkpoint = %v", err)
+	}
+	if got := ds.GetMedian(); got != 1.5 {
+		t.Errorf("GetMedian() = %v, want 1.5", got)
+	}
+
+	sketch := NewDataStreamStatsWithOptions(Options{Capacity: 10, Quantiles: TDigest(100)})
+	defer sketch.Stop()
+	data, _ := ds.MarshalBinary()
+	if err := sketch.UnmarshalBinary(data); err == nil {
+		t.Error("restoring a heap checkpoint into a sketch stream succeeded, want error")
+	}
+	if err := ds.UnmarshalBinary([]byte("garbage")); err == nil {
+		t.Error("UnmarshalBinary() of garbage succeeded, want error")
+	}
+	if err := ds.UnmarshalJSON([]byte(`{}`)); err == nil {
+		t.Error("UnmarshalJSON() without a version succeeded, want error")
+	}
+}
+
+func TestRestoreConcurrentReads(t *testing.T) {
+	opts := Options{Capacity: 100, Quantiles: TDigest(100)}
+	src := NewDataStreamStatsWithOptions(opts)
+	defer src.Stop()
+	for i := 1; i <= 1000; i++ {
+		src.AddNumber(float64(i))
+	}
+	data, err := src.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds := NewDataStreamStatsWithOptions(opts)
+	defer ds.Stop()
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			ds.GetMedian()
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		if err := ds.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	<-done
+
+	if err := ds.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if got := ds.GetMedian(); math.Abs(got-500) > 10 {
+		t.Errorf("GetMedian() after restore = %v, want about 500", got)
+	}
+}
@@ -0,0 +1,29 @@
+package streamstats
+
+// NewChild creates a sub-stream, e.g. per shard, whose accepted samples
+// also feed ds, so per-shard and overall statistics stay consistent with
+// a single AddNumber. The child is named "<parent>/<name>" and summarizes
+// like the parent, without its rate limiter, callbacks or background
+// workers; samples the child accepts always reach the parent.
+func (ds *DataStreamStats) NewChild(name string) *DataStreamStats {
+	ds.lazyInit()
+	if ds.name != "" {
+		name = ds.name + "/" + name
+	}
+
+	child := NewDataStreamStatsWithOptions(ds.summaryOptions(name))
+	child.parent = ds
+
+	ds.childLock.Lock()
+	ds.children = append(ds.children, child)
+	ds.childLock.Unlock()
+	return child
+}
+
+// Children returns the sub-streams created with NewChild
+func (ds *DataStreamStats) Children() []*DataStreamStats {
+	ds.lazyInit()
+	ds.childLock.Lock()
+	defer ds.childLock.Unlock()
+	return append([]*DataStreamStats(nil), ds.children...)
+}
@@ -0,0 +1,57 @@
+package streamstats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChildRollsUp(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Name: "api", Capacity: 10})
+	defer ds.Stop()
+	a, b := ds.NewChild("a"), ds.NewChild("b")
+	defer a.Stop()
+	defer b.Stop()
+
+	a.AddNumber(1)
+	a.AddNumber(3)
+	b.AddNumber(8)
+	ds.AddNumber(100) // Only the parent
+
+	if got := ds.Count(); got != 4 {
+		t.Errorf("parent Count() = %d, want 4", got)
+	}
+	if got := a.GetMean(); got != 2 {
+		t.Errorf("child GetMean() = %v, want 2", got)
+	}
+	if got := b.Snapshot().Name; got != "api/b" {
+		t.Errorf("child name = %q, want api/b", got)
+	}
+	if got := len(ds.Children()); got != 2 {
+		t.Errorf("Children() = %d streams, want 2", got)
+	}
+}
+
+func TestChildSkipsParentAdmission(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{
+		Capacity:    10,
+		Limiter:     NewTokenBucket(0, 1),
+		EventWindow: time.Second,
+	})
+	defer ds.Stop()
+	child := ds.NewChild("c")
+	defer child.Stop()
+
+	ds.AddNumber(1) // Takes the only token
+	for i := 0; i < 5; i++ {
+		child.AddNumber(float64(i))
+	}
+	if got, want := ds.Count(), 1+child.Count(); got != want || child.Count() != 5 {
+		t.Errorf("parent Count() = %d, child %d; want the parent to hold every child sample", got, child.Count())
+	}
+	if got := ds.GetShedCount(); got != 0 {
+		t.Errorf("parent shed %d child samples", got)
+	}
+	if child.Snapshot().Watermark != nil {
+		t.Error("child inherited the parent's event windows")
+	}
+}
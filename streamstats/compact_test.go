@@ -0,0 +1,38 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCompactWindow(t *testing.T) {
+	exact := NewDataStreamStatsWithOptions(Options{Capacity: 1000})
+	defer exact.Stop()
+	compact := NewDataStreamStatsWithOptions(Options{Capacity: 1000, CompactWindow: true})
+	defer compact.Stop()
+
+	for i := 1; i <= 1500; i++ {
+		v := 1 + float64(i)/3
+		exact.AddNumber(v)
+		compact.AddNumber(v)
+	}
+
+	// Window samples are stored at half the size
+	if e, c := exact.MemoryUsage().Window, compact.MemoryUsage().Window; c*2 != e {
+		t.Errorf("compact window uses %d bytes, want half of %d", c, e)
+	}
+
+	// Window percentiles keep float32 precision, lifetime aggregates stay exact
+	for _, p := range []float64{1, 50, 99} {
+		e, c := exact.GetPercentile(p), compact.GetPercentile(p)
+		if c != float64(float32(e)) || math.Abs(c-e) > 1e-6*e {
+			t.Errorf("p%v = %v compact, want float32(%v)", p, c, e)
+		}
+	}
+	if exact.GetMean() != compact.GetMean() || exact.GetMax() != compact.GetMax() {
+		t.Errorf("compact mean/max = %v/%v, want exact %v/%v", compact.GetMean(), compact.GetMax(), exact.GetMean(), exact.GetMax())
+	}
+	if got := compact.GetWindowStats().Count; got != 1000 {
+		t.Errorf("compact window holds %d samples, want 1000", got)
+	}
+}
@@ -0,0 +1,259 @@
+package streamstats
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// ComparisonRow is one statistic compared between two periods
+type ComparisonRow struct {
+	Stat        string
+	Before      float64
+	After       float64
+	Change      float64 // Relative change in percent, NaN when Before is zero
+	Significant bool
+}
+
+// Comparison is the diff between a baseline and a current period
+type Comparison struct {
+	Before, After string
+	Rows          []ComparisonRow
+	PValue        float64 // Mann-Whitney U p-value for the two digests
+
+	// Annotations made during the after period since the before period
+	// ended, such as the deploy that explains a shift
+	Annotations []Annotation
+}
+
+// significanceLevel is the p-value below which a change is flagged
+const significanceLevel = 0.05
+
+// Compare diffs the count, mean and p50/p95/p99 of two rollups, e.g. last
+// week and this week from RangeRollup; see CompareRanges. Significance is
+// judged on the rollup digests, whose centroids stand in for the samples:
+// Welch's t-test for the mean and Mann-Whitney U for quantiles.
+func Compare(before, after Rollup) Comparison {
+	a, b := before.centroids(), after.centroids()
+	_, pU := mannWhitney(a, b)
+	pT := welchWeighted(a, b)
+
+	row := func(name string, x, y float64, p float64) ComparisonRow {
+		return ComparisonRow{
+			Stat:        name,
+			Before:      x,
+			After:       y,
+			Change:      percentChange(x, y),
+			Significant: p < significanceLevel,
+		}
+	}
+
+	var notes []Annotation
+	for _, a := range after.Annotations {
+		if a.Time.After(before.End) {
+			notes = append(notes, a)
+		}
+	}
+
+	return Comparison{
+		Before:      before.Label,
+		After:       after.Label,
+		PValue:      pU,
+		Annotations: notes,
+		Rows: []ComparisonRow{
+			{Stat: "count", Before: float64(before.Count), After: float64(after.Count),
+				Change: percentChange(float64(before.Count), float64(after.Count))},
+			row("mean", before.Mean(), after.Mean(), pT),
+			row("p50", before.Percentile(50), after.Percentile(50), pU),
+			row("p95", before.Percentile(95), after.Percentile(95), pU),
+			row("p99", before.Percentile(99), after.Percentile(99), pU),
+		},
+	}
+}
+
+// CompareRanges compares the stored rollups of two time ranges, e.g. last
+// week and this week, each merged as RangeRollup does. The periods are
+// labeled with their ranges in ISO 8601 interval notation, ".." for an
+// open bound.
+func (ds *DataStreamStats) CompareRanges(beforeFrom, beforeTo, afterFrom, afterTo time.Time) (Comparison, error) {
+	ds.lazyInit()
+	before, err := ds.RangeRollup(beforeFrom, beforeTo)
+	if err != nil {
+		return Comparison{}, err
+	}
+	after, err := ds.RangeRollup(afterFrom, afterTo)
+	if err != nil {
+		return Comparison{}, err
+	}
+	before.Label = rangeLabel(beforeFrom, beforeTo)
+	after.Label = rangeLabel(afterFrom, afterTo)
+	return Compare(before, after), nil
+}
+
+// rangeLabel formats a time range as from/to
+func rangeLabel(from, to time.Time) string {
+	bound := func(t time.Time) string {
+		if t.IsZero() {
+			return ".."
+		}
+		return t.Format(time.RFC3339)
+	}
+	return bound(from) + "/" + bound(to)
+}
+
+// RenderComparison writes a comparison as a Markdown diff table
+func RenderComparison(w io.Writer, c Comparison) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "| Stat | %s | %s | Change | Significant |\n", c.Before, c.After)
+	sb.WriteString("|---|---:|---:|---:|:---:|\n")
+	for _, r := range c.Rows {
+		flag := ""
+		if r.Significant {
+			flag = "*"
+		}
+		fmt.Fprintf(&sb, "| %s | %.2f | %.2f | %+.1f%% | %s |\n", r.Stat, r.Before, r.After, r.Change, flag)
+	}
+	fmt.Fprintf(&sb, "\nMann-Whitney U p-value: %.4f\n", c.PValue)
+	if len(c.Annotations) > 0 {
+		sb.WriteString("\nAnnotations:\n")
+		for _, a := range c.Annotations {
+			fmt.Fprintf(&sb, "- %s %s\n", a.Time.Format(time.RFC3339), a.Text)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// percentChange returns the relative change from x to y in percent
+func percentChange(x, y float64) float64 {
+	if x == 0 {
+		return math.NaN()
+	}
+	return (y - x) / math.Abs(x) * 100
+}
+
+// normalSF is the upper tail probability of the standard normal
+func normalSF(z float64) float64 {
+	return 0.5 * math.Erfc(z/math.Sqrt2)
+}
+
+// welchT returns the two-sided p-value of Welch's t-test, using the
+// normal approximation which is adequate for window-sized samples
+func welchT(a, b []float64) float64 {
+	return welchWeighted(unitCentroids(a), unitCentroids(b))
+}
+
+// welchWeighted is welchT for weighted samples
+func welchWeighted(a, b []centroid) float64 {
+	meanVar := func(x []centroid) (float64, float64, float64) {
+		var n, m, s float64
+		for _, c := range x {
+			n += c.weight
+			d := c.mean - m
+			m += d * c.weight / n
+			s += c.weight * d * (c.mean - m)
+		}
+		return n, m, s / (n - 1)
+	}
+	na, ma, va := meanVar(a)
+	nb, mb, vb := meanVar(b)
+	if na < 2 || nb < 2 {
+		return 1
+	}
+	se := math.Sqrt(va/na + vb/nb)
+	if se == 0 {
+		if ma == mb {
+			return 1
+		}
+		return 0
+	}
+	return 2 * normalSF(math.Abs(ma-mb)/se)
+}
+
+// mannWhitneyU returns the U statistic of a and the two-sided p-value of
+// the Mann-Whitney U test for two ascending slices, using the normal
+// approximation with ties. NaN samples are ignored, since they never
+// compare equal and would stall the ranking.
+func mannWhitneyU(a, b []float64) (float64, float64) {
+	return mannWhitney(unitCentroids(withoutNaN(a)), unitCentroids(withoutNaN(b)))
+}
+
+// mannWhitney is mannWhitneyU for weighted samples in ascending order
+func mannWhitney(a, b []centroid) (float64, float64) {
+	var n1, n2 float64
+	for _, c := range a {
+		n1 += c.weight
+	}
+	for _, c := range b {
+		n2 += c.weight
+	}
+	if n1 == 0 || n2 == 0 {
+		return 0, 1
+	}
+
+	// Rank the merged samples, averaging ranks of ties
+	var rankSumA, tieTerm float64
+	i, j, rank := 0, 0, 1.0
+	for i < len(a) || j < len(b) {
+		v := math.Inf(1)
+		if i < len(a) {
+			v = a[i].mean
+		}
+		if j < len(b) && b[j].mean < v {
+			v = b[j].mean
+		}
+		var ca, cb float64
+		for i < len(a) && a[i].mean == v {
+			ca += a[i].weight
+			i++
+		}
+		for j < len(b) && b[j].mean == v {
+			cb += b[j].weight
+			j++
+		}
+		t := ca + cb
+		rankSumA += ca * (rank + (t-1)/2)
+		tieTerm += t*t*t - t
+		rank += t
+	}
+
+	u := rankSumA - n1*(n1+1)/2
+	n := n1 + n2
+	sigma := math.Sqrt(n1 * n2 / 12 * ((n + 1) - tieTerm/(n*(n-1))))
+	if sigma == 0 {
+		return u, 1
+	}
+	return u, 2 * normalSF(math.Abs(u-n1*n2/2)/sigma)
+}
+
+// unitCentroids wraps samples as centroids of weight 1
+func unitCentroids(x []float64) []centroid {
+	out := make([]centroid, len(x))
+	for i, v := range x {
+		out[i] = centroid{mean: v, weight: 1}
+	}
+	return out
+}
+
+// appendNotNaN appends the values of src that are not NaN to dst
+func appendNotNaN(dst, src []float64) []float64 {
+	for _, v := range src {
+		if !math.IsNaN(v) {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
+
+// withoutNaN returns x without its NaN values, copying only when it has any
+func withoutNaN(x []float64) []float64 {
+	for i, v := range x {
+		if math.IsNaN(v) {
+			return appendNotNaN(append([]float64(nil), x[:i]...), x[i+1:])
+		}
+	}
+	return x
+}
@@ -0,0 +1,163 @@
+package streamstats
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// labeledRollup returns the rollup of a stream fed values
+func labeledRollup(t *testing.T, label string, values []float64) Rollup {
+	t.Helper()
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+	for _, v := range values {
+		ds.AddNumber(v)
+	}
+	r := MergeRollups(ds.Rollups()...)
+	r.Label = label
+	return r
+}
+
+func TestCompare(t *testing.T) {
+	var base, same, shifted []float64
+	for i := 0; i < 200; i++ {
+		v := float64(i % 50)
+		base = append(base, v)
+		same = append(same, 49-v)
+		shifted = append(shifted, v+25)
+	}
+	before := labeledRollup(t, "last week", base)
+
+	c := Compare(before, labeledRollup(t, "this week", shifted))
+	if c.Before != "last week" || c.After != "this week" {
+		t.Errorf("Compare() names = %q, %q", c.Before, c.After)
+	}
+	if c.PValue >= significanceLevel {
+		t.Errorf("shifted windows p-value = %v, want below %v", c.PValue, significanceLevel)
+	}
+	rows := make(map[string]ComparisonRow)
+	for _, r := range c.Rows {
+		rows[r.Stat] = r
+	}
+	if r := rows["mean"]; !r.Significant || math.Abs(r.Change-(25/24.5*100)) > 1e-9 {
+		t.Errorf("mean row = %+v, want a significant change of about +102%%", r)
+	}
+	if r := rows["count"]; r.Change != 0 || r.Significant {
+		t.Errorf("count row = %+v, want unchanged", r)
+	}
+
+	c = Compare(before, labeledRollup(t, "mirror", same))
+	if c.PValue < 0.99 {
+		t.Errorf("identical distributions p-value = %v, want about 1", c.PValue)
+	}
+	for _, r := range c.Rows {
+		if r.Significant {
+			t.Errorf("row %s of identical distributions is significant", r.Stat)
+		}
+	}
+
+	var sb strings.Builder
+	if err := RenderComparison(&sb, c); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), "| Stat | last week | mirror |") || !strings.Contains(sb.String(), "| p99 |") {
+		t.Errorf("RenderComparison() =\n%s", sb.String())
+	}
+}
+
+func TestMannWhitneyU(t *testing.T) {
+	// Fully separated samples give U = 0
+	u, p := mannWhitneyU([]float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10})
+	if u != 0 || p >= 0.05 {
+		t.Errorf("mannWhitneyU(separated) = %v, %v; want 0 and p < 0.05", u, p)
+	}
+	// All ties carry no information
+	if _, p := mannWhitneyU([]float64{1, 1}, []float64{1, 1}); p != 1 {
+		t.Errorf("mannWhitneyU(ties) p = %v, want 1", p)
+	}
+	if _, p := mannWhitneyU(nil, []float64{1}); p != 1 {
+		t.Errorf("mannWhitneyU(empty) p = %v, want 1", p)
+	}
+	// NaN samples are dropped rather than stalling the ranking
+	nan := math.NaN()
+	if u, _ := mannWhitneyU([]float64{nan, 1, 2}, []float64{nan, 3, 4}); u != 0 {
+		t.Errorf("mannWhitneyU with NaN U = %v, want 0", u)
+	}
+	if d := ksDistance([]float64{nan, 1, 2}, []float64{3, nan}); d != 1 {
+		t.Errorf("ksDistance with NaN = %v, want 1", d)
+	}
+	if p := welchT([]float64{1}, []float64{2, 3}); p != 1 {
+		t.Errorf("welchT with one sample = %v, want 1", p)
+	}
+	if got := percentChange(0, 5); !math.IsNaN(got) {
+		t.Errorf("percentChange(0, 5) = %v, want NaN", got)
+	}
+}
+
+func TestCompareRanges(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	week := 7 * 24 * time.Hour
+	now := t0
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Now: func() time.Time { return now }})
+	defer ds.Stop()
+
+	// Last week's latencies are 1..100, this week's 51..150
+	ds.SetEpoch("w1")
+	for i := 1; i <= 100; i++ {
+		ds.AddNumber(float64(i))
+	}
+	now = t0.Add(week)
+	ds.SetEpoch("w2")
+	for i := 51; i <= 150; i++ {
+		ds.AddNumber(float64(i))
+	}
+	now = t0.Add(2 * week)
+
+	c, err := ds.CompareRanges(t0, t0.Add(week), t0.Add(week), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Before != "2024-01-01T00:00:00Z/2024-01-08T00:00:00Z" || c.After != "2024-01-08T00:00:00Z/.." {
+		t.Errorf("labels = %q, %q", c.Before, c.After)
+	}
+	rows := make(map[string]ComparisonRow)
+	for _, r := range c.Rows {
+		rows[r.Stat] = r
+	}
+	if r := rows["mean"]; r.Before != 50.5 || r.After != 100.5 || !r.Significant {
+		t.Errorf("mean row = %+v, want a significant 50.5 -> 100.5", r)
+	}
+	if r := rows["p50"]; math.Abs(r.After-r.Before-50) > 2 || !r.Significant {
+		t.Errorf("p50 row = %+v, want a significant shift of about 50", r)
+	}
+	if r := rows["count"]; r.Before != 100 || r.After != 100 || r.Change != 0 {
+		t.Errorf("count row = %+v, want 100 on both sides", r)
+	}
+
+	byEpoch, err := ds.CompareEpochs("w1", "w2")
+	if err != nil || byEpoch.PValue != c.PValue {
+		t.Errorf("CompareEpochs() = %+v, %v, want the same test as CompareRanges", byEpoch, err)
+	}
+	if _, err := ds.CompareRanges(t0.Add(-week), t0, t0, time.Time{}); !errors.Is(err, ErrEmptyStream) {
+		t.Errorf("CompareRanges() of an empty range = %v, want ErrEmptyStream", err)
+	}
+}
+
+func TestMannWhitneyWeighted(t *testing.T) {
+	// Weights count as repeated samples
+	a := []float64{1, 1, 1, 2, 5}
+	b := []float64{2, 3, 3, 6}
+	wa := []centroid{{1, 3}, {2, 1}, {5, 1}}
+	wb := []centroid{{2, 1}, {3, 2}, {6, 1}}
+	u, p := mannWhitneyU(a, b)
+	wu, wp := mannWhitney(wa, wb)
+	if math.Abs(u-wu) > 1e-12 || math.Abs(p-wp) > 1e-12 {
+		t.Errorf("mannWhitney(weighted) = %v, %v, want %v, %v", wu, wp, u, p)
+	}
+	if got, want := welchWeighted(wa, wb), welchT(a, b); math.Abs(got-want) > 1e-12 {
+		t.Errorf("welchWeighted() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,230 @@
+package streamstats
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// aggregator is one concurrency design for the lifetime aggregates every
+// stream keeps; worker identifies the writing goroutine for sharding
+type aggregator interface {
+	add(worker int, v float64)
+	read() (count int64, mean float64)
+}
+
+// aggregates are count, sum, min and max, as guarded by minMaxLock
+type aggregates struct {
+	count    int64
+	sum      float64
+	min, max float64
+}
+
+func (a *aggregates) add(v float64) {
+	if a.count == 0 {
+		a.min, a.max = v, v
+	}
+	a.count++
+	a.sum += v
+	a.min = math.Min(a.min, v)
+	a.max = math.Max(a.max, v)
+}
+
+// mutexAggregator is the current design: one mutex for writers and readers
+type mutexAggregator struct {
+	mu sync.Mutex
+	a  aggregates
+}
+
+func (m *mutexAggregator) add(_ int, v float64) {
+	m.mu.Lock()
+	m.a.add(v)
+	m.mu.Unlock()
+}
+
+func (m *mutexAggregator) read() (int64, float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.a.count, m.a.sum / float64(m.a.count)
+}
+
+// rwMutexAggregator lets readers share the lock
+type rwMutexAggregator struct {
+	mu sync.RWMutex
+	a  aggregates
+}
+
+func (m *rwMutexAggregator) add(_ int, v float64) {
+	m.mu.Lock()
+	m.a.add(v)
+	m.mu.Unlock()
+}
+
+func (m *rwMutexAggregator) read() (int64, float64) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.a.count, m.a.sum / float64(m.a.count)
+}
+
+// shardedAggregator gives each writer one of GOMAXPROCS mutex-guarded
+// shards; readers combine every shard
+type shardedAggregator struct {
+	shards []paddedShard
+}
+
+type paddedShard struct {
+	mu sync.Mutex
+	a  aggregates
+	_  [64]byte // Keeps neighbouring shards off the same cache line
+}
+
+func newShardedAggregator() *shardedAggregator {
+	return &shardedAggregator{shards: make([]paddedShard, runtime.GOMAXPROCS(0))}
+}
+
+func (s *shardedAggregator) add(worker int, v float64) {
+	sh := &s.shards[worker%len(s.shards)]
+	sh.mu.Lock()
+	sh.a.add(v)
+	sh.mu.Unlock()
+}
+
+func (s *shardedAggregator) read() (int64, float64) {
+	var count int64
+	var sum float64
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.Lock()
+		count += sh.a.count
+		sum += sh.a.sum
+		sh.mu.Unlock()
+	}
+	return count, sum / float64(count)
+}
+
+// atomicAggregator updates each aggregate with compare-and-swap loops;
+// readers never block but may see a sum and count from different samples
+type atomicAggregator struct {
+	count    atomic.Int64
+	sum      atomic.Uint64 // float64 bits
+	min, max atomic.Uint64 // float64 bits
+}
+
+func newAtomicAggregator() *atomicAggregator {
+	a := &atomicAggregator{}
+	a.min.Store(math.Float64bits(math.Inf(1)))
+	a.max.Store(math.Float64bits(math.Inf(-1)))
+	return a
+}
+
+// casFloat applies f to the float64 in bits until no other writer interferes
+func casFloat(bits *atomic.Uint64, f func(float64) float64) {
+	for {
+		old := bits.Load()
+		next := math.Float64bits(f(math.Float64frombits(old)))
+		if next == old || bits.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+func (a *atomicAggregator) add(_ int, v float64) {
+	a.count.Add(1)
+	casFloat(&a.sum, func(s float64) float64 { return s + v })
+	casFloat(&a.min, func(m float64) float64 { return math.Min(m, v) })
+	casFloat(&a.max, func(m float64) float64 { return math.Max(m, v) })
+}
+
+func (a *atomicAggregator) read() (int64, float64) {
+	count := a.count.Load()
+	return count, math.Float64frombits(a.sum.Load()) / float64(count)
+}
+
+// streamAggregator measures DataStreamStats itself, including the heaps
+// and window that the models above leave out
+type streamAggregator struct {
+	ds *DataStreamStats
+}
+
+func (s streamAggregator) add(_ int, v float64) { s.ds.AddNumber(v) }
+
+func (s streamAggregator) read() (int64, float64) { return s.ds.Count(), s.ds.GetMean() }
+
+// concurrencyReaders read continuously while the writers run
+const concurrencyReaders = 2
+
+// BenchmarkConcurrency runs every design with 1 to 64 writer goroutines
+// and concurrent readers. ns/op is per sample written; reads/s is the
+// throughput the readers achieved meanwhile. See BENCHMARKS.md.
+func BenchmarkConcurrency(b *testing.B) {
+	designs := []struct {
+		name string
+		new  func() aggregator
+	}{
+		{"mutex", func() aggregator { return &mutexAggregator{} }},
+		{"rwmutex", func() aggregator { return &rwMutexAggregator{} }},
+		{"sharded", func() aggregator { return newShardedAggregator() }},
+		{"atomic", func() aggregator { return newAtomicAggregator() }},
+		{"stream", func() aggregator { return streamAggregator{NewDataStreamStats(1000)} }},
+	}
+	for _, d := range designs {
+		for _, writers := range []int{1, 2, 4, 8, 16, 32, 64} {
+			b.Run(fmt.Sprintf("%s/writers=%d", d.name, writers), func(b *testing.B) {
+				benchmarkDesign(b, d.new(), writers)
+			})
+		}
+	}
+}
+
+func benchmarkDesign(b *testing.B, agg aggregator, writers int) {
+	if s, ok := agg.(streamAggregator); ok {
+		defer s.ds.Stop()
+	}
+	agg.add(0, 1) // Readers never divide by a zero count
+
+	stop := make(chan struct{})
+	var reads atomic.Int64
+	var readers sync.WaitGroup
+	for range concurrencyReaders {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				agg.read()
+				reads.Add(1)
+			}
+		}()
+	}
+
+	b.ResetTimer()
+	var wg sync.WaitGroup
+	for w := range writers {
+		n := b.N / writers
+		if w == 0 {
+			n += b.N % writers
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range n {
+				agg.add(w, float64(i%1000))
+			}
+		}()
+	}
+	wg.Wait()
+	b.StopTimer()
+
+	close(stop)
+	readers.Wait()
+	if secs := b.Elapsed().Seconds(); secs > 0 {
+		b.ReportMetric(float64(reads.Load())/secs, "reads/s")
+	}
+}
@@ -0,0 +1,222 @@
+package streamstats
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ChartKind selects the statistic plotted on a control chart
+type ChartKind int
+
+const (
+	// Shewhart plots individual samples
+	Shewhart ChartKind = iota
+	// EWMA plots an exponentially weighted moving average, which is more
+	// sensitive to small sustained shifts
+	EWMA
+)
+
+// Violation is a Western Electric rule firing on a control chart
+type Violation struct {
+	Rule        int // 1-4, see ControlChart
+	Description string
+	Value       float64 // Plotted value that completed the pattern
+	Index       int64   // Sample index after training
+	Time        time.Time
+}
+
+// ControlChart is a statistical process control chart. The first training
+// samples fix the center line and sigma; later samples are classified by
+// the Western Electric rules:
+//
+//  1. one point beyond 3 sigma
+//  2. two of three consecutive points beyond 2 sigma on the same side
+//  3. four of five consecutive points beyond 1 sigma on the same side
+//  4. eight consecutive points on the same side of the center line
+//
+// EWMA charts only apply rule 1, using the EWMA's own limits. A chart
+// implements Statistic, so it can be attached with RegisterStatistic; its
+// Value is the number of violations so far.
+type ControlChart struct {
+	mu          sync.Mutex
+	kind        ChartKind
+	training    int
+	lambda      float64 // EWMA smoothing factor
+	onViolation func(Violation)
+
+	n      int64 // Samples seen
+	mean   float64
+	m2     float64
+	center float64
+	sigma  float64 // Sigma of the plotted statistic
+	ewma   float64
+	zones  []float64 // Recent plotted values in sigma units, newest last
+	count  int64     // Violations so far
+}
+
+// minTraining is the fewest training samples that give a sigma
+const minTraining = 2
+
+// NewControlChart creates a chart trained on the first training samples,
+// at least 2. lambda is the EWMA smoothing factor in (0, 1], default 0.2,
+// and is ignored for Shewhart charts. onViolation may be nil; when the
+// chart is attached to a stream it runs inside AddNumber and must not call
+// back into it.
+func NewControlChart(kind ChartKind, training int, lambda float64, onViolation func(Violation)) *ControlChart {
+	training = max(training, minTraining)
+	if !(lambda > 0 && lambda <= 1) {
+		lambda = 0.2
+	}
+	return &ControlChart{
+		kind:        kind,
+		training:    training,
+		lambda:      lambda,
+		onViolation: onViolation,
+	}
+}
+
+// Name implements Statistic
+func (cc *ControlChart) Name() string {
+	if cc.kind == EWMA {
+		return "ewma_chart"
+	}
+	return "shewhart_chart"
+}
+
+// Observe implements Statistic
+func (cc *ControlChart) Observe(x float64) {
+	for _, v := range cc.Check(x) {
+		if cc.onViolation != nil {
+			cc.onViolation(v)
+		}
+	}
+}
+
+// Check adds x and returns the rules it triggered
+func (cc *ControlChart) Check(x float64) []Violation {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	cc.n++
+	if cc.n <= int64(cc.training) {
+		d := x - cc.mean
+		cc.mean += d / float64(cc.n)
+		cc.m2 += d * (x - cc.mean)
+		if cc.n == int64(cc.training) {
+			cc.freeze()
+		}
+		return nil
+	}
+	if cc.sigma == 0 {
+		return nil
+	}
+
+	plotted := x
+	if cc.kind == EWMA {
+		cc.ewma = cc.lambda*x + (1-cc.lambda)*cc.ewma
+		plotted = cc.ewma
+	}
+	z := (plotted - cc.center) / cc.sigma
+	cc.zones = append(cc.zones, z)
+	if len(cc.zones) > 8 {
+		cc.zones = cc.zones[1:]
+	}
+
+	var out []Violation
+	fire := func(rule int, desc string) {
+		cc.count++
+		out = append(out, Violation{
+			Rule:        rule,
+			Description: desc,
+			Value:       plotted,
+			Index:       cc.n - int64(cc.training),
+			Time:        time.Now(),
+		})
+	}
+
+	if math.Abs(z) > 3 {
+		fire(1, "point beyond 3 sigma")
+	}
+	if cc.kind == Shewhart {
+		if countBeyond(cc.zones, 3, 2) >= 2 {
+			fire(2, "2 of 3 points beyond 2 sigma")
+		}
+		if countBeyond(cc.zones, 5, 1) >= 4 {
+			fire(3, "4 of 5 points beyond 1 sigma")
+		}
+		if countBeyond(cc.zones, 8, 0) >= 8 {
+			fire(4, "8 points on one side of center")
+		}
+	}
+	return out
+}
+
+// freeze fixes the center line and sigma at the end of training
+func (cc *ControlChart) freeze() {
+	cc.center = cc.mean
+	cc.sigma = math.Sqrt(cc.m2 / float64(cc.n-1))
+	if cc.kind == EWMA {
+		cc.ewma = cc.center
+		cc.sigma *= math.Sqrt(cc.lambda / (2 - cc.lambda))
+	}
+}
+
+// countBeyond counts how many of the last n zone values lie beyond k
+// sigma on the side of the newest value
+func countBeyond(zones []float64, n int, k float64) int {
+	if len(zones) < n {
+		return 0
+	}
+	last := zones[len(zones)-n:]
+	side := math.Copysign(1, last[n-1])
+	if last[n-1] == 0 {
+		return 0
+	}
+	c := 0
+	for _, z := range last {
+		if z*side > k {
+			c++
+		}
+	}
+	return c
+}
+
+// Limits returns the lower control limit, center line and upper control
+// limit of the plotted statistic; trained is false during training
+func (cc *ControlChart) Limits() (lcl, center, ucl float64, trained bool) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.n < int64(cc.training) {
+		return 0, 0, 0, false
+	}
+	return cc.center - 3*cc.sigma, cc.center, cc.center + 3*cc.sigma, true
+}
+
+// Value implements Statistic
+func (cc *ControlChart) Value() float64 {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return float64(cc.count)
+}
+
+// Merge implements Statistic; chart histories cannot be combined
+func (cc *ControlChart) Merge(other Statistic) error {
+	return cc.CheckMerge(other)
+}
+
+// CheckMerge implements MergeChecker, refusing every merge
+func (cc *ControlChart) CheckMerge(Statistic) error {
+	return fmt.Errorf("%w %s", ErrIncompatibleMerge, cc.Name())
+}
+
+// Reset implements Statistic, restarting training
+func (cc *ControlChart) Reset() {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.n, cc.mean, cc.m2 = 0, 0, 0
+	cc.center, cc.sigma, cc.ewma = 0, 0, 0
+	cc.zones = nil
+	cc.count = 0
+}
@@ -0,0 +1,101 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+)
+
+// trainChart feeds 20 samples alternating between 9 and 11: center 10,
+// sigma about 1.03
+func trainChart(cc *ControlChart) {
+	for i := 0; i < 20; i++ {
+		cc.Check(9 + float64(i%2)*2)
+	}
+}
+
+func TestShewhartRules(t *testing.T) {
+	cc := NewControlChart(Shewhart, 20, 0, nil)
+	if _, _, _, trained := cc.Limits(); trained {
+		t.Error("Limits() reports trained before any sample")
+	}
+	trainChart(cc)
+	lcl, center, ucl, trained := cc.Limits()
+	if !trained || math.Abs(center-10) > 1e-9 || ucl-center < 3 || ucl-center > 3.2 || math.Abs(center-lcl-(ucl-center)) > 1e-9 {
+		t.Fatalf("Limits() = %v, %v, %v, %v; want center 10 and 3 sigma of about 1.03", lcl, center, ucl, trained)
+	}
+
+	if v := cc.Check(20); len(v) != 1 || v[0].Rule != 1 || v[0].Index != 1 {
+		t.Errorf("Check(20) = %+v, want rule 1 at index 1", v)
+	}
+
+	cc.Reset()
+	trainChart(cc)
+	// Slightly above center: only the run rule can see it
+	for i := 1; i <= 8; i++ {
+		v := cc.Check(10.5)
+		switch {
+		case i < 8 && len(v) != 0:
+			t.Errorf("point %d: %+v, want no violation yet", i, v)
+		case i == 8 && (len(v) != 1 || v[0].Rule != 4):
+			t.Errorf("point 8: %+v, want rule 4", v)
+		}
+	}
+
+	cc.Reset()
+	trainChart(cc)
+	cc.Check(10)
+	cc.Check(12.5)
+	if v := cc.Check(12.5); len(v) != 1 || v[0].Rule != 2 {
+		t.Errorf("two points beyond 2 sigma: %+v, want rule 2", v)
+	}
+}
+
+func TestEWMAChart(t *testing.T) {
+	var fired []Violation
+	cc := NewControlChart(EWMA, 20, 0.2, func(v Violation) { fired = append(fired, v) })
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+	if err := ds.RegisterStatistic(cc); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 20; i++ {
+		ds.AddNumber(9 + float64(i%2)*2)
+	}
+	// A sustained shift of 1.5 sigma, too small for individual points
+	for i := 0; i < 20 && len(fired) == 0; i++ {
+		ds.AddNumber(11.5)
+	}
+	if len(fired) == 0 || fired[0].Rule != 1 {
+		t.Fatalf("violations = %+v, want the EWMA beyond its limit", fired)
+	}
+	if got, ok := ds.GetStatistic("ewma_chart"); !ok || got != float64(len(fired)) {
+		t.Errorf("GetStatistic(ewma_chart) = %v, %v, want %d", got, ok, len(fired))
+	}
+}
+
+func TestControlChartTrainingClamp(t *testing.T) {
+	for _, training := range []int{-1, 0, 1} {
+		cc := NewControlChart(Shewhart, training, 0, nil)
+		cc.Check(9)
+		if _, _, _, trained := cc.Limits(); trained {
+			t.Errorf("training %d: trained after one sample", training)
+		}
+		cc.Check(11)
+		lcl, center, ucl, trained := cc.Limits()
+		if !trained || math.Abs(center-10) > 1e-9 || lcl >= center || ucl <= center {
+			t.Errorf("training %d: Limits() = %v, %v, %v, %v; want trained on 2 samples", training, lcl, center, ucl, trained)
+		}
+		if v := cc.Check(100); len(v) != 1 || v[0].Rule != 1 {
+			t.Errorf("training %d: Check(100) = %+v, want rule 1", training, v)
+		}
+	}
+
+	// An invalid lambda falls back to 0.2 rather than a zero sigma
+	cc := NewControlChart(EWMA, 2, 0, nil)
+	cc.Check(9)
+	cc.Check(11)
+	if _, center, ucl, _ := cc.Limits(); ucl <= center {
+		t.Errorf("EWMA with lambda 0: ucl %v, center %v, want a positive sigma", ucl, center)
+	}
+}
@@ -0,0 +1,110 @@
+package streamstats
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// DebugState describes the internal structures of a stream for diagnosing
+// accuracy or performance complaints
+type DebugState struct {
+	Name           string                   `json:"name"`
+	Time           time.Time                `json:"time"`
+	Count          int64                    `json:"count"`
+	ShedCount      int64                    `json:"shed_count"`
+	LowerHeapLen   int                      `json:"lower_heap_len"`
+	LowerHeapCap   int                      `json:"lower_heap_cap"`
+	UpperHeapLen   int                      `json:"upper_heap_len"`
+	UpperHeapCap   int                      `json:"upper_heap_cap"`
+	BalanceCounter int                      `json:"balance_counter"`
+	RingSize       int                      `json:"ring_size"`
+	RingCap        int                      `json:"ring_cap"`
+	RingHead       int                      `json:"ring_head"`
+	CacheValid     bool                     `json:"cache_valid"`
+	Statistics     int                      `json:"statistics"`
+	DerivedFields  int                      `json:"derived_fields"`
+	LockWait       map[string]time.Duration `json:"lock_wait_ns"` // Time the dump waited for each lock
+}
+
+// DebugState captures the current internal state.
+// Locks are taken one at a time, so the values are not a consistent cut.
+func (ds *DataStreamStats) DebugState() DebugState {
+	ds.lazyInit()
+	st := DebugState{
+		Name:     ds.name,
+		Time:     time.Now(),
+		LockWait: make(map[string]time.Duration),
+	}
+
+	wait := func(name string, mu *sync.Mutex) {
+		start := time.Now()
+		mu.Lock()
+		st.LockWait[name] = time.Since(start)
+	}
+
+	wait("minmax", &ds.minMaxLock)
+	st.Count = ds.count
+	st.ShedCount = ds.shedCount
+	ds.minMaxLock.Unlock()
+
+	wait("heap", &ds.heapLock)
+	st.LowerHeapLen, st.LowerHeapCap = len(ds.lower), cap(ds.lower)
+	st.UpperHeapLen, st.UpperHeapCap = len(ds.upper), cap(ds.upper)
+	st.BalanceCounter = ds.balanceCounter
+	ds.heapLock.Unlock()
+
+	wait("percentile", &ds.percentileLock)
+	st.RingSize, st.RingCap, st.RingHead = ds.recentData.size, ds.recentData.cap, ds.recentData.head
+	ds.percentileLock.Unlock()
+
+	wait("cached", &ds.cachedLock)
+	st.CacheValid = ds.cacheUpdated
+	st.DerivedFields = len(ds.derived)
+	ds.cachedLock.Unlock()
+
+	wait("plugin", &ds.pluginLock)
+	st.Statistics = len(ds.plugins)
+	ds.pluginLock.Unlock()
+
+	return st
+}
+
+// DumpState writes the internal state as one JSON line
+func (ds *DataStreamStats) DumpState(w io.Writer) error {
+	ds.lazyInit()
+	return json.NewEncoder(w).Encode(ds.DebugState())
+}
+
+// StartStateDump dumps the internal state to w every interval until the
+// returned stop function is called. Dumping ends at the first write error,
+// which stop returns. stop may be called more than once.
+func (ds *DataStreamStats) StartStateDump(w io.Writer, interval time.Duration) (stop func() error) {
+	ds.lazyInit()
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	var err error
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err = ds.DumpState(w); err != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() error {
+		once.Do(func() { close(done) })
+		<-exited
+		return err
+	}
+}
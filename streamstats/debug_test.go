@@ -0,0 +1,83 @@
+package streamstats
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for a dump goroutine and a reader
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Len()
+}
+
+// failWriter fails every write and signals the attempt on tried
+type failWriter struct{ tried chan struct{} }
+
+func (fw failWriter) Write([]byte) (int, error) {
+	select {
+	case fw.tried <- struct{}{}:
+	default:
+	}
+	return 0, errors.New("disk full")
+}
+
+func TestDumpState(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Name: "latency", Capacity: 10})
+	defer ds.Stop()
+	for i := 0; i < 5; i++ {
+		ds.AddNumber(float64(i))
+	}
+
+	var buf bytes.Buffer
+	if err := ds.DumpState(&buf); err != nil {
+		t.Fatalf("DumpState: %v", err)
+	}
+	var st DebugState
+	if err := json.Unmarshal(buf.Bytes(), &st); err != nil {
+		t.Fatalf("DumpState wrote invalid JSON: %v", err)
+	}
+	if st.Name != "latency" || st.Count != 5 || st.RingSize != 5 {
+		t.Errorf("DumpState() = %+v, want name latency, count 5 and 5 ring entries", st)
+	}
+}
+
+func TestStartStateDump(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+
+	var buf syncBuffer
+	stop := ds.StartStateDump(&buf, time.Millisecond)
+	for buf.Len() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := stop(); err != nil {
+		t.Errorf("stop() = %v, want nil", err)
+	}
+	if err := stop(); err != nil {
+		t.Errorf("second stop() = %v, want nil", err)
+	}
+
+	fw := failWriter{tried: make(chan struct{}, 1)}
+	stop = ds.StartStateDump(fw, time.Millisecond)
+	<-fw.tried
+	if err := stop(); err == nil {
+		t.Error("stop() after failed writes = nil, want the write error")
+	}
+}
@@ -0,0 +1,94 @@
+package streamstats
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// maxDecayWeight triggers renormalization before forward-decay weights overflow
+const maxDecayWeight = 1e100
+
+// DecayingQuantiles estimates quantiles where each sample's influence
+// halves every halfLife, so percentiles follow recent behaviour
+// continuously instead of through hard window cutoffs.
+//
+// It uses forward decay: new samples get exponentially growing weights
+// relative to a landmark time, which is equivalent to decaying old ones.
+type DecayingQuantiles struct {
+	mu       sync.Mutex
+	digest   *tdigest
+	rate     float64 // Decay rate per second, ln2 / halfLife
+	landmark time.Time
+	now      func() time.Time
+}
+
+// NewDecayingQuantiles creates a recency-weighted quantile estimator
+func NewDecayingQuantiles(halfLife time.Duration, compression float64) *DecayingQuantiles {
+	return &DecayingQuantiles{
+		digest:   newTDigest(compression),
+		rate:     math.Ln2 / halfLife.Seconds(),
+		landmark: time.Now(),
+		now:      time.Now,
+	}
+}
+
+// Add records v at the current time
+func (dq *DecayingQuantiles) Add(v float64) {
+	dq.AddAt(dq.now(), v)
+}
+
+// AddAt records v observed at t
+func (dq *DecayingQuantiles) AddAt(t time.Time, v float64) {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	w := math.Exp(dq.rate * t.Sub(dq.landmark).Seconds())
+	if w > maxDecayWeight {
+		// Move the landmark to t, shrinking existing weights accordingly
+		dq.digest.scale(1 / w)
+		dq.landmark = t
+		w = 1
+	}
+	dq.digest.add(v, w)
+}
+
+// Merge adds the samples of other, which must have the same half-life
+func (dq *DecayingQuantiles) Merge(other *DecayingQuantiles) error {
+	other.mu.Lock()
+	rate, landmark := other.rate, other.landmark
+	theirs := newTDigest(other.digest.compression)
+	theirs.merge(other.digest)
+	other.mu.Unlock()
+
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	if rate != dq.rate {
+		return fmt.Errorf("%w decaying quantiles with different half-lives", ErrIncompatibleMerge)
+	}
+	// Rescale to the later landmark so no weight grows
+	if d := landmark.Sub(dq.landmark).Seconds(); d > 0 {
+		dq.digest.scale(math.Exp(-rate * d))
+		dq.landmark = landmark
+	} else {
+		theirs.scale(math.Exp(rate * d))
+	}
+	dq.digest.merge(theirs)
+	return nil
+}
+
+// Mean returns the recency-weighted mean
+func (dq *DecayingQuantiles) Mean() float64 {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+	return dq.digest.mean()
+}
+
+// Quantile returns the recency-weighted pth percentile
+func (dq *DecayingQuantiles) Quantile(p float64) float64 {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+	return dq.digest.quantile(p)
+}
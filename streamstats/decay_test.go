@@ -0,0 +1,76 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDecayingQuantiles(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	dq := NewDecayingQuantiles(time.Minute, 100)
+	dq.landmark = t0
+
+	// 100 old samples of 10 weigh as much as 25 samples of 50 two
+	// half-lives later, so the mean is halfway between
+	for i := 0; i < 100; i++ {
+		dq.AddAt(t0, 10)
+	}
+	for i := 0; i < 25; i++ {
+		dq.AddAt(t0.Add(2*time.Minute), 50)
+	}
+	if got := dq.Mean(); math.Abs(got-30) > 1e-6 {
+		t.Errorf("Mean() = %v, want 30", got)
+	}
+	if got := dq.Quantile(90); got != 50 {
+		t.Errorf("p90 = %v, want the recent value 50", got)
+	}
+
+	// Weights far past the landmark are renormalized, not overflowed
+	late := t0.Add(24 * time.Hour)
+	dq.AddAt(late, 90)
+	if got := dq.Mean(); math.IsNaN(got) || math.Abs(got-90) > 1e-6 {
+		t.Errorf("Mean() a day later = %v, want 90", got)
+	}
+}
+
+func TestDecayingQuantilesMerge(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := NewDecayingQuantiles(time.Minute, 100)
+	a.landmark = t0
+	b := NewDecayingQuantiles(time.Minute, 100)
+	b.landmark = t0.Add(time.Minute)
+	a.AddAt(t0.Add(time.Minute), 10)
+	b.AddAt(t0.Add(time.Minute), 30)
+
+	if err := a.Merge(b); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	if got := a.Mean(); math.Abs(got-20) > 1e-9 {
+		t.Errorf("merged Mean() = %v, want 20 as both samples are equally recent", got)
+	}
+	if err := a.Merge(NewDecayingQuantiles(time.Hour, 100)); err == nil {
+		t.Error("Merge of different half-lives succeeded, want error")
+	}
+}
+
+func TestDecayedPercentile(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ds := NewDataStreamStatsWithOptions(Options{
+		Capacity:      1000,
+		DecayHalfLife: time.Second,
+		Now:           func() time.Time { return now },
+	})
+	defer ds.Stop()
+
+	for i := 0; i < 100; i++ {
+		ds.AddNumberAt(now.Add(-time.Minute), 100)
+		ds.AddNumberAt(now, 1)
+	}
+	if got := ds.GetPercentile(75); got != 100 {
+		t.Errorf("plain p75 = %v, want 100", got)
+	}
+	if got := ds.GetDecayedPercentile(75); got != 1 {
+		t.Errorf("decayed p75 = %v, want 1 once old samples faded", got)
+	}
+}
@@ -0,0 +1,79 @@
+package streamstats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// derivedField is a named expression evaluated at snapshot time
+type derivedField struct {
+	name string
+	expr exprNode
+}
+
+// DefineDerived adds a field computed from other statistics on every
+// GetCachedStats and Snapshot, e.g. DefineDerived("spread", "p99 - p50").
+// Expressions may use mean, median, min, max, stddev, variance, skewness,
+// kurtosis, count, pN for any integer percentile N, and the names of
+// registered custom statistics.
+func (ds *DataStreamStats) DefineDerived(name, expr string) error {
+	ds.lazyInit()
+	n, err := parseExpr(expr)
+	if err != nil {
+		return fmt.Errorf("derived field %q: %w", name, err)
+	}
+
+	ds.cachedLock.Lock()
+	defer ds.cachedLock.Unlock()
+	ds.derived = append(ds.derived, derivedField{name: name, expr: n})
+	ds.cacheUpdated = false
+	return nil
+}
+
+// lookupVar resolves a variable used in a derived expression
+func (ds *DataStreamStats) lookupVar(name string) (float64, error) {
+	switch name {
+	case "mean":
+		return ds.GetMean(), nil
+	case "median":
+		return ds.GetMedian(), nil
+	case "min":
+		return ds.GetMin(), nil
+	case "max":
+		return ds.GetMax(), nil
+	case "stddev":
+		return ds.GetStdDev(), nil
+	case "variance":
+		return ds.GetVariance(), nil
+	case "skewness":
+		return ds.GetSkewness(), nil
+	case "kurtosis":
+		return ds.GetKurtosis(), nil
+	case "count":
+		ds.minMaxLock.Lock()
+		defer ds.minMaxLock.Unlock()
+		return float64(ds.count), nil
+	}
+	if strings.HasPrefix(name, "p") {
+		if p, err := strconv.Atoi(name[1:]); err == nil && p >= 0 && p <= 100 {
+			return ds.GetPercentile(float64(p)), nil
+		}
+	}
+	if v, ok := ds.GetStatistic(name); ok {
+		return v, nil
+	}
+	return 0, fmt.Errorf("unknown variable %q", name)
+}
+
+// evalDerived evaluates every derived field; callers hold cachedLock
+func (ds *DataStreamStats) evalDerived() {
+	for _, d := range ds.derived {
+		v, err := d.expr.eval(ds.lookupVar)
+		if err != nil {
+			delete(ds.cached.derived, d.name)
+			continue
+		}
+		ds.cached.derived[d.name] = v
+	}
+}
@@ -0,0 +1,62 @@
+package streamstats
+
+import "testing"
+
+func TestDerivedSnapshot(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+
+	if err := ds.DefineDerived("spread", "max - min"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ds.DefineDerived("bad", "max -"); err == nil {
+		t.Error("DefineDerived() with a malformed expression succeeded")
+	}
+	ds.DefineDerived("per_min", "count / min")
+
+	// Snapshots after every sample must not serve a stale value
+	for i := 1; i <= 3; i++ {
+		ds.AddNumber(float64(i * 10))
+		if got := ds.Snapshot().Derived["spread"]; got != float64((i-1)*10) {
+			t.Errorf("Snapshot().Derived[spread] after %d samples = %v, want %d", i, got, (i-1)*10)
+		}
+	}
+
+	// Expressions failing to evaluate are left out
+	ds.AddNumber(0)
+	if v, ok := ds.Snapshot().Derived["per_min"]; ok {
+		t.Errorf("Derived[per_min] with min 0 = %v, want absent", v)
+	}
+}
+
+func TestParseExpr(t *testing.T) {
+	vars := map[string]float64{"a": 2, "b": 3}
+	lookup := func(name string) (float64, error) { return vars[name], nil }
+
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"a * b - 1", 5},
+		{"-a + 10 / 4", 0.5},
+		{"b - a - 1", 0},
+	}
+	for _, tt := range tests {
+		n, err := parseExpr(tt.expr)
+		if err != nil {
+			t.Errorf("parseExpr(%q): %v", tt.expr, err)
+			continue
+		}
+		if got, err := n.eval(lookup); err != nil || got != tt.want {
+			t.Errorf("%q = %v, %v, want %v", tt.expr, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "1 +", "(1", "1 2", "a $ b"} {
+		if _, err := parseExpr(bad); err == nil {
+			t.Errorf("parseExpr(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package streamstats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DriftEvent reports a distribution shift between consecutive blocks
+type DriftEvent struct {
+	Time       time.Time
+	KS         float64             // Kolmogorov-Smirnov distance, 0..1
+	QuantDelta map[float64]float64 // Relative change of p50/p95/p99
+}
+
+// driftQuantiles are compared between consecutive blocks
+var driftQuantiles = []float64{50, 95, 99}
+
+// DriftDetector compares the distributions of consecutive tumbling blocks
+// of samples and reports gradual shifts that threshold alerts miss. It
+// implements Statistic, so it can be attached with RegisterStatistic;
+// its Value is the KS distance between the last two blocks.
+type DriftDetector struct {
+	mu        sync.Mutex
+	blockSize int
+	threshold float64 // KS distance at or above which onDrift is called
+	onDrift   func(DriftEvent)
+	current   []float64
+	previous  []float64 // Sorted samples of the last complete block
+	lastKS    float64
+	events    int64
+}
+
+// NewDriftDetector compares blocks of blockSize samples and calls onDrift
+// (if non-nil) when their KS distance reaches threshold. When attached to
+// a stream, onDrift runs inside AddNumber and must not call back into it.
+func NewDriftDetector(blockSize int, threshold float64, onDrift func(DriftEvent)) *DriftDetector {
+	return &DriftDetector{
+		blockSize: blockSize,
+		threshold: threshold,
+		onDrift:   onDrift,
+		current:   make([]float64, 0, blockSize),
+	}
+}
+
+// Name implements Statistic
+func (dd *DriftDetector) Name() string { return "drift" }
+
+// Observe implements Statistic
+func (dd *DriftDetector) Observe(v float64) {
+	dd.mu.Lock()
+	dd.current = append(dd.current, v)
+	if len(dd.current) < dd.blockSize {
+		dd.mu.Unlock()
+		return
+	}
+
+	block := dd.current
+	sort.Float64s(block)
+	dd.current = make([]float64, 0, dd.blockSize)
+	prev := dd.previous
+	dd.previous = block
+	if prev == nil {
+		dd.mu.Unlock()
+		return
+	}
+
+	ev := DriftEvent{
+		Time:       time.Now(),
+		KS:         ksDistance(prev, block),
+		QuantDelta: make(map[float64]float64, len(driftQuantiles)),
+	}
+	for _, q := range driftQuantiles {
+		ev.QuantDelta[q] = percentChange(sortedPercentile(prev, q), sortedPercentile(block, q))
+	}
+	dd.lastKS = ev.KS
+	fire := ev.KS >= dd.threshold
+	if fire {
+		dd.events++
+	}
+	dd.mu.Unlock()
+
+	if fire && dd.onDrift != nil {
+		dd.onDrift(ev)
+	}
+}
+
+// Value implements Statistic
+func (dd *DriftDetector) Value() float64 {
+	dd.mu.Lock()
+	defer dd.mu.Unlock()
+	return dd.lastKS
+}
+
+// Events returns the number of drift events reported so far
+func (dd *DriftDetector) Events() int64 {
+	dd.mu.Lock()
+	defer dd.mu.Unlock()
+	return dd.events
+}
+
+// Merge implements Statistic; block sequences cannot be combined
+func (dd *DriftDetector) Merge(other Statistic) error {
+	return dd.CheckMerge(other)
+}
+
+// CheckMerge implements MergeChecker, refusing every merge
+func (dd *DriftDetector) CheckMerge(Statistic) error {
+	return fmt.Errorf("%w drift detectors", ErrIncompatibleMerge)
+}
+
+// Reset implements Statistic
+func (dd *DriftDetector) Reset() {
+	dd.mu.Lock()
+	defer dd.mu.Unlock()
+	dd.current = dd.current[:0]
+	dd.previous = nil
+	dd.lastKS = 0
+	dd.events = 0
+}
+
+// ksDistance is the two-sample Kolmogorov-Smirnov statistic of two
+// ascending slices, ignoring NaN samples
+func ksDistance(a, b []float64) float64 {
+	a, b = withoutNaN(a), withoutNaN(b)
+	var i, j int
+	var d float64
+	for i < len(a) && j < len(b) {
+		v := math.Min(a[i], b[j])
+		for i < len(a) && a[i] == v {
+			i++
+		}
+		for j < len(b) && b[j] == v {
+			j++
+		}
+		d = math.Max(d, math.Abs(float64(i)/float64(len(a))-float64(j)/float64(len(b))))
+	}
+	return d
+}
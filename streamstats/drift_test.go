@@ -0,0 +1,65 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDriftDetector(t *testing.T) {
+	var events []DriftEvent
+	dd := NewDriftDetector(100, 0.5, func(ev DriftEvent) { events = append(events, ev) })
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+	if err := ds.RegisterStatistic(dd); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two blocks of the same distribution do not drift
+	for i := 0; i < 200; i++ {
+		ds.AddNumber(float64(i % 100))
+	}
+	if dd.Value() != 0 || len(events) != 0 {
+		t.Errorf("stable blocks: KS %v with %d events, want 0 and none", dd.Value(), len(events))
+	}
+
+	// A block shifted by 60 drifts
+	for i := 0; i < 100; i++ {
+		ds.AddNumber(float64(i + 60))
+	}
+	if len(events) != 1 || dd.Events() != 1 {
+		t.Fatalf("shifted block reported %d events, want 1", len(events))
+	}
+	ev := events[0]
+	if math.Abs(ev.KS-0.6) > 1e-9 || dd.Value() != ev.KS {
+		t.Errorf("KS = %v, Value() = %v, want 0.6", ev.KS, dd.Value())
+	}
+	if got := ev.QuantDelta[50]; math.Abs(got-60/49.0*100) > 1e-9 {
+		t.Errorf("p50 change = %v%%, want about +122%%", got)
+	}
+	if snap := ds.Snapshot(); snap.Custom["drift"] != ev.KS {
+		t.Errorf("Snapshot().Custom[drift] = %v, want %v", snap.Custom["drift"], ev.KS)
+	}
+
+	dd.Reset()
+	if dd.Value() != 0 || dd.Events() != 0 {
+		t.Error("Reset() kept the drift state")
+	}
+	if err := dd.Merge(NewDriftDetector(100, 0.5, nil)); err == nil {
+		t.Error("Merge of drift detectors succeeded, want error")
+	}
+}
+
+func TestKSDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b []float64
+		want float64
+	}{
+		{[]float64{1, 2, 3}, []float64{1, 2, 3}, 0},
+		{[]float64{1, 2}, []float64{3, 4}, 1},
+		{[]float64{1, 2, 3, 4}, []float64{3, 4, 5, 6}, 0.5},
+	} {
+		if got := ksDistance(tc.a, tc.b); got != tc.want {
+			t.Errorf("ksDistance(%v, %v) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package streamstats
+
+import (
+	"fmt"
+	"time"
+)
+
+// lifetimeCompression is the initial t-digest compression of per-epoch
+// digests, see Options.AccuracyTarget
+const lifetimeCompression = 100
+
+// Epoch holds the statistics of the samples added under one epoch label,
+// such as a build SHA, for before/after-deploy analysis
+type Epoch struct {
+	Label    string
+	Started  time.Time
+	Ended    time.Time // Zero for the current epoch
+	Summary  Summary
+	Snapshot Snapshot
+}
+
+// epochState is the live stream of the current epoch
+type epochState struct {
+	label   string
+	started time.Time
+	stats   *DataStreamStats
+}
+
+// SetEpoch closes the current epoch, if any, and starts collecting
+// subsequent samples under label in addition to the stream itself. The
+// epoch's stream summarizes like ds but runs none of its callbacks or
+// background workers.
+func (ds *DataStreamStats) SetEpoch(label string) {
+	ds.lazyInit()
+	opts := ds.summaryOptions(ds.name + "@" + label)
+	now := ds.now()
+
+	// Hold minMaxLock so no sample lands between the pre-epoch totals and
+	// the digest they describe
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	ds.epochLock.Lock()
+	defer ds.epochLock.Unlock()
+
+	if len(ds.closedDigests) == 0 && ds.count > 0 {
+		ds.preEpoch = Rollup{
+			Start: ds.firstSample,
+			End:   now,
+			Count: ds.count,
+			Sum:   ds.totalSum,
+			Min:   ds.minVal,
+			Max:   ds.maxVal,
+		}
+	}
+	if ds.epoch != nil {
+		ds.closedEpochs = append(ds.closedEpochs, ds.epoch.close())
+	}
+	ds.closedDigests = append(ds.closedDigests, ds.epochDigest)
+	ds.epochDigest = newTDigest(ds.compression)
+	ds.epoch = &epochState{
+		label:   label,
+		started: now,
+		stats:   NewDataStreamStatsWithOptions(opts),
+	}
+}
+
+// close finalizes and stops the epoch's stream
+func (es *epochState) close() Epoch {
+	ep := es.view()
+	ep.Summary = es.stats.Finalize()
+	ep.Ended = ep.Summary.Finalized
+	es.stats.Stop()
+	return ep
+}
+
+// view describes the epoch without closing it
+func (es *epochState) view() Epoch {
+	return Epoch{
+		Label:    es.label,
+		Started:  es.started,
+		Snapshot: es.stats.Snapshot(),
+	}
+}
+
+// Epochs returns every closed epoch followed by the current one
+func (ds *DataStreamStats) Epochs() []Epoch {
+	ds.lazyInit()
+	ds.epochLock.Lock()
+	defer ds.epochLock.Unlock()
+
+	out := append([]Epoch(nil), ds.closedEpochs...)
+	if ds.epoch != nil {
+		out = append(out, ds.epoch.view())
+	}
+	return out
+}
+
+// ApproxLifetimePercentile estimates the pth percentile of every sample
+// since the stream was created by merging the compact per-epoch digests,
+// without retaining raw history
+func (ds *DataStreamStats) ApproxLifetimePercentile(p float64) float64 {
+	ds.lazyInit()
+	ds.epochLock.Lock()
+	defer ds.epochLock.Unlock()
+
+	merged := newTDigest(ds.compression)
+	for _, td := range ds.closedDigests {
+		merged.merge(td)
+	}
+	merged.merge(ds.epochDigest)
+	return merged.quantile(p)
+}
+
+// CompareEpochs compares the rollups of two epochs by label, see Compare,
+// with the stream's annotations from the start of before to the end of
+// after. The label "" names the samples before the first epoch.
+func (ds *DataStreamStats) CompareEpochs(before, after string) (Comparison, error) {
+	ds.lazyInit()
+	var a, b *Rollup
+	rollups := ds.Rollups()
+	for i := range rollups {
+		if rollups[i].Label == before {
+			a = &rollups[i]
+		}
+		if rollups[i].Label == after {
+			b = &rollups[i]
+		}
+	}
+	if a == nil {
+		return Comparison{}, fmt.Errorf("unknown epoch %q", before)
+	}
+	if b == nil {
+		return Comparison{}, fmt.Errorf("unknown epoch %q", after)
+	}
+	c := Compare(*a, *b)
+	c.Annotations = ds.Annotations(a.Start, b.End)
+	return c, nil
+}
@@ -0,0 +1,122 @@
+package streamstats
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestEpochs(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+
+	ds.AddNumber(100) // Before any epoch
+	ds.SetEpoch("v1")
+	ds.AddNumber(1)
+	ds.AddNumber(3)
+	ds.SetEpoch("v2")
+	ds.AddNumber(10)
+
+	eps := ds.Epochs()
+	if len(eps) != 2 {
+		t.Fatalf("Epochs() = %d epochs, want 2", len(eps))
+	}
+	v1, v2 := eps[0], eps[1]
+	if v1.Label != "v1" || v1.Ended.IsZero() || v1.Summary.Count != 2 || v1.Summary.Mean != 2 {
+		t.Errorf("closed epoch = %+v, want v1 with samples 1 and 3", v1)
+	}
+	if v2.Label != "v2" || !v2.Ended.IsZero() || v2.Snapshot.Lifetime.Count != 1 {
+		t.Errorf("current epoch = %+v, want open v2 with one sample", v2)
+	}
+	if got := ds.Count(); got != 4 {
+		t.Errorf("Count() = %d, want every sample in the stream itself", got)
+	}
+}
+
+func TestEpochRunsNoCallbacks(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time { return base.Add(time.Duration(sec) * time.Second) }
+
+	var closed int
+	ds := NewDataStreamStatsWithOptions(Options{
+		Capacity:      100,
+		EventWindow:   10 * time.Second,
+		OnWindowClose: func(Rollup) { closed++ },
+		TrackJitter:   true,
+		PublishEvery:  1,
+		Now:           func() time.Time { return at(60) },
+	})
+	defer ds.Stop()
+
+	ds.SetEpoch("v1")
+	ds.AddNumberAt(at(1), 1)
+	ds.AddNumberAt(at(15), 2)
+	if closed != 1 {
+		t.Errorf("OnWindowClose called %d times for one window, want 1", closed)
+	}
+
+	ep := ds.Epochs()[0].Snapshot
+	if ep.Watermark != nil || ep.Jitter != nil {
+		t.Errorf("epoch snapshot has watermark %+v, jitter %+v, want neither", ep.Watermark, ep.Jitter)
+	}
+	if ep.Lifetime.Count != 2 {
+		t.Errorf("epoch count = %d, want 2", ep.Lifetime.Count)
+	}
+}
+
+func TestEpochStreamsStop(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ds := NewDataStreamStats(10)
+	for i := 0; i < 5; i++ {
+		ds.SetEpoch(fmt.Sprint("v", i))
+		ds.AddNumber(float64(i))
+	}
+	ds.Stop()
+
+	// Workers exit asynchronously once stopped
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("%d goroutines after Stop, want at most %d", n, before)
+	}
+}
+
+func TestApproxLifetimePercentile(t *testing.T) {
+	ds := NewDataStreamStats(10) // The window only holds the last epoch's tail
+	defer ds.Stop()
+	for e := 0; e < 4; e++ {
+		ds.SetEpoch(fmt.Sprint("v", e))
+		for i := 1; i <= 250; i++ {
+			ds.AddNumber(float64(e*250 + i))
+		}
+	}
+
+	for _, tc := range []struct{ p, want float64 }{{1, 10}, {50, 500}, {99, 990}} {
+		if got := ds.ApproxLifetimePercentile(tc.p); math.Abs(got-tc.want) > 0.01*1000 {
+			t.Errorf("ApproxLifetimePercentile(%v) = %v, want about %v", tc.p, got, tc.want)
+		}
+	}
+	if got := ds.GetPercentile(1); got < 990 {
+		t.Errorf("window p1 = %v, want it limited to the last samples", got)
+	}
+}
+
+func TestEpochTimesUseStreamClock(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := t0
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Now: func() time.Time { return now }})
+	defer ds.Stop()
+
+	ds.SetEpoch("v1")
+	now = t0.Add(time.Hour)
+	ds.SetEpoch("v2")
+
+	epochs := ds.Epochs()
+	if !epochs[0].Started.Equal(t0) || !epochs[0].Ended.Equal(now) || !epochs[1].Started.Equal(now) {
+		t.Errorf("epochs v1 %v to %v, v2 from %v; want the injected clock", epochs[0].Started, epochs[0].Ended, epochs[1].Started)
+	}
+}
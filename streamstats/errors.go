@@ -0,0 +1,45 @@
+package streamstats
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned across the package, to be matched with errors.Is; the
+// returned errors wrap them with details
+var (
+	// ErrEmptyStream is returned by queries on streams without samples
+	ErrEmptyStream = errors.New("no samples")
+
+	// ErrInsufficientSamples is returned by queries on streams that have
+	// samples, but too few for the query
+	ErrInsufficientSamples = errors.New("not enough samples")
+
+	// ErrInvalidPercentile is returned for percentiles outside [0, 100]
+	ErrInvalidPercentile = errors.New("invalid percentile")
+
+	// ErrIncompatibleMerge is returned when merging streams, sketches,
+	// histograms or statistics whose configurations differ
+	ErrIncompatibleMerge = errors.New("cannot merge")
+
+	// ErrClosed is returned when merging into or restoring a finalized
+	// stream
+	ErrClosed = errors.New("stream is finalized")
+)
+
+// tooFew returns ErrEmptyStream when have is 0, or ErrInsufficientSamples
+// when it falls short of want, wrapped with what was counted
+func tooFew(have, want int64, what string) error {
+	if have == 0 {
+		return fmt.Errorf("need at least %d %s: %w", want, what, ErrEmptyStream)
+	}
+	return fmt.Errorf("need at least %d %s, have %d: %w", want, what, have, ErrInsufficientSamples)
+}
+
+// checkPercentile returns ErrInvalidPercentile unless 0 <= p <= 100
+func checkPercentile(p float64) error {
+	if !(p >= 0 && p <= 100) {
+		return fmt.Errorf("%w %v", ErrInvalidPercentile, p)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package streamstats
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	empty := NewDataStreamStats(10)
+	defer empty.Stop()
+	sketched := NewDataStreamStatsWithOptions(Options{Capacity: 10, Quantiles: TDigest(100)})
+	defer sketched.Stop()
+	finalized := NewDataStreamStats(10)
+	finalized.Finalize()
+	checkpoint, _ := empty.MarshalBinary()
+
+	ms := NewMultivariateStats(2)
+	_, fitErr := empty.FitDistribution(Normal)
+	_, mahaErr := ms.Mahalanobis([]float64{1, 2})
+	_, pctErr := empty.Percentile(50)
+	_, rangeErr := empty.QueryRange(time.Time{}, time.Time{}, 50)
+	_, invalidErr := empty.Percentile(101)
+	_, nanErr := empty.Percentile(math.NaN())
+	_, tailErr := empty.FitTailAbove(-1)
+	_, emptyTailErr := empty.FitTail(0)
+
+	single := NewDataStreamStats(10)
+	defer single.Stop()
+	single.AddNumber(1)
+	_, fewFitErr := single.FitDistribution(Normal)
+	_, fewTailErr := single.FitTail(0)
+	one := NewMultivariateStats(2)
+	one.AddVector([]float64{1, 2})
+	_, fewMahaErr := one.Mahalanobis([]float64{1, 2})
+
+	for _, tc := range []struct {
+		name   string
+		err    error
+		target error
+	}{
+		{"FitDistribution on an empty window", fitErr, ErrEmptyStream},
+		{"Mahalanobis without vectors", mahaErr, ErrEmptyStream},
+		{"FitTail on an empty window", emptyTailErr, ErrEmptyStream},
+		{"FitDistribution on one sample", fewFitErr, ErrInsufficientSamples},
+		{"FitTail with one exceedance", fewTailErr, ErrInsufficientSamples},
+		{"Mahalanobis with one vector", fewMahaErr, ErrInsufficientSamples},
+		{"Percentile of an empty stream", pctErr, ErrEmptyStream},
+		{"QueryRange without rollups", rangeErr, ErrEmptyStream},
+		{"Percentile(101)", invalidErr, ErrInvalidPercentile},
+		{"Percentile(NaN)", nanErr, ErrInvalidPercentile},
+		{"FitTailAbove(-1)", tailErr, ErrInvalidPercentile},
+		{"Merge of different engines", empty.Merge(sketched), ErrIncompatibleMerge},
+		{"Merge into itself", empty.Merge(empty), ErrIncompatibleMerge},
+		{"Merge of t-digest into DDSketch", NewDDSketchEstimator(0.01).Merge(NewTDigestEstimator(100)), ErrIncompatibleMerge},
+		{"Merge of other dimensions", ms.Merge(NewMultivariateStats(3)), ErrIncompatibleMerge},
+		{"Merge into a finalized stream", finalized.Merge(empty), ErrClosed},
+		{"restore into a finalized stream", finalized.UnmarshalBinary(checkpoint), ErrClosed},
+	} {
+		if !errors.Is(tc.err, tc.target) {
+			t.Errorf("%s: error %v is not %v", tc.name, tc.err, tc.target)
+		}
+	}
+
+	for _, err := range []error{fitErr, mahaErr, emptyTailErr} {
+		if errors.Is(err, ErrInsufficientSamples) {
+			t.Errorf("error %v on an empty stream is ErrInsufficientSamples, want only ErrEmptyStream", err)
+		}
+	}
+
+	// Wrapping keeps the messages readable
+	if got, want := empty.Merge(sketched).Error(), `cannot merge stream "": only one stream uses a quantile sketch`; got != want {
+		t.Errorf("Merge() error = %q, want %q", got, want)
+	}
+	empty.AddNumber(1)
+	if _, err := empty.Percentile(50); err != nil {
+		t.Errorf("Percentile() with samples returned %v", err)
+	}
+}
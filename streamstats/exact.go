@@ -0,0 +1,118 @@
+package streamstats
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ExactBelow returns a constructor of ExactEstimators for
+// Options.Quantiles: streams that stay below limit samples get exact
+// percentiles, larger ones switch to the sketch built by sketch, e.g.
+// ExactBelow(1000, TDigest(100))
+func ExactBelow(limit int, sketch func() QuantileEstimator) func() QuantileEstimator {
+	return func() QuantileEstimator { return NewExactEstimator(limit, sketch) }
+}
+
+// EngineStatus tells which engine answers the percentiles of a stream
+// using ExactBelow
+type EngineStatus struct {
+	Exact      bool  // Percentiles are exact, computed from every sample
+	Limit      int   // Sample count at which the sketch takes over
+	SwitchedAt int64 // Sample count when the sketch took over, 0 while exact
+}
+
+// ExactEstimator keeps every sample and answers exact percentiles until it
+// holds limit samples, then moves them into a sketch and answers from it
+type ExactEstimator struct {
+	limit      int
+	newSketch  func() QuantileEstimator
+	values     []float64 // Every sample while exact, nil once switched
+	sorted     bool
+	sketch     QuantileEstimator // Set once switched
+	switchedAt int64
+}
+
+// NewExactEstimator creates an estimator that is exact below limit
+// samples and uses a sketch from newSketch beyond
+func NewExactEstimator(limit int, newSketch func() QuantileEstimator) *ExactEstimator {
+	return &ExactEstimator{limit: limit, newSketch: newSketch}
+}
+
+func (ee *ExactEstimator) Add(v float64) {
+	if ee.sketch != nil {
+		ee.sketch.Add(v)
+		return
+	}
+	ee.values = append(ee.values, v)
+	ee.sorted = false
+	if len(ee.values) >= ee.limit {
+		ee.switchToSketch()
+	}
+}
+
+// switchToSketch moves the samples into a new sketch
+func (ee *ExactEstimator) switchToSketch() {
+	ee.sketch = ee.newSketch()
+	for _, v := range ee.values {
+		ee.sketch.Add(v)
+	}
+	ee.switchedAt = int64(len(ee.values))
+	ee.values = nil
+}
+
+func (ee *ExactEstimator) Quantile(p float64) float64 {
+	if ee.sketch != nil {
+		return ee.sketch.Quantile(p)
+	}
+	if !ee.sorted {
+		slices.Sort(ee.values)
+		ee.sorted = true
+	}
+	return sortedPercentile(ee.values, p)
+}
+
+func (ee *ExactEstimator) Count() int64 {
+	if ee.sketch != nil {
+		return ee.sketch.Count()
+	}
+	return int64(len(ee.values))
+}
+
+// Merge adds other's samples, switching to the sketch when other already
+// did or the combined samples reach the limit
+func (ee *ExactEstimator) Merge(other QuantileEstimator) error {
+	o, ok := other.(*ExactEstimator)
+	if !ok {
+		return fmt.Errorf("%w %T into an exact estimator", ErrIncompatibleMerge, other)
+	}
+	if o.sketch != nil && ee.sketch == nil {
+		ee.switchToSketch()
+	}
+	if ee.sketch != nil {
+		if o.sketch != nil {
+			return ee.sketch.Merge(o.sketch)
+		}
+		for _, v := range o.values {
+			ee.sketch.Add(v)
+		}
+		return nil
+	}
+	ee.values = append(ee.values, o.values...)
+	ee.sorted = false
+	if len(ee.values) >= ee.limit {
+		ee.switchToSketch()
+	}
+	return nil
+}
+
+// Status reports whether percentiles are still exact
+func (ee *ExactEstimator) Status() EngineStatus {
+	return EngineStatus{Exact: ee.sketch == nil, Limit: ee.limit, SwitchedAt: ee.switchedAt}
+}
+
+func (ee *ExactEstimator) memoryUsage() int64 {
+	if e, ok := ee.sketch.(interface{ memoryUsage() int64 }); ok {
+		return e.memoryUsage()
+	}
+	return int64(cap(ee.values)) * floatBytes
+}
@@ -0,0 +1,104 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestExactBelowSwitch(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Quantiles: ExactBelow(100, TDigest(100))})
+	defer ds.Stop()
+
+	for i := 1; i <= 99; i++ {
+		ds.AddNumber(float64(i))
+	}
+	// The window holds only 10 samples, the exact engine all of them
+	if got := ds.GetPercentile(10); got != 10 {
+		t.Errorf("GetPercentile(10) = %v, want exactly 10", got)
+	}
+	if got := ds.GetMedian(); got != 50 {
+		t.Errorf("GetMedian() = %v, want exactly 50", got)
+	}
+	if e := ds.Snapshot().Engine; e == nil || !e.Exact || e.SwitchedAt != 0 {
+		t.Errorf("Engine = %+v, want exact", e)
+	}
+	if r := ds.AccuracyReport(); !r[2].Exact {
+		t.Errorf("accuracy of %s reported inexact while exact", r[2].Statistic)
+	}
+
+	ds.AddNumber(100)
+	e := ds.Snapshot().Engine
+	if e == nil || e.Exact || e.SwitchedAt != 100 {
+		t.Errorf("Engine = %+v, want switched at 100", e)
+	}
+	for i := 101; i <= 10000; i++ {
+		ds.AddNumber(float64(i))
+	}
+	if got := ds.GetPercentile(50); math.Abs(got-5000)/5000 > 0.01 {
+		t.Errorf("GetPercentile(50) after the switch = %v, want about 5000", got)
+	}
+}
+
+func TestExactBelowMerge(t *testing.T) {
+	opts := Options{Capacity: 10, Quantiles: ExactBelow(100, DDSketch(0.01))}
+	small := NewDataStreamStatsWithOptions(opts)
+	defer small.Stop()
+	big := NewDataStreamStatsWithOptions(opts)
+	defer big.Stop()
+	for i := 1; i <= 60; i++ {
+		small.AddNumber(float64(i))
+	}
+
+	// Exact into exact stays exact below the limit
+	other := NewDataStreamStatsWithOptions(opts)
+	defer other.Stop()
+	for i := 61; i <= 90; i++ {
+		other.AddNumber(float64(i))
+	}
+	if err := small.Merge(other); err != nil {
+		t.Fatal(err)
+	}
+	if e := small.Snapshot().Engine; !e.Exact || small.GetPercentile(100) != 90 {
+		t.Errorf("merged engine %+v, max percentile %v, want exact up to 90", e, small.GetPercentile(100))
+	}
+
+	// A switched stream switches the target
+	for i := 1; i <= 500; i++ {
+		big.AddNumber(float64(i))
+	}
+	if err := small.Merge(big); err != nil {
+		t.Fatal(err)
+	}
+	if e := small.Snapshot().Engine; e.Exact {
+		t.Errorf("engine after merging a switched stream = %+v, want switched", e)
+	}
+	if got := small.GetPercentile(50); math.Abs(got-float64(small.Count())/2)/got > 0.5 {
+		t.Errorf("GetPercentile(50) = %v out of %d samples", got, small.Count())
+	}
+}
+
+func TestExactBelowCheckpoint(t *testing.T) {
+	opts := Options{Capacity: 10, Quantiles: ExactBelow(100, TDigest(100))}
+	for _, n := range []int{50, 500} {
+		ds := NewDataStreamStatsWithOptions(opts)
+		for i := 1; i <= n; i++ {
+			ds.AddNumber(float64(i))
+		}
+		data, err := ds.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored := NewDataStreamStatsWithOptions(opts)
+		if err := restored.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%d samples: %v", n, err)
+		}
+		if got, want := restored.GetPercentile(90), ds.GetPercentile(90); got != want {
+			t.Errorf("%d samples: restored p90 = %v, want %v", n, got, want)
+		}
+		if got, want := *restored.Snapshot().Engine, *ds.Snapshot().Engine; got != want {
+			t.Errorf("%d samples: restored engine = %+v, want %+v", n, got, want)
+		}
+		ds.Stop()
+		restored.Stop()
+	}
+}
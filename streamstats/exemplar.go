@@ -0,0 +1,106 @@
+package streamstats
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// exemplarsPerBucket bounds the exemplars retained per bucket
+	exemplarsPerBucket = 2
+	// exemplarGamma is the growth factor of exemplar buckets (~10% wide)
+	exemplarGamma = 1.1
+)
+
+// Exemplar links a recorded value to the trace that produced it
+type Exemplar struct {
+	Value   float64   `json:"value"`
+	TraceID string    `json:"trace_id"`
+	Time    time.Time `json:"time"`
+}
+
+// AddNumberWithExemplar adds num like AddNumber and keeps traceID as an
+// exemplar for the bucket num falls in, so percentiles can be linked
+// back to concrete traces. Samples the stream rejects, e.g. NaN or shed
+// by the limiter, leave no exemplar.
+func (ds *DataStreamStats) AddNumberWithExemplar(num float64, traceID string) {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	before := ds.count
+	ds.add(time.Time{}, num)
+	accepted := ds.count > before
+	stamp := ds.now()
+	ds.minMaxLock.Unlock()
+	if !accepted {
+		return
+	}
+
+	ds.exemplarLock.Lock()
+	defer ds.exemplarLock.Unlock()
+
+	if ds.exemplars == nil {
+		ds.exemplars = make(map[int][]Exemplar)
+	}
+	b := exemplarBucket(num)
+	kept := append(ds.exemplars[b], Exemplar{Value: num, TraceID: traceID, Time: stamp})
+	if len(kept) > exemplarsPerBucket {
+		kept = kept[len(kept)-exemplarsPerBucket:]
+	}
+	ds.exemplars[b] = kept
+}
+
+// Exemplars returns all retained exemplars ordered by value
+func (ds *DataStreamStats) Exemplars() []Exemplar {
+	ds.lazyInit()
+	ds.exemplarLock.Lock()
+	defer ds.exemplarLock.Unlock()
+
+	var out []Exemplar
+	for _, ex := range ds.exemplars {
+		out = append(out, ex...)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Value < out[j].Value })
+	return out
+}
+
+// ExemplarFor returns the most recent exemplar in the bucket holding the
+// pth percentile, e.g. a trace behind the current p99
+func (ds *DataStreamStats) ExemplarFor(p float64) (Exemplar, bool) {
+	ds.lazyInit()
+	b := exemplarBucket(ds.GetPercentile(p))
+
+	ds.exemplarLock.Lock()
+	defer ds.exemplarLock.Unlock()
+
+	ex := ds.exemplars[b]
+	if len(ex) == 0 {
+		return Exemplar{}, false
+	}
+	return ex[len(ex)-1], true
+}
+
+// latestExemplar returns the most recent of exemplars in the bucket holding v
+func latestExemplar(exemplars []Exemplar, v float64) (Exemplar, bool) {
+	b := exemplarBucket(v)
+	var latest Exemplar
+	found := false
+	for _, ex := range exemplars {
+		if exemplarBucket(ex.Value) == b && (!found || !ex.Time.Before(latest.Time)) {
+			latest, found = ex, true
+		}
+	}
+	return latest, found
+}
+
+// exemplarBucket maps v to a log-scaled bucket, mirrored for negatives
+func exemplarBucket(v float64) int {
+	if v == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(math.Log(math.Abs(v))/math.Log(exemplarGamma))) + 1<<20
+	if v < 0 {
+		return -idx
+	}
+	return idx
+}
@@ -0,0 +1,49 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestExemplars(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 100, Now: func() time.Time { return now }})
+	defer ds.Stop()
+
+	for i := 1; i <= 100; i++ {
+		ds.AddNumber(float64(i))
+	}
+	ds.AddNumberWithExemplar(1000, "slow")
+	ds.AddNumberWithExemplar(1, "fast")
+
+	got := ds.Exemplars()
+	if len(got) != 2 || got[0].TraceID != "fast" || got[1].TraceID != "slow" {
+		t.Fatalf("Exemplars() = %+v, want fast then slow", got)
+	}
+	for _, ex := range got {
+		if !ex.Time.Equal(now) {
+			t.Errorf("exemplar %q stamped %v, want the stream clock %v", ex.TraceID, ex.Time, now)
+		}
+	}
+	if ex, ok := ds.ExemplarFor(100); !ok || ex.TraceID != "slow" {
+		t.Errorf("ExemplarFor(100) = %+v, %v, want slow", ex, ok)
+	}
+}
+
+func TestExemplarsOnlyForAcceptedSamples(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{
+		Capacity: 10,
+		Limiter:  NewTokenBucket(0, 1), // One token, never refilled
+	})
+	defer ds.Stop()
+
+	ds.AddNumberWithExemplar(1, "kept")
+	ds.AddNumberWithExemplar(2, "shed")
+	ds.AddNumberWithExemplar(math.NaN(), "nan")
+
+	got := ds.Exemplars()
+	if len(got) != 1 || got[0].TraceID != "kept" {
+		t.Errorf("Exemplars() = %+v, want only the accepted sample", got)
+	}
+}
@@ -0,0 +1,181 @@
+package streamstats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// exprNode is a parsed arithmetic expression
+type exprNode interface {
+	eval(lookup func(string) (float64, error)) (float64, error)
+}
+
+type numNode float64
+
+func (n numNode) eval(func(string) (float64, error)) (float64, error) { return float64(n), nil }
+
+type varNode string
+
+func (v varNode) eval(lookup func(string) (float64, error)) (float64, error) {
+	return lookup(string(v))
+}
+
+type negNode struct{ x exprNode }
+
+func (n negNode) eval(lookup func(string) (float64, error)) (float64, error) {
+	x, err := n.x.eval(lookup)
+	return -x, err
+}
+
+type binNode struct {
+	op   byte
+	l, r exprNode
+}
+
+func (b binNode) eval(lookup func(string) (float64, error)) (float64, error) {
+	l, err := b.l.eval(lookup)
+	if err != nil {
+		return 0, err
+	}
+	r, err := b.r.eval(lookup)
+	if err != nil {
+		return 0, err
+	}
+	switch b.op {
+	case '+':
+		return l + r, nil
+	case '-':
+		return l - r, nil
+	case '*':
+		return l * r, nil
+	default:
+		if r == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	}
+}
+
+// exprParser is a recursive-descent parser for + - * / and parentheses
+type exprParser struct {
+	src string
+	pos int
+}
+
+// parseExpr parses expressions such as "p99 - p50" or "max / mean"
+func parseExpr(src string) (exprNode, error) {
+	p := &exprParser{src: src}
+	n, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.src[p.pos], p.pos)
+	}
+	return n, nil
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *exprParser) parseSum() (exprNode, error) {
+	l, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) || (p.src[p.pos] != '+' && p.src[p.pos] != '-') {
+			return l, nil
+		}
+		op := p.src[p.pos]
+		p.pos++
+		r, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		l = binNode{op: op, l: l, r: r}
+	}
+}
+
+func (p *exprParser) parseProduct() (exprNode, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) || (p.src[p.pos] != '*' && p.src[p.pos] != '/') {
+			return l, nil
+		}
+		op := p.src[p.pos]
+		p.pos++
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = binNode{op: op, l: l, r: r}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == '-' {
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return negNode{x}, nil
+	}
+	return p.parseAtom()
+}
+
+func (p *exprParser) parseAtom() (exprNode, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	c := rune(p.src[p.pos])
+	switch {
+	case c == '(':
+		p.pos++
+		n, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.pos >= len(p.src) || p.src[p.pos] != ')' {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		p.pos++
+		return n, nil
+	case unicode.IsDigit(c) || c == '.':
+		start := p.pos
+		for p.pos < len(p.src) && (unicode.IsDigit(rune(p.src[p.pos])) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		v, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			return nil, err
+		}
+		return numNode(v), nil
+	case unicode.IsLetter(c) || c == '_':
+		start := p.pos
+		for p.pos < len(p.src) {
+			r := rune(p.src[p.pos])
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+				break
+			}
+			p.pos++
+		}
+		return varNode(strings.ToLower(p.src[start:p.pos])), nil
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", c, p.pos)
+}
@@ -0,0 +1,79 @@
+package streamstats
+
+import "time"
+
+// summaryPercentiles are the percentiles kept in a Summary
+var summaryPercentiles = [...]float64{1, 5, 10, 25, 50, 75, 90, 95, 99, 99.9}
+
+// Summary is a compact, immutable record of a finished stream, meant for
+// batch jobs that report once. It holds no slices or maps, so copies
+// never share state.
+type Summary struct {
+	Name        string
+	Finalized   time.Time
+	Count       int64
+	Sum         float64
+	Mean        float64
+	Min         float64
+	Max         float64
+	Median      float64
+	Percentiles [len(summaryPercentiles)]float64 // Values at summaryPercentiles
+	Shed        int64
+	Quantum     float64 // Rounding applied before quantile structures, 0 if none
+}
+
+// Percentile returns the stored value for one of the summary percentiles
+func (s Summary) Percentile(p float64) (float64, bool) {
+	for i, sp := range summaryPercentiles {
+		if sp == p {
+			return s.Percentiles[i], true
+		}
+	}
+	return 0, false
+}
+
+// Finalize seals the stream so further AddNumber calls are ignored, stops
+// background workers and returns the final summary. Calling it again
+// returns the same summary.
+func (ds *DataStreamStats) Finalize() Summary {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+
+	if ds.finalized != nil {
+		return *ds.finalized
+	}
+
+	s := &Summary{
+		Name:      ds.name,
+		Finalized: ds.now(),
+		Count:     ds.count,
+		Sum:       ds.totalSum,
+		Min:       ds.minVal,
+		Max:       ds.maxVal,
+		Shed:      ds.shedCount,
+		Quantum:   ds.opts.Quantum,
+	}
+	if ds.count > 0 {
+		s.Mean = ds.totalSum / float64(ds.count)
+	}
+
+	// AddNumber takes minMaxLock first, so holding it here keeps the
+	// quantile structures stable while they are read
+	s.Median = ds.GetMedian()
+	for i, p := range summaryPercentiles {
+		s.Percentiles[i] = ds.GetPercentile(p)
+	}
+
+	ds.finalized = s
+	ds.Stop()
+	return *s
+}
+
+// IsFinalized reports whether Finalize has been called
+func (ds *DataStreamStats) IsFinalized() bool {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	return ds.finalized != nil
+}
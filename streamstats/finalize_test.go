@@ -0,0 +1,46 @@
+package streamstats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFinalize(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ds := NewDataStreamStatsWithOptions(Options{Name: "job", Capacity: 100, Now: func() time.Time { return now }})
+	for i := 1; i <= 100; i++ {
+		ds.AddNumber(float64(i))
+	}
+	if ds.IsFinalized() {
+		t.Fatal("IsFinalized() before Finalize")
+	}
+
+	s := ds.Finalize()
+	if s.Name != "job" || !s.Finalized.Equal(now) || s.Count != 100 || s.Sum != 5050 || s.Mean != 50.5 {
+		t.Errorf("Finalize() = %+v, want job with 100 samples summing to 5050 at %v", s, now)
+	}
+	if s.Min != 1 || s.Max != 100 || s.Median != 50.5 {
+		t.Errorf("Finalize() min/median/max = %v/%v/%v, want 1/50.5/100", s.Min, s.Median, s.Max)
+	}
+	for _, tc := range []struct{ p, want float64 }{{1, 1}, {50, 50}, {99, 99}, {99.9, 100}} {
+		if got, ok := s.Percentile(tc.p); !ok || got != tc.want {
+			t.Errorf("Percentile(%v) = %v, %v; want %v", tc.p, got, ok, tc.want)
+		}
+	}
+	if _, ok := s.Percentile(42); ok {
+		t.Error("Percentile(42) found a value, want only the summary percentiles")
+	}
+
+	// A finalized stream is sealed and keeps its summary
+	if !ds.IsFinalized() {
+		t.Error("IsFinalized() = false after Finalize")
+	}
+	ds.AddNumber(1000)
+	now = now.Add(time.Hour)
+	if again := ds.Finalize(); again != s {
+		t.Errorf("second Finalize() = %+v, want %+v", again, s)
+	}
+	if got := ds.Count(); got != 100 {
+		t.Errorf("Count() after Finalize = %d, want 100", got)
+	}
+}
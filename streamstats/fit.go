@@ -0,0 +1,115 @@
+package streamstats
+
+import (
+	"fmt"
+	"math"
+)
+
+// DistributionKind names a parametric distribution family
+type DistributionKind string
+
+const (
+	Normal      DistributionKind = "normal"
+	LogNormal   DistributionKind = "lognormal"
+	Exponential DistributionKind = "exponential"
+	Pareto      DistributionKind = "pareto"
+)
+
+// DistributionFit holds maximum likelihood parameters for a window
+type DistributionFit struct {
+	Kind          DistributionKind
+	Params        map[string]float64 // normal: mu, sigma; lognormal: mu, sigma; exponential: lambda; pareto: xm, alpha
+	LogLikelihood float64
+	N             int
+}
+
+// FitDistribution fits kind to the recent samples by maximum likelihood
+func (ds *DataStreamStats) FitDistribution(kind DistributionKind) (DistributionFit, error) {
+	ds.lazyInit()
+	return fitDistribution(kind, ds.windowValues())
+}
+
+// fitDistribution fits kind to values by maximum likelihood
+func fitDistribution(kind DistributionKind, values []float64) (DistributionFit, error) {
+	n := float64(len(values))
+	if len(values) < 2 {
+		return DistributionFit{}, tooFew(int64(len(values)), 2, "samples")
+	}
+	fit := DistributionFit{Kind: kind, N: len(values)}
+
+	switch kind {
+	case Normal:
+		mu, sigma := meanStd(values)
+		if sigma == 0 {
+			return DistributionFit{}, fmt.Errorf("samples have zero variance")
+		}
+		fit.Params = map[string]float64{"mu": mu, "sigma": sigma}
+		fit.LogLikelihood = -n/2*math.Log(2*math.Pi*sigma*sigma) - n/2
+
+	case LogNormal:
+		logs := make([]float64, len(values))
+		var sumLog float64
+		for i, v := range values {
+			if v <= 0 {
+				return DistributionFit{}, fmt.Errorf("lognormal needs positive samples, got %v", v)
+			}
+			logs[i] = math.Log(v)
+			sumLog += logs[i]
+		}
+		mu, sigma := meanStd(logs)
+		if sigma == 0 {
+			return DistributionFit{}, fmt.Errorf("samples have zero variance")
+		}
+		fit.Params = map[string]float64{"mu": mu, "sigma": sigma}
+		fit.LogLikelihood = -sumLog - n/2*math.Log(2*math.Pi*sigma*sigma) - n/2
+
+	case Exponential:
+		var sum float64
+		for _, v := range values {
+			if v < 0 {
+				return DistributionFit{}, fmt.Errorf("exponential needs non-negative samples, got %v", v)
+			}
+			sum += v
+		}
+		if sum == 0 {
+			return DistributionFit{}, fmt.Errorf("samples are all zero")
+		}
+		lambda := n / sum
+		fit.Params = map[string]float64{"lambda": lambda}
+		fit.LogLikelihood = n*math.Log(lambda) - lambda*sum
+
+	case Pareto:
+		xm := math.Inf(1)
+		for _, v := range values {
+			if v <= 0 {
+				return DistributionFit{}, fmt.Errorf("pareto needs positive samples, got %v", v)
+			}
+			xm = math.Min(xm, v)
+		}
+		var sumLog float64
+		for _, v := range values {
+			sumLog += math.Log(v / xm)
+		}
+		if sumLog == 0 {
+			return DistributionFit{}, fmt.Errorf("samples have zero variance")
+		}
+		alpha := n / sumLog
+		fit.Params = map[string]float64{"xm": xm, "alpha": alpha}
+		fit.LogLikelihood = n*math.Log(alpha) + n*alpha*math.Log(xm) - (alpha+1)*(sumLog+n*math.Log(xm))
+
+	default:
+		return DistributionFit{}, fmt.Errorf("unknown distribution %q", kind)
+	}
+	return fit, nil
+}
+
+// meanStd returns the mean and maximum likelihood (population) standard deviation
+func meanStd(values []float64) (float64, float64) {
+	var mean, m2 float64
+	for i, v := range values {
+		d := v - mean
+		mean += d / float64(i+1)
+		m2 += d * (v - mean)
+	}
+	return mean, math.Sqrt(m2 / float64(len(values)))
+}
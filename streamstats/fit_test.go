@@ -0,0 +1,77 @@
+package streamstats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// fedStream returns a stream whose window holds n samples of gen
+func fedStream(t *testing.T, n int, gen func() float64) *DataStreamStats {
+	t.Helper()
+	ds := NewDataStreamStats(n)
+	t.Cleanup(ds.Stop)
+	for i := 0; i < n; i++ {
+		ds.AddNumber(gen())
+	}
+	return ds
+}
+
+func TestFitDistribution(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tests := []struct {
+		kind   DistributionKind
+		gen    func() float64
+		params map[string]float64
+	}{
+		{Normal, func() float64 { return 10 + 2*r.NormFloat64() }, map[string]float64{"mu": 10, "sigma": 2}},
+		{LogNormal, func() float64 { return math.Exp(1 + 0.5*r.NormFloat64()) }, map[string]float64{"mu": 1, "sigma": 0.5}},
+		{Exponential, func() float64 { return r.ExpFloat64() / 4 }, map[string]float64{"lambda": 4}},
+		{Pareto, func() float64 { return 2 / math.Pow(1-r.Float64(), 1.0/3) }, map[string]float64{"xm": 2, "alpha": 3}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.kind), func(t *testing.T) {
+			fit, err := fedStream(t, 20000, tt.gen).FitDistribution(tt.kind)
+			if err != nil {
+				t.Fatalf("FitDistribution: %v", err)
+			}
+			for name, want := range tt.params {
+				if got := fit.Params[name]; math.Abs(got-want) > 0.05*want {
+					t.Errorf("%s = %v, want %v ± 5%%", name, got, want)
+				}
+			}
+			if fit.N != 20000 || math.IsNaN(fit.LogLikelihood) {
+				t.Errorf("fit = %+v, want N 20000 and a log-likelihood", fit)
+			}
+		})
+	}
+
+	// The true family has the highest likelihood
+	ds := fedStream(t, 5000, func() float64 { return r.ExpFloat64() })
+	exp, _ := ds.FitDistribution(Exponential)
+	norm, _ := ds.FitDistribution(Normal)
+	if exp.LogLikelihood <= norm.LogLikelihood {
+		t.Errorf("exponential log-likelihood %v <= normal %v on exponential data", exp.LogLikelihood, norm.LogLikelihood)
+	}
+}
+
+func TestFitDistributionRejects(t *testing.T) {
+	negative := fedStream(t, 10, func() float64 { return -1 })
+	constant := fedStream(t, 10, func() float64 { return 3 })
+	for _, tc := range []struct {
+		name string
+		ds   *DataStreamStats
+		kind DistributionKind
+	}{
+		{"negative", negative, LogNormal},
+		{"negative", negative, Exponential},
+		{"negative", negative, Pareto},
+		{"constant", constant, Normal},
+		{"constant", constant, Pareto},
+		{"constant", constant, "weibull"},
+	} {
+		if _, err := tc.ds.FitDistribution(tc.kind); err == nil {
+			t.Errorf("FitDistribution(%s) on %s samples succeeded, want error", tc.kind, tc.name)
+		}
+	}
+}
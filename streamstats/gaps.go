@@ -0,0 +1,54 @@
+package streamstats
+
+import "time"
+
+// GapPolicy decides what happens to intervals that received no samples
+type GapPolicy int
+
+const (
+	// GapFlag only counts empty intervals, reported as Snapshot.Gaps
+	GapFlag GapPolicy = iota
+	// GapZeroFill counts empty intervals and adds a zero sample for each,
+	// so averages of rate-like metrics are not biased by gaps
+	GapZeroFill
+)
+
+// gapWorker checks every interval whether samples arrived
+func (ds *DataStreamStats) gapWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ds.minMaxLock.Lock()
+	seen := ds.count
+	ds.minMaxLock.Unlock()
+
+	for {
+		select {
+		case <-ticker.C:
+			ds.minMaxLock.Lock()
+			empty := ds.count == seen && ds.finalized == nil
+			if empty {
+				ds.gapIntervals++
+			}
+			ds.minMaxLock.Unlock()
+
+			if empty && ds.opts.GapPolicy == GapZeroFill {
+				ds.AddNumber(0)
+			}
+
+			ds.minMaxLock.Lock()
+			seen = ds.count
+			ds.minMaxLock.Unlock()
+		case <-ds.stopChan:
+			return
+		}
+	}
+}
+
+// GetGapCount returns the number of intervals that received no samples
+func (ds *DataStreamStats) GetGapCount() int64 {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	return ds.gapIntervals
+}
@@ -0,0 +1,46 @@
+package streamstats
+
+import (
+	"testing"
+	"time"
+)
+
+// waitGaps polls until ds has counted at least n gaps
+func waitGaps(t *testing.T, ds *DataStreamStats, n int64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for ds.GetGapCount() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("GetGapCount() = %d after 5s, want at least %d", ds.GetGapCount(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestGapFlag(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, GapInterval: 2 * time.Millisecond})
+	defer ds.Stop()
+	ds.AddNumber(5)
+
+	waitGaps(t, ds, 2)
+	if got := ds.Count(); got != 1 {
+		t.Errorf("Count() = %d, want the flag policy to add nothing", got)
+	}
+	if snap := ds.Snapshot(); snap.Gaps < 2 {
+		t.Errorf("Snapshot().Gaps = %d, want at least 2", snap.Gaps)
+	}
+}
+
+func TestGapZeroFill(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, GapInterval: 2 * time.Millisecond, GapPolicy: GapZeroFill})
+	defer ds.Stop()
+	ds.AddNumber(5)
+
+	waitGaps(t, ds, 2)
+	if got := ds.Count(); got < 2 {
+		t.Errorf("Count() = %d, want zeros filled for the gaps", got)
+	}
+	if got := ds.GetMin(); got != 0 {
+		t.Errorf("GetMin() = %v, want a filled zero", got)
+	}
+}
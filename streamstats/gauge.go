@@ -0,0 +1,79 @@
+package streamstats
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// GaugeSampler samples a gauge, such as in-flight requests or queue depth,
+// at a fixed interval into a stream, so gauges get distribution statistics
+// like event-driven values. Sampling at a fixed interval weights every
+// level by how long it lasted.
+type GaugeSampler struct {
+	ds       *DataStreamStats
+	gauge    func() float64
+	mu       sync.Mutex
+	errors   int64 // Samples where the gauge returned NaN
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewGaugeSampler samples gauge into ds every interval until Stop, or
+// only on Sample when interval is 0. The gauge runs on the sampler's
+// goroutine and must be safe to call from it.
+func NewGaugeSampler(ds *DataStreamStats, gauge func() float64, interval time.Duration) *GaugeSampler {
+	gs := &GaugeSampler{
+		ds:       ds,
+		gauge:    gauge,
+		stopChan: make(chan struct{}),
+	}
+	if interval > 0 {
+		go gs.worker(interval)
+	}
+	return gs
+}
+
+// worker samples every interval until Stop
+func (gs *GaugeSampler) worker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			gs.Sample()
+		case <-gs.stopChan:
+			return
+		}
+	}
+}
+
+// Sample reads the gauge once and records its value
+func (gs *GaugeSampler) Sample() {
+	v := gs.gauge()
+	if math.IsNaN(v) {
+		gs.mu.Lock()
+		gs.errors++
+		gs.mu.Unlock()
+		return
+	}
+	gs.ds.AddNumber(v)
+}
+
+// Errors returns the number of samples skipped because the gauge
+// returned NaN, e.g. to signal it could not be read
+func (gs *GaugeSampler) Errors() int64 {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	return gs.errors
+}
+
+// Stream returns the stream the gauge is sampled into
+func (gs *GaugeSampler) Stream() *DataStreamStats {
+	return gs.ds
+}
+
+// Stop stops periodic sampling; the stream keeps running
+func (gs *GaugeSampler) Stop() {
+	gs.stopOnce.Do(func() { close(gs.stopChan) })
+}
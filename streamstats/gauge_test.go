@@ -0,0 +1,52 @@
+package streamstats
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGaugeSampler(t *testing.T) {
+	ds := NewDataStreamStats(100)
+	defer ds.Stop()
+
+	levels := []float64{3, 1, math.NaN(), 2}
+	i := 0
+	gs := NewGaugeSampler(ds, func() float64 { i++; return levels[i-1] }, 0)
+	defer gs.Stop()
+	for range levels {
+		gs.Sample()
+	}
+
+	if got := ds.Stats(); got.Count != 3 || got.Median != 2 || got.Max != 3 {
+		t.Errorf("Stats() = %+v, want samples 3, 1, 2", got)
+	}
+	if got := gs.Errors(); got != 1 {
+		t.Errorf("Errors() = %d, want 1 NaN reading", got)
+	}
+	if gs.Stream() != ds {
+		t.Error("Stream() is not the sampled stream")
+	}
+}
+
+func TestGaugeSamplerInterval(t *testing.T) {
+	ds := NewDataStreamStats(100)
+	defer ds.Stop()
+
+	var inflight atomic.Int64
+	inflight.Store(4)
+	gs := NewGaugeSampler(ds, func() float64 { return float64(inflight.Load()) }, time.Millisecond)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for ds.Count() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	gs.Stop()
+	if got := ds.Count(); got < 3 {
+		t.Fatalf("Count() = %d after waiting, want at least 3 periodic samples", got)
+	}
+	if got := ds.GetMean(); got != 4 {
+		t.Errorf("GetMean() = %v, want 4", got)
+	}
+}
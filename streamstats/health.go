@@ -0,0 +1,29 @@
+package streamstats
+
+// Health flags pathological states that make derived values unreliable
+type Health struct {
+	CountOverflow bool  // count reached MaxInt64, later samples were dropped
+	SumOverflow   bool  // the running sum overflowed to ±Inf, so means are garbage
+	NaNInputs     int64 // NaN samples rejected
+}
+
+// OK reports whether no pathological state was detected
+func (h Health) OK() bool {
+	return !h.CountOverflow && !h.SumOverflow && h.NaNInputs == 0
+}
+
+// Count returns the number of samples added over the stream's lifetime
+func (ds *DataStreamStats) Count() int64 {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	return ds.count
+}
+
+// GetHealth returns the stream's health flags
+func (ds *DataStreamStats) GetHealth() Health {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	return ds.health
+}
@@ -0,0 +1,54 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHealth(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+	ds.AddNumber(1)
+	if h := ds.GetHealth(); !h.OK() {
+		t.Errorf("GetHealth() = %+v, want OK", h)
+	}
+
+	ds.AddNumber(math.NaN())
+	ds.AddNumber(math.NaN())
+	if h := ds.GetHealth(); h.OK() || h.NaNInputs != 2 {
+		t.Errorf("GetHealth() = %+v, want 2 NaN inputs", h)
+	}
+	if got := ds.Count(); got != 1 {
+		t.Errorf("Count() = %d, want NaN samples rejected", got)
+	}
+
+	ds.AddNumber(math.MaxFloat64)
+	ds.AddNumber(math.MaxFloat64)
+	if h := ds.Snapshot().Health; !h.SumOverflow {
+		t.Errorf("Snapshot().Health = %+v, want SumOverflow", h)
+	}
+
+	// An infinite sample is not an overflow
+	inf := NewDataStreamStats(10)
+	defer inf.Stop()
+	inf.AddNumber(math.Inf(1))
+	if h := inf.GetHealth(); h.SumOverflow {
+		t.Errorf("GetHealth() after +Inf = %+v, want no SumOverflow", h)
+	}
+}
+
+func TestCountOverflow(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+	ds.minMaxLock.Lock()
+	ds.count = math.MaxInt64
+	ds.minMaxLock.Unlock()
+
+	ds.AddNumber(1)
+	if h := ds.GetHealth(); !h.CountOverflow || h.OK() {
+		t.Errorf("GetHealth() = %+v, want CountOverflow", h)
+	}
+	if got := ds.Count(); got != math.MaxInt64 {
+		t.Errorf("Count() = %d, want it to stay at MaxInt64", got)
+	}
+}
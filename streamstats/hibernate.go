@@ -0,0 +1,77 @@
+package streamstats
+
+import (
+	"slices"
+	"time"
+)
+
+// idleWorker hibernates the stream once it received no samples for a
+// whole Options.IdleTTL
+func (ds *DataStreamStats) idleWorker(ttl time.Duration) {
+	ticker := time.NewTicker(ttl)
+	defer ticker.Stop()
+
+	seen := int64(-1)
+	for {
+		select {
+		case <-ticker.C:
+			ds.minMaxLock.Lock()
+			if ds.count == seen && !ds.hibernating {
+				ds.hibernate()
+			}
+			seen = ds.count
+			ds.minMaxLock.Unlock()
+		case <-ds.stopChan:
+			return
+		}
+	}
+}
+
+// hibernate releases the window and trims the remaining structures to
+// their contents, keeping lifetime aggregates, the median heaps and the
+// digests intact. The window is allocated again by the next sample.
+// Callers hold minMaxLock.
+func (ds *DataStreamStats) hibernate() {
+	ds.hibernating = true
+
+	ds.heapLock.Lock()
+	ds.lower = slices.Clip(ds.lower)
+	ds.upper = slices.Clip(ds.upper)
+	ds.heapLock.Unlock()
+
+	ds.percentileLock.Lock()
+	ds.recentData.release()
+	ds.percentileLock.Unlock()
+
+	if ds.decayed != nil {
+		ds.decayed.mu.Lock()
+		ds.decayed.digest.trim()
+		ds.decayed.mu.Unlock()
+	}
+
+	ds.epochLock.Lock()
+	ds.epochDigest.trim()
+	ds.epochLock.Unlock()
+
+	if ds.jitter != nil {
+		ds.jitter.minMaxLock.Lock()
+		ds.jitter.hibernate()
+		ds.jitter.minMaxLock.Unlock()
+	}
+}
+
+// IsHibernating reports whether the stream is hibernating after being
+// idle for Options.IdleTTL
+func (ds *DataStreamStats) IsHibernating() bool {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	return ds.hibernating
+}
+
+// trim flushes the buffer and frees unused capacity
+func (td *tdigest) trim() {
+	td.compress()
+	td.centroids = slices.Clip(td.centroids)
+	td.buffer = nil
+}
@@ -0,0 +1,44 @@
+package streamstats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHibernate(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 100, IdleTTL: 2 * time.Millisecond, TrackJitter: true})
+	defer ds.Stop()
+	for i := 1; i <= 50; i++ {
+		ds.AddNumber(float64(i))
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !ds.IsHibernating() {
+		if time.Now().After(deadline) {
+			t.Fatal("stream idle for 5s is not hibernating")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The window is released, lifetime aggregates and the median survive
+	if m := ds.MemoryUsage(); m.Window != 0 {
+		t.Errorf("hibernating window holds %d bytes, want 0", m.Window)
+	}
+	if ds.Count() != 50 || ds.GetMedian() != 25.5 || ds.GetMax() != 50 {
+		t.Errorf("count %d, median %v, max %v; want 50, 25.5, 50", ds.Count(), ds.GetMedian(), ds.GetMax())
+	}
+	if got := ds.ApproxLifetimePercentile(50); got < 24 || got > 27 {
+		t.Errorf("lifetime p50 = %v, want about 25.5", got)
+	}
+
+	// The next sample wakes the stream and refills the window; stopping
+	// the idle worker first keeps it from hibernating the stream again
+	ds.Stop()
+	ds.AddNumber(7)
+	if ds.IsHibernating() {
+		t.Error("stream still hibernating after a sample")
+	}
+	if got := ds.GetWindowStats().Samples; len(got) != 1 || got[0] != 7 {
+		t.Errorf("window after waking = %v, want [7]", got)
+	}
+}
@@ -0,0 +1,155 @@
+package streamstats
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// LinearBuckets returns n upper bounds start, start+width, ...
+func LinearBuckets(start, width float64, n int) []float64 {
+	bounds := make([]float64, n)
+	for i := range bounds {
+		bounds[i] = start + float64(i)*width
+	}
+	return bounds
+}
+
+// ExponentialBuckets returns n upper bounds start, start*factor, ...
+func ExponentialBuckets(start, factor float64, n int) []float64 {
+	bounds := make([]float64, n)
+	for i := range bounds {
+		bounds[i] = start * math.Pow(factor, float64(i))
+	}
+	return bounds
+}
+
+// Histogram counts samples into fixed buckets. Each bucket holds the
+// samples at most its upper bound and above the previous one; samples
+// above the last bound are counted as overflow. Counters are atomic, so
+// reading never blocks Add.
+type Histogram struct {
+	bounds []float64
+	counts []atomic.Int64 // One per bound plus the overflow bucket
+}
+
+// NewHistogram creates a histogram with the given upper bounds, e.g. from
+// LinearBuckets or ExponentialBuckets. Bounds are sorted and NaN and
+// duplicate bounds dropped.
+func NewHistogram(bounds []float64) *Histogram {
+	b := slices.Clone(bounds)
+	b = slices.DeleteFunc(b, math.IsNaN)
+	slices.Sort(b)
+	b = slices.Compact(b)
+	return &Histogram{bounds: b, counts: make([]atomic.Int64, len(b)+1)}
+}
+
+// Add counts v
+func (h *Histogram) Add(v float64) {
+	i, _ := slices.BinarySearch(h.bounds, v)
+	h.counts[i].Add(1)
+}
+
+// merge adds the counts of other, which must have the same bounds
+func (h *Histogram) merge(other *Histogram) error {
+	if !slices.Equal(h.bounds, other.bounds) {
+		return fmt.Errorf("%w histograms with different buckets", ErrIncompatibleMerge)
+	}
+	for i := range h.counts {
+		h.counts[i].Add(other.counts[i].Load())
+	}
+	return nil
+}
+
+// Snapshot returns the current counts. Samples added concurrently may be
+// counted in some buckets and not yet in others.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	snap := HistogramSnapshot{Buckets: make([]HistogramBucket, len(h.bounds))}
+	for i, le := range h.bounds {
+		snap.Total += h.counts[i].Load()
+		snap.Buckets[i] = HistogramBucket{UpperBound: le, Count: h.counts[i].Load(), Cumulative: snap.Total}
+	}
+	snap.Overflow = h.counts[len(h.bounds)].Load()
+	snap.Total += snap.Overflow
+	return snap
+}
+
+// HistogramBucket is one bucket of a HistogramSnapshot
+type HistogramBucket struct {
+	UpperBound float64 `json:"le"`
+	Count      int64   `json:"count"`
+	Cumulative int64   `json:"cumulative"` // Samples at most UpperBound
+}
+
+// HistogramSnapshot is a point-in-time copy of a histogram
+type HistogramSnapshot struct {
+	Buckets  []HistogramBucket `json:"buckets"`
+	Overflow int64             `json:"overflow"` // Samples above the last bound
+	Total    int64             `json:"total"`
+}
+
+// CDF returns, per bucket, the fraction of samples at most its upper bound
+func (hs HistogramSnapshot) CDF() []float64 {
+	cdf := make([]float64, len(hs.Buckets))
+	if hs.Total == 0 {
+		return cdf
+	}
+	for i, b := range hs.Buckets {
+		cdf[i] = float64(b.Cumulative) / float64(hs.Total)
+	}
+	return cdf
+}
+
+// WriteText writes one line per bucket with a bar scaled to the fullest
+func (hs HistogramSnapshot) WriteText(w io.Writer) error {
+	most := hs.Overflow
+	for _, b := range hs.Buckets {
+		most = max(most, b.Count)
+	}
+	bar := func(n int64) string {
+		if most == 0 {
+			return ""
+		}
+		return strings.Repeat("#", int(n*40/most))
+	}
+
+	for _, b := range hs.Buckets {
+		if _, err := fmt.Fprintf(w, "<= %-12.6g %8d %s\n", b.UpperBound, b.Count, bar(b.Count)); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%-15s %8d %s\n", "> last", hs.Overflow, bar(hs.Overflow))
+	return err
+}
+
+// WriteCSV writes le,count,cumulative rows, the overflow bucket as +Inf
+func (hs HistogramSnapshot) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"le", "count", "cumulative"})
+	for _, b := range hs.Buckets {
+		cw.Write([]string{
+			strconv.FormatFloat(b.UpperBound, 'g', -1, 64),
+			strconv.FormatInt(b.Count, 10),
+			strconv.FormatInt(b.Cumulative, 10),
+		})
+	}
+	cw.Write([]string{"+Inf", strconv.FormatInt(hs.Overflow, 10), strconv.FormatInt(hs.Total, 10)})
+	cw.Flush()
+	return cw.Error()
+}
+
+// GetHistogram returns the counts of Options.Histogram; ok is false when
+// the stream has no histogram. It takes no lock.
+func (ds *DataStreamStats) GetHistogram() (hs HistogramSnapshot, ok bool) {
+	ds.lazyInit()
+	h := ds.histogram.Load()
+	if h == nil {
+		return HistogramSnapshot{}, false
+	}
+	return h.Snapshot(), true
+}
@@ -0,0 +1,107 @@
+package streamstats
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBucketConstructors(t *testing.T) {
+	if got, want := LinearBuckets(0, 10, 4), []float64{0, 10, 20, 30}; !slices.Equal(got, want) {
+		t.Errorf("LinearBuckets() = %v, want %v", got, want)
+	}
+	if got, want := ExponentialBuckets(1, 2, 4), []float64{1, 2, 4, 8}; !slices.Equal(got, want) {
+		t.Errorf("ExponentialBuckets() = %v, want %v", got, want)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Histogram: []float64{10, 1, 5, 5}})
+	defer ds.Stop()
+
+	for _, v := range []float64{0, 1, 2, 5, 7, 10, 11, 100} {
+		ds.AddNumber(v)
+	}
+	hs, ok := ds.GetHistogram()
+	if !ok {
+		t.Fatal("GetHistogram() reports no histogram")
+	}
+
+	want := HistogramSnapshot{
+		Buckets: []HistogramBucket{
+			{UpperBound: 1, Count: 2, Cumulative: 2},
+			{UpperBound: 5, Count: 2, Cumulative: 4},
+			{UpperBound: 10, Count: 2, Cumulative: 6},
+		},
+		Overflow: 2,
+		Total:    8,
+	}
+	if !slices.Equal(hs.Buckets, want.Buckets) || hs.Overflow != want.Overflow || hs.Total != want.Total {
+		t.Errorf("GetHistogram() = %+v, want %+v", hs, want)
+	}
+	if got := hs.CDF(); !slices.Equal(got, []float64{0.25, 0.5, 0.75}) {
+		t.Errorf("CDF() = %v, want [0.25 0.5 0.75]", got)
+	}
+	if snap := ds.Snapshot(); snap.Histogram == nil || snap.Histogram.Total != 8 {
+		t.Errorf("Snapshot().Histogram = %+v, want 8 samples", snap.Histogram)
+	}
+
+	var csv bytes.Buffer
+	if err := hs.WriteCSV(&csv); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := csv.String(), "le,count,cumulative\n1,2,2\n5,2,4\n10,2,6\n+Inf,2,8\n"; got != want {
+		t.Errorf("WriteCSV() = %q, want %q", got, want)
+	}
+
+	var text bytes.Buffer
+	hs.WriteText(&text)
+	if lines := strings.Split(strings.TrimSpace(text.String()), "\n"); len(lines) != 4 {
+		t.Errorf("WriteText() wrote %d lines, want 4:\n%s", len(lines), text.String())
+	}
+
+	data, err := json.Marshal(hs)
+	if err != nil {
+		t.Fatalf("json.Marshal() = %v", err)
+	}
+	var back HistogramSnapshot
+	if err := json.Unmarshal(data, &back); err != nil || back.Total != 8 || len(back.Buckets) != 3 {
+		t.Errorf("JSON round trip = %+v, %v", back, err)
+	}
+}
+
+func TestHistogramMergeAndCheckpoint(t *testing.T) {
+	opts := Options{Capacity: 10, Histogram: LinearBuckets(0, 1, 3)}
+	a := NewDataStreamStatsWithOptions(opts)
+	b := NewDataStreamStatsWithOptions(opts)
+	defer a.Stop()
+	defer b.Stop()
+	a.AddNumber(0.5)
+	b.AddNumber(1.5)
+	b.AddNumber(9)
+
+	if err := a.Merge(b); err != nil {
+		t.Fatalf("Merge() = %v", err)
+	}
+	data, err := a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewDataStreamStatsWithOptions(opts)
+	defer restored.Stop()
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary() = %v", err)
+	}
+	hs, _ := restored.GetHistogram()
+	if hs.Total != 3 || hs.Buckets[1].Count != 1 || hs.Buckets[2].Count != 1 || hs.Overflow != 1 {
+		t.Errorf("histogram after merge and restore = %+v", hs)
+	}
+
+	other := NewDataStreamStatsWithOptions(Options{Capacity: 10, Histogram: LinearBuckets(0, 2, 3)})
+	defer other.Stop()
+	if err := a.Merge(other); err == nil {
+		t.Error("Merge() with different buckets succeeded, want error")
+	}
+}
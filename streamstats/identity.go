@@ -0,0 +1,86 @@
+package streamstats
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Instance identifies the process incarnation a stream lives in, so fleet
+// aggregation can tell hosts apart and detect restarts
+type Instance struct {
+	Host        string `json:"host"`
+	PID         int    `json:"pid"`
+	Incarnation int64  `json:"incarnation"` // Process start, Unix nanoseconds
+}
+
+// Source identifies a snapshot or merged state: the process, the stream
+// (stable across checkpoint restores) and a sequence number that grows
+// with every snapshot, export and merge of the stream
+type Source struct {
+	Instance Instance `json:"instance"`
+	Stream   string   `json:"stream"`
+	Seq      uint64   `json:"seq"`
+}
+
+// processInstance is computed once per process
+var processInstance = sync.OnceValue(func() Instance {
+	host, _ := os.Hostname()
+	return Instance{Host: host, PID: os.Getpid(), Incarnation: time.Now().UnixNano()}
+})
+
+// ProcessInstance returns the instance of the running process, the default
+// for Options.Instance
+func ProcessInstance() Instance {
+	return processInstance()
+}
+
+// newStreamID returns a random stream ID
+func newStreamID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// ID returns the stream ID, which a checkpoint restore carries over
+func (ds *DataStreamStats) ID() string {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	return ds.id
+}
+
+// source returns the current source with the next sequence number
+func (ds *DataStreamStats) source() Source {
+	ds.minMaxLock.Lock()
+	id := ds.id
+	ds.minMaxLock.Unlock()
+	return Source{Instance: ds.opts.Instance, Stream: id, Seq: ds.seq.Add(1)}
+}
+
+// MergedSources returns the latest source merged from each stream, ordered
+// by stream ID. A higher Seq from the same stream means newer state; a
+// different Instance for the same stream means it was restarted.
+func (ds *DataStreamStats) MergedSources() []Source {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	out := make([]Source, 0, len(ds.merged))
+	for _, src := range ds.merged {
+		out = append(out, src)
+	}
+	ds.minMaxLock.Unlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Stream < out[j].Stream })
+	return out
+}
+
+// now reads Options.Now, or the system clock
+func (ds *DataStreamStats) now() time.Time {
+	if ds.opts.Now != nil {
+		return ds.opts.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,75 @@
+package streamstats
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSnapshotSource(t *testing.T) {
+	clock := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Now: func() time.Time { return clock }})
+	other := NewDataStreamStats(10)
+	defer ds.Stop()
+	defer other.Stop()
+
+	first, second := ds.Snapshot(), ds.Snapshot()
+	if first.Source.Stream != ds.ID() || first.Source.Stream == other.ID() {
+		t.Errorf("Source.Stream = %q, want %q and distinct from %q", first.Source.Stream, ds.ID(), other.ID())
+	}
+	if second.Source.Seq <= first.Source.Seq {
+		t.Errorf("Seq went from %d to %d, want increasing", first.Source.Seq, second.Source.Seq)
+	}
+	if got := first.Source.Instance; got != ProcessInstance() || got.PID != os.Getpid() {
+		t.Errorf("Source.Instance = %+v, want the process instance", got)
+	}
+	if !first.Time.Equal(clock) {
+		t.Errorf("Time = %v, want %v from Options.Now", first.Time, clock)
+	}
+}
+
+func TestMergedSources(t *testing.T) {
+	global := NewDataStreamStats(10)
+	region := NewDataStreamStats(10)
+	worker := NewDataStreamStatsWithOptions(Options{Capacity: 10, Instance: Instance{Host: "w1", PID: 7, Incarnation: 1}})
+	for _, s := range []*DataStreamStats{global, region, worker} {
+		defer s.Stop()
+	}
+
+	worker.AddNumber(1)
+	region.Merge(worker)
+	region.Merge(worker)
+	global.Merge(region)
+
+	srcs := global.MergedSources()
+	if len(srcs) != 2 {
+		t.Fatalf("MergedSources() = %+v, want region and worker", srcs)
+	}
+	for _, src := range srcs {
+		if src.Stream == worker.ID() && (src.Instance.Host != "w1" || src.Seq != 2) {
+			t.Errorf("worker source = %+v, want host w1 and the second merge's seq 2", src)
+		}
+	}
+}
+
+func TestCheckpointKeepsIdentity(t *testing.T) {
+	src := NewDataStreamStats(10)
+	defer src.Stop()
+	src.Snapshot()
+	data, err := src.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := NewDataStreamStats(10)
+	defer restarted.Stop()
+	if err := restarted.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if restarted.ID() != src.ID() {
+		t.Errorf("ID() after restore = %q, want %q", restarted.ID(), src.ID())
+	}
+	if seq := restarted.Snapshot().Source.Seq; seq <= 2 {
+		t.Errorf("Seq after restore = %d, want it to continue past 2", seq)
+	}
+}
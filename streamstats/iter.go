@@ -0,0 +1,90 @@
+package streamstats
+
+import (
+	"iter"
+	"math"
+	"time"
+)
+
+// All iterates over the buffered values from oldest to newest with their
+// position, without copying them
+func (rb *RingBuffer) All() iter.Seq2[int, float64] {
+	return func(yield func(int, float64) bool) {
+		if rb.maxAge > 0 {
+			rb.expire(time.Now())
+		}
+		start := rb.start()
+		for i := 0; i < rb.size; i++ {
+			j := (start + i) % rb.cap
+			var v float64
+			if rb.compact {
+				v = float64(rb.data32[j])
+			} else {
+				v = rb.data[j]
+			}
+			if !yield(i, v) {
+				return
+			}
+		}
+	}
+}
+
+// Window iterates over the window samples from oldest to newest without
+// copying them. The loop body runs while the window is locked, so it must
+// not call back into the stream; use GetWindowStats for a copy instead.
+func (ds *DataStreamStats) Window() iter.Seq[float64] {
+	ds.lazyInit()
+	return func(yield func(float64) bool) {
+		ds.percentileLock.Lock()
+		defer ds.percentileLock.Unlock()
+		for _, v := range ds.recentData.All() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Buckets iterates over the histogram buckets followed by the overflow
+// bucket, whose UpperBound is +Inf, reading each counter as it goes; it
+// yields nothing without a histogram
+func (ds *DataStreamStats) Buckets() iter.Seq[HistogramBucket] {
+	ds.lazyInit()
+	return func(yield func(HistogramBucket) bool) {
+		h := ds.histogram.Load()
+		if h == nil {
+			return
+		}
+		var cumulative int64
+		for i := range h.counts {
+			b := HistogramBucket{UpperBound: math.Inf(1), Count: h.counts[i].Load()}
+			if i < len(h.bounds) {
+				b.UpperBound = h.bounds[i]
+			}
+			cumulative += b.Count
+			b.Cumulative = cumulative
+			if !yield(b) {
+				return
+			}
+		}
+	}
+}
+
+// StoredRollups iterates over the rollups QueryRange merges: the closed
+// event windows with Options.EventWindow, the epochs otherwise
+func (ds *DataStreamStats) StoredRollups() iter.Seq[Rollup] {
+	ds.lazyInit()
+	return func(yield func(Rollup) bool) {
+		var stored []Rollup
+		if ds.opts.EventWindow > 0 {
+			stored = ds.ClosedWindows()
+		} else {
+			stored = ds.Rollups()
+		}
+		for _, r := range stored {
+			if !yield(r) {
+				return
+			}
+		}
+	}
+}
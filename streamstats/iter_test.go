@@ -0,0 +1,72 @@
+package streamstats
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWindowIterator(t *testing.T) {
+	for _, compact := range []bool{false, true} {
+		ds := NewDataStreamStatsWithOptions(Options{Capacity: 3, CompactWindow: compact})
+		for _, v := range []float64{1, 2, 3, 4, 5} {
+			ds.AddNumber(v)
+		}
+		if got := slices.Collect(ds.Window()); !slices.Equal(got, []float64{3, 4, 5}) {
+			t.Errorf("compact %v: Window() = %v, want [3 4 5]", compact, got)
+		}
+		for v := range ds.Window() {
+			if v != 3 {
+				t.Errorf("first window sample = %v, want 3", v)
+			}
+			break
+		}
+		ds.Stop()
+	}
+}
+
+func TestBucketsIterator(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Histogram: []float64{1, 10}})
+	defer ds.Stop()
+	for _, v := range []float64{0.5, 5, 5, 50} {
+		ds.AddNumber(v)
+	}
+
+	var got []HistogramBucket
+	for b := range ds.Buckets() {
+		got = append(got, b)
+	}
+	want := []HistogramBucket{
+		{UpperBound: 1, Count: 1, Cumulative: 1},
+		{UpperBound: 10, Count: 2, Cumulative: 3},
+		{UpperBound: math.Inf(1), Count: 1, Cumulative: 4},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Buckets() = %v, want %v", got, want)
+	}
+
+	plain := NewDataStreamStats(10)
+	defer plain.Stop()
+	for range plain.Buckets() {
+		t.Error("Buckets() without a histogram yielded a bucket")
+	}
+}
+
+func TestStoredRollupsIterator(t *testing.T) {
+	t0 := time.Unix(1699999980, 0)
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, EventWindow: time.Minute, Now: func() time.Time { return t0.Add(time.Hour) }})
+	defer ds.Stop()
+	for m := 0; m < 3; m++ {
+		ds.AddNumberAt(t0.Add(time.Duration(m)*time.Minute), float64(m))
+	}
+	ds.AdvanceWatermark(t0.Add(time.Hour))
+
+	var starts []time.Time
+	for r := range ds.StoredRollups() {
+		starts = append(starts, r.Start)
+	}
+	if len(starts) != 3 || !starts[2].Equal(t0.Add(2*time.Minute)) {
+		t.Errorf("StoredRollups() starts = %v, want the 3 minutes", starts)
+	}
+}
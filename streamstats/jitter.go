@@ -0,0 +1,9 @@
+package streamstats
+
+// Jitter returns the derived stream of absolute differences between
+// consecutive samples (|x_i - x_(i-1)|), or nil unless Options.TrackJitter
+// is set. Its mean and percentiles are the mean and percentile jitter.
+func (ds *DataStreamStats) Jitter() *DataStreamStats {
+	ds.lazyInit()
+	return ds.jitter
+}
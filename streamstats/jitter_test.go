@@ -0,0 +1,33 @@
+package streamstats
+
+import "testing"
+
+func TestJitter(t *testing.T) {
+	plain := NewDataStreamStats(10)
+	defer plain.Stop()
+	if plain.Jitter() != nil {
+		t.Error("Jitter() without TrackJitter is not nil")
+	}
+
+	ds := NewDataStreamStatsWithOptions(Options{Name: "rtt", Capacity: 10, TrackJitter: true})
+	defer ds.Stop()
+	for _, v := range []float64{10, 12, 9, 9, 14} {
+		ds.AddNumber(v)
+	}
+
+	j := ds.Jitter()
+	if j.Count() != 4 {
+		t.Fatalf("jitter stream has %d samples, want one per consecutive pair", j.Count())
+	}
+	// Deltas are 2, 3, 0 and 5
+	if j.GetMean() != 2.5 || j.GetMax() != 5 || j.GetMin() != 0 {
+		t.Errorf("jitter mean/min/max = %v/%v/%v, want 2.5/0/5", j.GetMean(), j.GetMin(), j.GetMax())
+	}
+	snap := ds.Snapshot()
+	if snap.Jitter == nil || snap.Jitter.Count != 4 || snap.Jitter.P99 != 5 {
+		t.Errorf("Snapshot().Jitter = %+v, want the 4 deltas", snap.Jitter)
+	}
+	if got := j.Snapshot().Name; got != "rtt/jitter" {
+		t.Errorf("jitter stream name = %q, want rtt/jitter", got)
+	}
+}
@@ -0,0 +1,49 @@
+package streamstats
+
+import "time"
+
+// Lane is the priority of a sample when the stream is rate limited
+type Lane int
+
+const (
+	// LaneBestEffort samples are shed when Options.Limiter is exhausted.
+	// AddNumber uses this lane.
+	LaneBestEffort Lane = iota
+	// LaneCritical samples are never shed. They still take tokens from
+	// the limiter, so under load best-effort samples make room for them.
+	LaneCritical
+)
+
+// LaneCounts accounts for the samples of one lane
+type LaneCounts struct {
+	Accepted int64
+	Shed     int64
+}
+
+// valid reports whether l is one of the defined lanes
+func (l Lane) valid() bool {
+	return l >= LaneBestEffort && l <= LaneCritical
+}
+
+// AddNumberLane adds num in the given lane; samples in undefined lanes
+// are ignored
+func (ds *DataStreamStats) AddNumberLane(lane Lane, num float64) {
+	ds.lazyInit()
+	if !lane.valid() {
+		return
+	}
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	ds.addLane(time.Time{}, lane, num)
+}
+
+// GetLaneCounts returns the accounting of a lane, zero for undefined lanes
+func (ds *DataStreamStats) GetLaneCounts(lane Lane) LaneCounts {
+	ds.lazyInit()
+	if !lane.valid() {
+		return LaneCounts{}
+	}
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	return ds.lanes[lane]
+}
@@ -0,0 +1,45 @@
+package streamstats
+
+import "testing"
+
+func TestLanes(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{
+		Capacity: 100,
+		Limiter:  NewTokenBucket(0, 5), // Five tokens, never refilled
+	})
+	defer ds.Stop()
+
+	for i := 0; i < 10; i++ {
+		ds.AddNumberLane(LaneCritical, 1)
+		ds.AddNumber(2)
+	}
+
+	// Critical samples take the first tokens and are never shed
+	if got, want := ds.GetLaneCounts(LaneCritical), (LaneCounts{Accepted: 10}); got != want {
+		t.Errorf("critical lane = %+v, want %+v", got, want)
+	}
+	if got, want := ds.GetLaneCounts(LaneBestEffort), (LaneCounts{Accepted: 2, Shed: 8}); got != want {
+		t.Errorf("best-effort lane = %+v, want %+v", got, want)
+	}
+	if got := ds.GetShedCount(); got != 8 {
+		t.Errorf("GetShedCount() = %d, want 8", got)
+	}
+	if got := ds.Count(); got != 12 {
+		t.Errorf("Count() = %d, want 12", got)
+	}
+}
+
+func TestUndefinedLanes(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+
+	for _, lane := range []Lane{-1, LaneCritical + 1, 100} {
+		ds.AddNumberLane(lane, 1)
+		if got := ds.GetLaneCounts(lane); got != (LaneCounts{}) {
+			t.Errorf("GetLaneCounts(%d) = %+v, want zero", lane, got)
+		}
+	}
+	if got := ds.Count(); got != 0 {
+		t.Errorf("Count() = %d, want samples in undefined lanes ignored", got)
+	}
+}
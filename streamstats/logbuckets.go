@@ -0,0 +1,97 @@
+package streamstats
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"sort"
+)
+
+// defaultRelativeAccuracy is used when Options.RelativeAccuracy is unset
+const defaultRelativeAccuracy = 0.01
+
+// logBuckets is a log-bucketed histogram of non-negative values whose
+// quantiles have a bounded relative error, with exact counting of zeros
+type logBuckets struct {
+	gamma     float64
+	logGamma  float64
+	zeroCount int64
+	counts    map[int]int64
+	total     int64
+}
+
+// newLogBuckets creates buckets with the given relative accuracy (e.g. 0.01)
+func newLogBuckets(accuracy float64) *logBuckets {
+	if accuracy <= 0 || accuracy >= 1 {
+		accuracy = defaultRelativeAccuracy
+	}
+	gamma := (1 + accuracy) / (1 - accuracy)
+	return &logBuckets{
+		gamma:    gamma,
+		logGamma: math.Log(gamma),
+		counts:   make(map[int]int64),
+	}
+}
+
+// add records a non-negative value
+func (lb *logBuckets) add(v float64) {
+	lb.total++
+	if v == 0 {
+		lb.zeroCount++
+		return
+	}
+	lb.counts[int(math.Ceil(math.Log(v)/lb.logGamma))]++
+}
+
+// merge adds the counts of other, which must have the same accuracy
+func (lb *logBuckets) merge(other *logBuckets) error {
+	if other.gamma != lb.gamma {
+		return fmt.Errorf("%w log buckets with different accuracies", ErrIncompatibleMerge)
+	}
+	lb.zeroCount += other.zeroCount
+	lb.total += other.total
+	for k, n := range other.counts {
+		lb.counts[k] += n
+	}
+	return nil
+}
+
+// clone returns an independent copy
+func (lb *logBuckets) clone() *logBuckets {
+	c := *lb
+	c.counts = maps.Clone(lb.counts)
+	return &c
+}
+
+// quantile returns the pth percentile, within the relative accuracy
+func (lb *logBuckets) quantile(p float64) float64 {
+	if lb.total == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(p / 100 * float64(lb.total)))
+	return lb.valueAtRank(max(rank, 1))
+}
+
+// valueAtRank returns the rank-th smallest value (1-based), within the
+// relative accuracy
+func (lb *logBuckets) valueAtRank(rank int64) float64 {
+	if rank <= lb.zeroCount {
+		return 0
+	}
+
+	keys := make([]int, 0, len(lb.counts))
+	for k := range lb.counts {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	seen := lb.zeroCount
+	for _, k := range keys {
+		seen += lb.counts[k]
+		if seen >= rank {
+			// Midpoint of (gamma^(k-1), gamma^k] in relative terms
+			return 2 * math.Pow(lb.gamma, float64(k)) / (lb.gamma + 1)
+		}
+	}
+	return 2 * math.Pow(lb.gamma, float64(keys[len(keys)-1])) / (lb.gamma + 1)
+}
@@ -0,0 +1,62 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNonNegativeStream(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, NonNegative: true, RelativeAccuracy: 0.01})
+	defer ds.Stop()
+
+	// Half the requests hit the cache and take no time
+	for i := 1; i <= 1000; i++ {
+		ds.AddNumber(0)
+		ds.AddNumber(float64(i))
+	}
+	ds.AddNumber(-1)
+
+	if got := ds.GetNegativeCount(); got != 1 {
+		t.Errorf("GetNegativeCount() = %d, want 1", got)
+	}
+	if got := ds.Count(); got != 2000 {
+		t.Errorf("Count() = %d, want 2000", got)
+	}
+	// Percentiles cover the lifetime, not the 10-sample window
+	if got := ds.GetPercentile(40); got != 0 {
+		t.Errorf("p40 = %v, want exactly 0", got)
+	}
+	for _, tc := range []struct{ p, want float64 }{{75, 500}, {99, 980}, {100, 1000}} {
+		if got := ds.GetPercentile(tc.p); math.Abs(got-tc.want) > 0.01*tc.want {
+			t.Errorf("p%v = %v, want %v within 1%%", tc.p, got, tc.want)
+		}
+	}
+}
+
+func TestLogBuckets(t *testing.T) {
+	lb := newLogBuckets(0.02)
+	other := newLogBuckets(0.02)
+	for i := 1; i <= 100; i++ {
+		lb.add(float64(i))
+		other.add(0)
+	}
+	c := lb.clone()
+	if err := lb.merge(other); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if lb.total != 200 || lb.zeroCount != 100 {
+		t.Errorf("merged total %d with %d zeros, want 200 and 100", lb.total, lb.zeroCount)
+	}
+	if got := lb.quantile(75); math.Abs(got-50) > 1 {
+		t.Errorf("merged p75 = %v, want 50 within 2%%", got)
+	}
+	if c.total != 100 {
+		t.Errorf("clone changed with the original, total %d", c.total)
+	}
+	if err := lb.merge(newLogBuckets(0.05)); err == nil {
+		t.Error("merge of different accuracies succeeded, want error")
+	}
+	if got := newLogBuckets(0).gamma; got != newLogBuckets(defaultRelativeAccuracy).gamma {
+		t.Errorf("accuracy 0 gamma = %v, want the default", got)
+	}
+}
@@ -0,0 +1,144 @@
+package streamstats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// errSingular is returned when the covariance matrix is not positive definite
+var errSingular = errors.New("covariance matrix is singular")
+
+// Mahalanobis returns the Mahalanobis distance of x from the stream's mean
+// under its covariance estimate
+func (ms *MultivariateStats) Mahalanobis(x []float64) (float64, error) {
+	if len(x) != ms.dim {
+		return 0, fmt.Errorf("vector has %d dimensions, want %d", len(x), ms.dim)
+	}
+
+	ms.mu.Lock()
+	cov := ms.covariance()
+	mean := append([]float64(nil), ms.mean...)
+	count := ms.count
+	ms.mu.Unlock()
+
+	if count < 2 {
+		return 0, tooFew(count, 2, "vectors")
+	}
+	l, err := cholesky(cov)
+	if err != nil {
+		return 0, err
+	}
+
+	// D² = dᵀ Σ⁻¹ d = |L⁻¹ d|² with Σ = L Lᵀ
+	y := make([]float64, ms.dim)
+	var d2 float64
+	for i := range y {
+		s := x[i] - mean[i]
+		for j := 0; j < i; j++ {
+			s -= l[i][j] * y[j]
+		}
+		y[i] = s / l[i][i]
+		d2 += y[i] * y[i]
+	}
+	return math.Sqrt(d2), nil
+}
+
+// cholesky factors a symmetric positive definite matrix as L Lᵀ
+func cholesky(m [][]float64) ([][]float64, error) {
+	n := len(m)
+	l := make([][]float64, n)
+	for i := range l {
+		l[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			s := m[i][j]
+			for k := 0; k < j; k++ {
+				s -= l[i][k] * l[j][k]
+			}
+			if i == j {
+				if s <= 0 {
+					return nil, errSingular
+				}
+				l[i][i] = math.Sqrt(s)
+			} else {
+				l[i][j] = s / l[j][j]
+			}
+		}
+	}
+	return l, nil
+}
+
+// chiSquareQuantile approximates the p quantile of a chi-square
+// distribution with k degrees of freedom (Wilson-Hilferty)
+func chiSquareQuantile(p float64, k int) float64 {
+	z := math.Sqrt2 * math.Erfinv(2*p-1)
+	kf := float64(k)
+	c := 1 - 2/(9*kf) + z*math.Sqrt(2/(9*kf))
+	return kf * c * c * c
+}
+
+// VectorAnomaly describes a vector far from the multivariate distribution
+type VectorAnomaly struct {
+	Vector    []float64
+	Distance  float64 // Mahalanobis distance
+	Threshold float64 // Distance threshold that was exceeded
+	Time      time.Time
+}
+
+// MahalanobisDetector scores each vector against the current estimate of
+// a MultivariateStats before adding it, flagging vectors whose squared
+// Mahalanobis distance exceeds the chi-square quantile at confidence
+type MahalanobisDetector struct {
+	stats     *MultivariateStats
+	threshold float64 // Distance, sqrt of the chi-square quantile
+	onAnomaly func(VectorAnomaly)
+	mu        sync.Mutex
+	anomalies int64
+}
+
+// NewMahalanobisDetector creates a detector over stats; confidence is
+// e.g. 0.999 and onAnomaly may be nil
+func NewMahalanobisDetector(stats *MultivariateStats, confidence float64, onAnomaly func(VectorAnomaly)) *MahalanobisDetector {
+	return &MahalanobisDetector{
+		stats:     stats,
+		threshold: math.Sqrt(chiSquareQuantile(confidence, stats.Dim())),
+		onAnomaly: onAnomaly,
+	}
+}
+
+// Observe scores x, adds it to the stats and reports whether it was
+// anomalous. Vectors are not scored until the covariance is usable.
+func (md *MahalanobisDetector) Observe(x []float64) (bool, error) {
+	d, err := md.stats.Mahalanobis(x)
+	scored := err == nil
+	if err := md.stats.AddVector(x); err != nil {
+		return false, err
+	}
+	if !scored || d <= md.threshold {
+		return false, nil
+	}
+
+	md.mu.Lock()
+	md.anomalies++
+	md.mu.Unlock()
+	if md.onAnomaly != nil {
+		md.onAnomaly(VectorAnomaly{
+			Vector:    append([]float64(nil), x...),
+			Distance:  d,
+			Threshold: md.threshold,
+			Time:      time.Now(),
+		})
+	}
+	return true, nil
+}
+
+// Anomalies returns the number of anomalous vectors seen so far
+func (md *MahalanobisDetector) Anomalies() int64 {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	return md.anomalies
+}
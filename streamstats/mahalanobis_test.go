@@ -0,0 +1,79 @@
+package streamstats
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestMahalanobis(t *testing.T) {
+	ms := NewMultivariateStats(2)
+	// Independent dimensions with standard deviations 1 and 10
+	for _, v := range [][]float64{{-1, -10}, {-1, 10}, {1, -10}, {1, 10}} {
+		ms.AddVector(v)
+	}
+	// Sample variances are 4/3 and 400/3
+	d, err := ms.Mahalanobis([]float64{2, 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := math.Sqrt(2 * 3); math.Abs(d-want) > 1e-9 {
+		t.Errorf("Mahalanobis([2 20]) = %v, want %v", d, want)
+	}
+	if _, err := ms.Mahalanobis([]float64{1}); err == nil {
+		t.Error("Mahalanobis with the wrong dimension succeeded, want error")
+	}
+
+	collinear := NewMultivariateStats(2)
+	for _, x := range []float64{1, 2, 3} {
+		collinear.AddVector([]float64{x, 2 * x})
+	}
+	if _, err := collinear.Mahalanobis([]float64{1, 2}); !errors.Is(err, errSingular) {
+		t.Errorf("Mahalanobis on collinear data = %v, want errSingular", err)
+	}
+}
+
+func TestChiSquareQuantile(t *testing.T) {
+	// Wilson-Hilferty is within about 1% of the exact quantiles
+	for _, tc := range []struct {
+		p    float64
+		k    int
+		want float64
+	}{{0.95, 2, 5.991}, {0.999, 3, 16.266}, {0.99, 10, 23.209}} {
+		if got := chiSquareQuantile(tc.p, tc.k); math.Abs(got-tc.want) > 0.02*tc.want {
+			t.Errorf("chiSquareQuantile(%v, %d) = %v, want %v", tc.p, tc.k, got, tc.want)
+		}
+	}
+}
+
+func TestMahalanobisDetector(t *testing.T) {
+	r := rand.New(rand.NewSource(6))
+	var anomalies []VectorAnomaly
+	md := NewMahalanobisDetector(NewMultivariateStats(2), 0.9999, func(a VectorAnomaly) { anomalies = append(anomalies, a) })
+
+	for i := 0; i < 1000; i++ {
+		// Correlated metrics: latency follows load
+		load := r.NormFloat64()
+		if _, err := md.Observe([]float64{load, load + 0.1*r.NormFloat64()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before := md.Anomalies()
+	if before > 2 {
+		t.Errorf("%d anomalies in normal traffic, want almost none", before)
+	}
+
+	// Each value is ordinary on its own, but the pair breaks the correlation
+	anomalous, err := md.Observe([]float64{1, -1})
+	if err != nil || !anomalous {
+		t.Fatalf("Observe([1 -1]) = %v, %v; want an anomaly", anomalous, err)
+	}
+	if md.Anomalies() != before+1 {
+		t.Errorf("Anomalies() = %d, want %d", md.Anomalies(), before+1)
+	}
+	last := anomalies[len(anomalies)-1]
+	if last.Distance <= last.Threshold || last.Vector[1] != -1 {
+		t.Errorf("anomaly = %+v, want distance above the threshold", last)
+	}
+}
@@ -0,0 +1,107 @@
+package streamstats
+
+import "unsafe"
+
+// MemoryUsage attributes the memory held by a stream to its internal
+// structures, in bytes. Sizes are estimated from slice capacities and map
+// entry counts, so they cover the data each structure retains but not
+// allocator or map bucket overhead.
+type MemoryUsage struct {
+	Heaps      int64 // Median heaps, one entry per lifetime sample
+	Window     int64 // Ring buffer used for window percentiles
+	LogBuckets int64 // Non-negative mode buckets
+	Sketch     int64 // Built-in Options.Quantiles estimators
+	Digests    int64 // Epoch t-digests behind ApproxLifetimePercentile
+	Decayed    int64 // Recency-weighted digest
+	Exemplars  int64
+	CallSites  int64
+	Epochs     int64 // Closed epoch snapshots and the live epoch stream
+	Jitter     int64 // Derived jitter stream
+	Children   int64 // Child streams created with NewChild
+	Histogram  int64 // Options.Histogram bounds and counters
+}
+
+// Total returns the sum of all components
+func (m MemoryUsage) Total() int64 {
+	return m.Heaps + m.Window + m.LogBuckets + m.Sketch + m.Digests + m.Decayed +
+		m.Exemplars + m.CallSites + m.Epochs + m.Jitter + m.Children + m.Histogram
+}
+
+const (
+	floatBytes    = int64(unsafe.Sizeof(float64(0)))
+	centroidBytes = int64(unsafe.Sizeof(centroid{}))
+	bucketBytes   = int64(unsafe.Sizeof(int(0)) + unsafe.Sizeof(int64(0)))
+	exemplarBytes = int64(unsafe.Sizeof(Exemplar{}))
+	callSiteBytes = int64(unsafe.Sizeof(CallSite{}))
+)
+
+// MemoryUsage returns the estimated memory held by the stream, including
+// the streams it owns
+func (ds *DataStreamStats) MemoryUsage() MemoryUsage {
+	ds.lazyInit()
+	var m MemoryUsage
+
+	ds.heapLock.Lock()
+	m.Heaps = int64(cap(ds.lower)+cap(ds.upper)) * floatBytes
+	ds.heapLock.Unlock()
+
+	ds.percentileLock.Lock()
+	m.Window = ds.recentData.memoryUsage()
+	if e, ok := ds.quantiles.(interface{ memoryUsage() int64 }); ok {
+		m.Sketch = e.memoryUsage()
+	}
+	if ds.nonNegative != nil {
+		m.LogBuckets = int64(len(ds.nonNegative.counts)) * bucketBytes
+	}
+	ds.percentileLock.Unlock()
+
+	if ds.decayed != nil {
+		ds.decayed.mu.Lock()
+		m.Decayed = ds.decayed.digest.memoryUsage()
+		ds.decayed.mu.Unlock()
+	}
+
+	if h := ds.histogram.Load(); h != nil {
+		m.Histogram = int64(len(h.bounds)+len(h.counts)) * floatBytes
+	}
+
+	ds.exemplarLock.Lock()
+	for _, list := range ds.exemplars {
+		for _, ex := range list {
+			m.Exemplars += exemplarBytes + int64(len(ex.TraceID))
+		}
+	}
+	ds.exemplarLock.Unlock()
+
+	ds.callerLock.Lock()
+	for key, cs := range ds.callSites {
+		m.CallSites += callSiteBytes + int64(len(key)+len(cs.Function)+len(cs.File))
+	}
+	ds.callerLock.Unlock()
+
+	ds.epochLock.Lock()
+	m.Digests = ds.epochDigest.memoryUsage()
+	for _, td := range ds.closedDigests {
+		m.Digests += td.memoryUsage()
+	}
+	for _, e := range ds.closedEpochs {
+		m.Epochs += int64(cap(e.Snapshot.Window.Samples)) * floatBytes
+	}
+	if ds.epoch != nil {
+		m.Epochs += ds.epoch.stats.MemoryUsage().Total()
+	}
+	ds.epochLock.Unlock()
+
+	if ds.jitter != nil {
+		m.Jitter = ds.jitter.MemoryUsage().Total()
+	}
+	for _, child := range ds.Children() {
+		m.Children += child.MemoryUsage().Total()
+	}
+	return m
+}
+
+// memoryUsage estimates the bytes held by the digest's centroids
+func (td *tdigest) memoryUsage() int64 {
+	return int64(cap(td.centroids)+cap(td.buffer)) * centroidBytes
+}
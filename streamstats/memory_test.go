@@ -0,0 +1,51 @@
+package streamstats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryUsage(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{
+		Capacity:         100,
+		NonNegative:      true,
+		TrackJitter:      true,
+		DecayHalfLife:    time.Minute,
+		CallerSampleRate: 1,
+	})
+	defer ds.Stop()
+	child := ds.NewChild("db")
+	for i := 1; i <= 50; i++ {
+		ds.AddNumberWithExemplar(float64(i), "trace")
+		child.AddNumber(float64(i))
+	}
+
+	m := ds.MemoryUsage()
+	if m.Window != 100*floatBytes {
+		t.Errorf("Window = %d bytes, want %d for 100 float64s", m.Window, 100*floatBytes)
+	}
+	for _, c := range []struct {
+		name  string
+		bytes int64
+	}{
+		{"Heaps", m.Heaps},
+		{"LogBuckets", m.LogBuckets},
+		{"Digests", m.Digests},
+		{"Decayed", m.Decayed},
+		{"Exemplars", m.Exemplars},
+		{"CallSites", m.CallSites},
+		{"Jitter", m.Jitter},
+		{"Children", m.Children},
+	} {
+		if c.bytes <= 0 {
+			t.Errorf("%s = %d bytes, want the structure accounted for", c.name, c.bytes)
+		}
+	}
+	if m.Sketch != 0 || m.Histogram != 0 {
+		t.Errorf("Sketch = %d, Histogram = %d bytes; want 0 for unused structures", m.Sketch, m.Histogram)
+	}
+	sum := m.Heaps + m.Window + m.LogBuckets + m.Digests + m.Decayed + m.Exemplars + m.CallSites + m.Epochs + m.Jitter + m.Children
+	if m.Total() != sum {
+		t.Errorf("Total() = %d, want the sum of the components", m.Total())
+	}
+}
@@ -0,0 +1,263 @@
+package streamstats
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"time"
+)
+
+// mergeState is a copy of the parts of a stream that Merge combines,
+// taken under the other stream's locks so the two streams' locks are
+// never held together
+type mergeState struct {
+	count         int64
+	sum           float64
+	min, max      float64
+	moments       moments
+	shed          int64
+	negativeCount int64
+	health        Health
+	lanes         [LaneCritical + 1]LaneCounts
+	firstSample   time.Time
+	heap          []float64
+	window        []float64
+	quantiles     QuantileEstimator
+	nonNegative   *logBuckets
+	digest        *tdigest
+	source        Source
+	merged        map[string]Source
+}
+
+// Merge adds every sample summarized by other to ds, so workers can keep
+// local streams and periodically merge them into a global one. Counts,
+// sums, min/max, moments, median heaps, sketches, custom statistics and
+// recency-weighted percentiles combine exactly as if ds had received
+// other's samples; ds's window receives other's window samples. The two
+// streams must use the same quantile engine. Merged samples also count
+// toward ds's current epoch. Other is left unchanged. The sources merged,
+// directly or through other, are kept for MergedSources.
+func (ds *DataStreamStats) Merge(other *DataStreamStats) error {
+	ds.lazyInit()
+	if ds == other {
+		return fmt.Errorf("%w stream %q into itself", ErrIncompatibleMerge, ds.name)
+	}
+	other.lazyInit()
+	if err := ds.checkMergeable(other); err != nil {
+		return err
+	}
+	// Everything that can fail happens before ds changes
+	if err := ds.checkStatistics(other); err != nil {
+		return fmt.Errorf("merge stream %q: %w", other.name, err)
+	}
+	st, err := other.mergeState(ds)
+	if err != nil {
+		return fmt.Errorf("merge stream %q: %w", other.name, err)
+	}
+	if err := ds.absorb(st, other); err != nil {
+		return err
+	}
+	return ds.MergeStatistics(other)
+}
+
+// absorb adds the samples summarized by st, copied from other, to ds and
+// to its current epoch
+func (ds *DataStreamStats) absorb(st mergeState, other *DataStreamStats) error {
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+
+	if ds.finalized != nil {
+		return fmt.Errorf("cannot merge into stream %q: %w", ds.name, ErrClosed)
+	}
+	// Sources are recorded even for empty streams, they still report in
+	ds.recordSource(st.source)
+	for _, src := range st.merged {
+		ds.recordSource(src)
+	}
+	if st.count == 0 {
+		return nil
+	}
+	if ds.count > math.MaxInt64-st.count {
+		ds.health.CountOverflow = true
+		return fmt.Errorf("merged count of stream %q overflows", ds.name)
+	}
+	if ds.count == 0 {
+		ds.minVal, ds.maxVal = st.min, st.max
+	}
+	ds.count += st.count
+	ds.generation.Add(1)
+	ds.totalSum += st.sum
+	if math.IsInf(ds.totalSum, 0) && !math.IsInf(st.sum, 0) {
+		ds.health.SumOverflow = true
+	}
+	ds.minVal = math.Min(ds.minVal, st.min)
+	ds.maxVal = math.Max(ds.maxVal, st.max)
+	ds.moments.merge(st.moments)
+	ds.shedCount += st.shed
+	ds.negativeCount += st.negativeCount
+	ds.health.NaNInputs += st.health.NaNInputs
+	ds.health.SumOverflow = ds.health.SumOverflow || st.health.SumOverflow
+	for i := range ds.lanes {
+		ds.lanes[i].Accepted += st.lanes[i].Accepted
+		ds.lanes[i].Shed += st.lanes[i].Shed
+	}
+	if ds.firstSample.IsZero() || (!st.firstSample.IsZero() && st.firstSample.Before(ds.firstSample)) {
+		ds.firstSample = st.firstSample
+	}
+
+	for _, v := range st.heap {
+		ds.addToHeaps(v)
+	}
+
+	var err error
+	ds.percentileLock.Lock()
+	for _, v := range st.window {
+		ds.recentData.Add(v)
+	}
+	if st.quantiles != nil {
+		err = ds.quantiles.Merge(st.quantiles)
+	}
+	if st.nonNegative != nil && err == nil {
+		err = ds.nonNegative.merge(st.nonNegative)
+	}
+	ds.percentileLock.Unlock()
+	if err != nil {
+		return fmt.Errorf("merge stream %q: %w", other.name, err)
+	}
+
+	if h := ds.histogram.Load(); h != nil {
+		h.merge(other.histogram.Load())
+	}
+
+	// Merged samples count toward the current epoch, as added ones do
+	ds.epochLock.Lock()
+	ds.epochDigest.merge(st.digest)
+	if ds.epoch != nil {
+		err = ds.epoch.stats.absorb(st, other)
+	}
+	ds.epochLock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if ds.decayed != nil {
+		if err := ds.decayed.Merge(other.decayed); err != nil {
+			return fmt.Errorf("merge stream %q: %w", other.name, err)
+		}
+	}
+
+	select {
+	case ds.percentileChan <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// recordSource keeps the newest merged source per stream, by incarnation
+// then sequence number; callers hold minMaxLock
+func (ds *DataStreamStats) recordSource(src Source) {
+	if ds.merged == nil {
+		ds.merged = make(map[string]Source)
+	}
+	prev, ok := ds.merged[src.Stream]
+	switch {
+	case !ok, src.Instance.Incarnation > prev.Instance.Incarnation,
+		src.Instance.Incarnation == prev.Instance.Incarnation && src.Seq > prev.Seq:
+		ds.merged[src.Stream] = src
+	}
+}
+
+// checkMergeable reports configuration differences that make merging
+// other into ds incorrect
+func (ds *DataStreamStats) checkMergeable(other *DataStreamStats) error {
+	sketch, gamma := ds.engineConfig()
+	otherSketch, otherGamma := other.engineConfig()
+	switch {
+	case ds.sketched() != other.sketched():
+		return fmt.Errorf("%w stream %q: only one stream uses a quantile sketch", ErrIncompatibleMerge, other.name)
+	case sketch != otherSketch:
+		return fmt.Errorf("%w stream %q: sketch is %s, stream uses %s", ErrIncompatibleMerge, other.name, otherSketch, sketch)
+	case (ds.nonNegative == nil) != (other.nonNegative == nil):
+		return fmt.Errorf("%w stream %q: only one stream is non-negative", ErrIncompatibleMerge, other.name)
+	case gamma != otherGamma:
+		return fmt.Errorf("%w stream %q: non-negative relative accuracies differ", ErrIncompatibleMerge, other.name)
+	case (ds.decayed == nil) != (other.decayed == nil):
+		return fmt.Errorf("%w stream %q: only one stream has decayed percentiles", ErrIncompatibleMerge, other.name)
+	case ds.opts.DecayHalfLife != other.opts.DecayHalfLife:
+		return fmt.Errorf("%w stream %q: decay half-lives differ", ErrIncompatibleMerge, other.name)
+	case (ds.histogram.Load() == nil) != (other.histogram.Load() == nil):
+		return fmt.Errorf("%w stream %q: only one stream has a histogram", ErrIncompatibleMerge, other.name)
+	case ds.histogram.Load() != nil && !slices.Equal(ds.histogram.Load().bounds, other.histogram.Load().bounds):
+		return fmt.Errorf("%w stream %q: histogram buckets differ", ErrIncompatibleMerge, other.name)
+	case ds.opts.Unit != other.opts.Unit:
+		return fmt.Errorf("%w stream %q in %q into %q", ErrIncompatibleMerge, other.name, other.opts.Unit.Name, ds.opts.Unit.Name)
+	}
+	return nil
+}
+
+// engineConfig returns the kind of quantile sketch and the log-bucket
+// gamma of ds, zero without either; restore replaces both under
+// percentileLock
+func (ds *DataStreamStats) engineConfig() (sketch string, gamma float64) {
+	ds.percentileLock.Lock()
+	defer ds.percentileLock.Unlock()
+	if ds.quantiles != nil {
+		sketch = sketchKind(ds.quantiles)
+	}
+	if ds.nonNegative != nil {
+		gamma = ds.nonNegative.gamma
+	}
+	return sketch, gamma
+}
+
+// mergeState copies the mergeable state of ds; into provides the sketch
+// constructor so the copy never shares memory with ds. It fails when
+// into's sketch cannot absorb ds's, e.g. for different accuracies.
+func (ds *DataStreamStats) mergeState(into *DataStreamStats) (mergeState, error) {
+	src := ds.source()
+	ds.minMaxLock.Lock()
+	st := mergeState{
+		source:        src,
+		merged:        maps.Clone(ds.merged),
+		count:         ds.count,
+		sum:           ds.totalSum,
+		min:           ds.minVal,
+		max:           ds.maxVal,
+		moments:       ds.moments,
+		shed:          ds.shedCount,
+		negativeCount: ds.negativeCount,
+		health:        ds.health,
+		lanes:         ds.lanes,
+		firstSample:   ds.firstSample,
+	}
+	ds.minMaxLock.Unlock()
+
+	ds.heapLock.Lock()
+	st.heap = append(append(st.heap, ds.lower...), ds.upper...)
+	ds.heapLock.Unlock()
+
+	ds.percentileLock.Lock()
+	st.window = ds.recentData.Values()
+	var err error
+	if ds.quantiles != nil {
+		st.quantiles = into.opts.Quantiles()
+		err = st.quantiles.Merge(ds.quantiles)
+	}
+	if ds.nonNegative != nil {
+		st.nonNegative = ds.nonNegative.clone()
+	}
+	ds.percentileLock.Unlock()
+	if err != nil {
+		return st, err
+	}
+
+	ds.epochLock.Lock()
+	st.digest = newTDigest(ds.epochDigest.compression)
+	for _, td := range ds.closedDigests {
+		st.digest.merge(td)
+	}
+	st.digest.merge(ds.epochDigest)
+	ds.epochLock.Unlock()
+	return st, nil
+}
@@ -0,0 +1,218 @@
+package streamstats
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMergeMatchesSingleStream(t *testing.T) {
+	whole := NewDataStreamStats(1000)
+	a := NewDataStreamStats(1000)
+	b := NewDataStreamStats(1000)
+	for _, s := range []*DataStreamStats{whole, a, b} {
+		defer s.Stop()
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		v := r.NormFloat64()*10 + 50
+		whole.AddNumber(v)
+		if i%3 == 0 {
+			a.AddNumber(v)
+		} else {
+			b.AddNumber(v)
+		}
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatalf("Merge() = %v", err)
+	}
+
+	if got, want := a.Count(), whole.Count(); got != want {
+		t.Errorf("Count() = %d, want %d", got, want)
+	}
+	if got, want := a.GetMin(), whole.GetMin(); got != want {
+		t.Errorf("GetMin() = %v, want %v", got, want)
+	}
+	if got, want := a.GetMax(), whole.GetMax(); got != want {
+		t.Errorf("GetMax() = %v, want %v", got, want)
+	}
+	if got, want := a.GetMedian(), whole.GetMedian(); got != want {
+		t.Errorf("GetMedian() = %v, want %v", got, want)
+	}
+	for name, get := range map[string]func(*DataStreamStats) float64{
+		"GetMean":     (*DataStreamStats).GetMean,
+		"GetVariance": (*DataStreamStats).GetVariance,
+		"GetSkewness": (*DataStreamStats).GetSkewness,
+	} {
+		if got, want := get(a), get(whole); math.Abs(got-want) > 1e-9*math.Max(1, math.Abs(want)) {
+			t.Errorf("%s() = %v, want %v", name, got, want)
+		}
+	}
+	if got := b.Count(); got != 666 {
+		t.Errorf("Count() of merged-from stream = %d, want 666", got)
+	}
+}
+
+func TestMergeSketches(t *testing.T) {
+	for name, q := range map[string]func() QuantileEstimator{
+		"tdigest":  TDigest(100),
+		"ddsketch": DDSketch(0.01),
+	} {
+		t.Run(name, func(t *testing.T) {
+			a := NewDataStreamStatsWithOptions(Options{Capacity: 10, Quantiles: q})
+			b := NewDataStreamStatsWithOptions(Options{Capacity: 10, Quantiles: q})
+			defer a.Stop()
+			defer b.Stop()
+
+			for i := 1; i <= 10000; i++ {
+				if i%2 == 0 {
+					a.AddNumber(float64(i))
+				} else {
+					b.AddNumber(float64(i))
+				}
+			}
+			if err := a.Merge(b); err != nil {
+				t.Fatalf("Merge() = %v", err)
+			}
+			if got := a.GetMedian(); math.Abs(got-5000)/5000 > 0.02 {
+				t.Errorf("GetMedian() = %v, want about 5000", got)
+			}
+			if got := a.GetPercentile(99); math.Abs(got-9900)/9900 > 0.02 {
+				t.Errorf("GetPercentile(99) = %v, want about 9900", got)
+			}
+		})
+	}
+}
+
+func TestMergeIncompatible(t *testing.T) {
+	a := NewDataStreamStats(10)
+	b := NewDataStreamStatsWithOptions(Options{Capacity: 10, Quantiles: TDigest(100)})
+	defer a.Stop()
+	defer b.Stop()
+
+	b.AddNumber(1)
+	if err := a.Merge(b); err == nil {
+		t.Error("Merge() of heap and sketch streams succeeded, want error")
+	}
+	if err := a.Merge(a); err == nil {
+		t.Error("Merge() of a stream into itself succeeded, want error")
+	}
+	if got := a.Count(); got != 0 {
+		t.Errorf("Count() after failed merge = %d, want 0", got)
+	}
+}
+
+func TestMergeLeavesStreamOnError(t *testing.T) {
+	tests := []struct {
+		name        string
+		into, other Options
+	}{
+		{"sketch kinds", Options{Quantiles: TDigest(100)}, Options{Quantiles: DDSketch(0.01)}},
+		{"sketch accuracies", Options{Quantiles: DDSketch(0.01)}, Options{Quantiles: DDSketch(0.05)}},
+		{"non-negative accuracies", Options{NonNegative: true, RelativeAccuracy: 0.01},
+			Options{NonNegative: true, RelativeAccuracy: 0.05}},
+		{"half-lives", Options{DecayHalfLife: time.Minute}, Options{DecayHalfLife: time.Hour}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.into.Capacity, tt.other.Capacity = 10, 10
+			ds := NewDataStreamStatsWithOptions(tt.into)
+			other := NewDataStreamStatsWithOptions(tt.other)
+			defer ds.Stop()
+			defer other.Stop()
+
+			ds.AddNumber(1)
+			other.AddNumber(1000)
+			err := ds.Merge(other)
+			if !errors.Is(err, ErrIncompatibleMerge) {
+				t.Fatalf("Merge() error = %v, want ErrIncompatibleMerge", err)
+			}
+			if got := ds.Count(); got != 1 {
+				t.Errorf("Count() after failed merge = %d, want 1", got)
+			}
+			if got := ds.GetMax(); got != 1 {
+				t.Errorf("GetMax() after failed merge = %v, want 1", got)
+			}
+			if got := ds.GetPercentile(99); math.Abs(got-1) > 0.05 {
+				t.Errorf("GetPercentile(99) after failed merge = %v, want about 1", got)
+			}
+		})
+	}
+}
+
+func TestMergeLeavesStreamOnStatisticError(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	other := NewDataStreamStats(10)
+	defer ds.Stop()
+	defer other.Stop()
+	for _, s := range []*DataStreamStats{ds, other} {
+		if err := s.RegisterStatistic(NewDriftDetector(5, 0.5, nil)); err != nil {
+			t.Fatal(err)
+		}
+		for i := range 10 {
+			s.AddNumber(float64(i))
+		}
+	}
+
+	for range 2 {
+		if err := ds.Merge(other); !errors.Is(err, ErrIncompatibleMerge) {
+			t.Fatalf("Merge() error = %v, want ErrIncompatibleMerge", err)
+		}
+		if got := ds.Count(); got != 10 {
+			t.Errorf("Count() after failed merge = %d, want 10", got)
+		}
+	}
+}
+
+func TestMergeEpochs(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	other := NewDataStreamStats(10)
+	defer ds.Stop()
+	defer other.Stop()
+	for range 1000 {
+		other.AddNumber(1000)
+	}
+	other.SetEpoch("v2")
+	for range 10 {
+		other.AddNumber(1)
+	}
+
+	// Other's closed digests carry its pre-epoch samples
+	ds.SetEpoch("v1")
+	if err := ds.Merge(other); err != nil {
+		t.Fatalf("Merge() = %v", err)
+	}
+	if got, want := ds.ApproxLifetimePercentile(50), other.ApproxLifetimePercentile(50); got != want {
+		t.Errorf("ApproxLifetimePercentile(50) = %v, want %v", got, want)
+	}
+
+	// The merged samples land in ds's current epoch
+	rs := ds.Rollups()
+	last := rs[len(rs)-1]
+	if last.Label != "v1" || last.Count != 1010 || last.Percentile(50) != 1000 {
+		t.Errorf("current rollup = %s with %d samples and p50 %v, want v1 with 1010 and 1000",
+			last.Label, last.Count, last.Percentile(50))
+	}
+}
+
+func TestMergeConcurrent(t *testing.T) {
+	a := NewDataStreamStatsWithOptions(Options{Capacity: 10, DecayHalfLife: time.Second})
+	b := NewDataStreamStatsWithOptions(Options{Capacity: 10, DecayHalfLife: time.Second})
+	defer a.Stop()
+	defer b.Stop()
+	a.AddNumber(1)
+	b.AddNumber(2)
+
+	// Opposite merges must not deadlock
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(2)
+		go func() { defer wg.Done(); a.Merge(b) }()
+		go func() { defer wg.Done(); b.Merge(a) }()
+	}
+	wg.Wait()
+}
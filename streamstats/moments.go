@@ -0,0 +1,127 @@
+package streamstats
+
+import "math"
+
+// moments holds the count, mean and central moment sums M2..M4 of a
+// stream, updated in O(1) per sample with the numerically stable
+// recurrences of Welford and Terriberry, and merged with Chan et al.'s
+// pairwise formulas
+type moments struct {
+	n          int64
+	mean       float64
+	m2, m3, m4 float64
+}
+
+// add updates the moments with x
+func (m *moments) add(x float64) {
+	n1 := float64(m.n)
+	m.n++
+	n := float64(m.n)
+	delta := x - m.mean
+	deltaN := delta / n
+	deltaN2 := deltaN * deltaN
+	term1 := delta * deltaN * n1
+
+	m.mean += deltaN
+	m.m4 += term1*deltaN2*(n*n-3*n+3) + 6*deltaN2*m.m2 - 4*deltaN*m.m3
+	m.m3 += term1*deltaN*(n-2) - 3*deltaN*m.m2
+	m.m2 += term1
+}
+
+// merge combines the moments of two disjoint sets of samples
+func (m *moments) merge(o moments) {
+	if o.n == 0 {
+		return
+	}
+	if m.n == 0 {
+		*m = o
+		return
+	}
+	na, nb := float64(m.n), float64(o.n)
+	n := na + nb
+	delta := o.mean - m.mean
+	delta2 := delta * delta
+
+	m4 := m.m4 + o.m4 +
+		delta2*delta2*na*nb*(na*na-na*nb+nb*nb)/(n*n*n) +
+		6*delta2*(na*na*o.m2+nb*nb*m.m2)/(n*n) +
+		4*delta*(na*o.m3-nb*m.m3)/n
+	m3 := m.m3 + o.m3 +
+		delta2*delta*na*nb*(na-nb)/(n*n) +
+		3*delta*(na*o.m2-nb*m.m2)/n
+	m2 := m.m2 + o.m2 + delta2*na*nb/n
+
+	m.n += o.n
+	m.mean += delta * nb / n
+	m.m2, m.m3, m.m4 = m2, m3, m4
+}
+
+// variance returns the sample (ddof 1) or population (ddof 0) variance
+func (m moments) variance(ddof int64) float64 {
+	if m.n <= ddof {
+		return 0
+	}
+	return m.m2 / float64(m.n-ddof)
+}
+
+// skewness returns the population skewness g1, 0 without spread
+func (m moments) skewness() float64 {
+	if m.m2 == 0 {
+		return 0
+	}
+	return math.Sqrt(float64(m.n)) * m.m3 / math.Pow(m.m2, 1.5)
+}
+
+// kurtosis returns the population excess kurtosis g2, 0 without spread
+func (m moments) kurtosis() float64 {
+	if m.m2 == 0 {
+		return 0
+	}
+	return float64(m.n)*m.m4/(m.m2*m.m2) - 3
+}
+
+// GetVariance returns the sample variance, dividing by n-1
+func (ds *DataStreamStats) GetVariance() float64 {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	return ds.moments.variance(1)
+}
+
+// GetPopulationVariance returns the population variance, dividing by n
+func (ds *DataStreamStats) GetPopulationVariance() float64 {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	return ds.moments.variance(0)
+}
+
+// GetStdDev returns the sample standard deviation
+func (ds *DataStreamStats) GetStdDev() float64 {
+	ds.lazyInit()
+	return math.Sqrt(ds.GetVariance())
+}
+
+// GetPopulationStdDev returns the population standard deviation
+func (ds *DataStreamStats) GetPopulationStdDev() float64 {
+	ds.lazyInit()
+	return math.Sqrt(ds.GetPopulationVariance())
+}
+
+// GetSkewness returns the skewness of the stream: 0 for symmetric
+// distributions, positive when the right tail is longer
+func (ds *DataStreamStats) GetSkewness() float64 {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	return ds.moments.skewness()
+}
+
+// GetKurtosis returns the excess kurtosis of the stream: 0 for a normal
+// distribution, positive for heavier tails
+func (ds *DataStreamStats) GetKurtosis() float64 {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	return ds.moments.kurtosis()
+}
@@ -0,0 +1,113 @@
+package streamstats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// twoPass computes the population variance, skewness and excess kurtosis
+// of values directly from their definitions
+func twoPass(values []float64) (variance, skewness, kurtosis float64) {
+	n := float64(len(values))
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= n
+	var m2, m3, m4 float64
+	for _, v := range values {
+		d := v - mean
+		m2 += d * d
+		m3 += d * d * d
+		m4 += d * d * d * d
+	}
+	return m2 / n, math.Sqrt(n) * m3 / math.Pow(m2, 1.5), n*m4/(m2*m2) - 3
+}
+
+func closeTo(got, want, tol float64) bool {
+	return math.Abs(got-want) <= tol*math.Max(1, math.Abs(want))
+}
+
+func TestMoments(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+
+	r := rand.New(rand.NewSource(1))
+	values := make([]float64, 10000)
+	for i := range values {
+		values[i] = r.ExpFloat64()
+		ds.AddNumber(values[i])
+	}
+
+	variance, skewness, kurtosis := twoPass(values)
+	n := float64(len(values))
+	if got := ds.GetPopulationVariance(); !closeTo(got, variance, 1e-9) {
+		t.Errorf("GetPopulationVariance() = %v, want %v", got, variance)
+	}
+	if got, want := ds.GetVariance(), variance*n/(n-1); !closeTo(got, want, 1e-9) {
+		t.Errorf("GetVariance() = %v, want %v", got, want)
+	}
+	if got := ds.GetStdDev(); !closeTo(got*got, ds.GetVariance(), 1e-12) {
+		t.Errorf("GetStdDev() = %v, not the root of the variance", got)
+	}
+	if got := ds.GetSkewness(); !closeTo(got, skewness, 1e-9) {
+		t.Errorf("GetSkewness() = %v, want %v", got, skewness)
+	}
+	if got := ds.GetKurtosis(); !closeTo(got, kurtosis, 1e-9) {
+		t.Errorf("GetKurtosis() = %v, want %v", got, kurtosis)
+	}
+}
+
+func TestMomentsLargeOffset(t *testing.T) {
+	// Naive sum-of-squares formulas lose every digit here
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+
+	for _, v := range []float64{4, 7, 13, 16} {
+		ds.AddNumber(1e9 + v)
+	}
+	if got := ds.GetVariance(); !closeTo(got, 30, 1e-9) {
+		t.Errorf("GetVariance() = %v, want 30", got)
+	}
+	if got := ds.GetSkewness(); !closeTo(got, 0, 1e-6) {
+		t.Errorf("GetSkewness() = %v, want 0", got)
+	}
+}
+
+func TestMomentsMerge(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	var all, a, b moments
+	for i := 0; i < 1000; i++ {
+		v := r.NormFloat64()*3 + 10
+		all.add(v)
+		if i < 300 {
+			a.add(v)
+		} else {
+			b.add(v)
+		}
+	}
+	a.merge(b)
+
+	if a.n != all.n || !closeTo(a.mean, all.mean, 1e-12) {
+		t.Errorf("merged n, mean = %d, %v, want %d, %v", a.n, a.mean, all.n, all.mean)
+	}
+	for _, c := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"variance", a.variance(1), all.variance(1)},
+		{"skewness", a.skewness(), all.skewness()},
+		{"kurtosis", a.kurtosis(), all.kurtosis()},
+	} {
+		if !closeTo(c.got, c.want, 1e-9) {
+			t.Errorf("merged %s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+
+	var empty moments
+	empty.merge(all)
+	if empty != all {
+		t.Errorf("merge into empty = %+v, want %+v", empty, all)
+	}
+}
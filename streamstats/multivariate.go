@@ -0,0 +1,137 @@
+package streamstats
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MultivariateStats tracks means and the covariance matrix of
+// N-dimensional samples online, for several correlated metrics per event
+type MultivariateStats struct {
+	mu       sync.Mutex
+	dim      int
+	count    int64
+	mean     []float64
+	comoment [][]float64 // Sum of (x_i - mean_i)(x_j - mean_j)
+}
+
+// NewMultivariateStats creates stats for vectors of length dim
+func NewMultivariateStats(dim int) *MultivariateStats {
+	co := make([][]float64, dim)
+	for i := range co {
+		co[i] = make([]float64, dim)
+	}
+	return &MultivariateStats{
+		dim:      dim,
+		mean:     make([]float64, dim),
+		comoment: co,
+	}
+}
+
+// AddVector adds one sample using Welford's update
+func (ms *MultivariateStats) AddVector(x []float64) error {
+	if len(x) != ms.dim {
+		return fmt.Errorf("vector has %d dimensions, want %d", len(x), ms.dim)
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.count++
+	n := float64(ms.count)
+	delta := make([]float64, ms.dim)
+	for i, v := range x {
+		delta[i] = v - ms.mean[i]
+		ms.mean[i] += delta[i] / n
+	}
+	// C_ij += (x_i - oldMean_i)(x_j - newMean_j)
+	for i := 0; i < ms.dim; i++ {
+		for j := 0; j < ms.dim; j++ {
+			ms.comoment[i][j] += delta[i] * (x[j] - ms.mean[j])
+		}
+	}
+	return nil
+}
+
+// Count returns the number of vectors added
+func (ms *MultivariateStats) Count() int64 {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.count
+}
+
+// Dim returns the vector length
+func (ms *MultivariateStats) Dim() int {
+	return ms.dim
+}
+
+// Mean returns the per-dimension means
+func (ms *MultivariateStats) Mean() []float64 {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return append([]float64(nil), ms.mean...)
+}
+
+// Covariance returns the sample covariance matrix
+func (ms *MultivariateStats) Covariance() [][]float64 {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.covariance()
+}
+
+// covariance computes the sample covariance; callers hold mu
+func (ms *MultivariateStats) covariance() [][]float64 {
+	cov := make([][]float64, ms.dim)
+	for i := range cov {
+		cov[i] = make([]float64, ms.dim)
+		if ms.count < 2 {
+			continue
+		}
+		for j := range cov[i] {
+			cov[i][j] = ms.comoment[i][j] / float64(ms.count-1)
+		}
+	}
+	return cov
+}
+
+// Merge folds other into ms using Chan's parallel algorithm, so workers
+// can keep local accumulators and combine them
+func (ms *MultivariateStats) Merge(other *MultivariateStats) error {
+	if other.dim != ms.dim {
+		return fmt.Errorf("%w %d-dimensional stats into %d-dimensional", ErrIncompatibleMerge, other.dim, ms.dim)
+	}
+
+	other.mu.Lock()
+	nb := other.count
+	meanB := append([]float64(nil), other.mean...)
+	coB := make([][]float64, other.dim)
+	for i := range coB {
+		coB[i] = append([]float64(nil), other.comoment[i]...)
+	}
+	other.mu.Unlock()
+
+	if nb == 0 {
+		return nil
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	na := ms.count
+	n := float64(na + nb)
+	delta := make([]float64, ms.dim)
+	for i := range delta {
+		delta[i] = meanB[i] - ms.mean[i]
+	}
+	f := float64(na) * float64(nb) / n
+	for i := 0; i < ms.dim; i++ {
+		for j := 0; j < ms.dim; j++ {
+			ms.comoment[i][j] += coB[i][j] + delta[i]*delta[j]*f
+		}
+	}
+	for i := range ms.mean {
+		ms.mean[i] += delta[i] * float64(nb) / n
+	}
+	ms.count += nb
+	return nil
+}
@@ -0,0 +1,60 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMultivariateStats(t *testing.T) {
+	ms := NewMultivariateStats(2)
+	// y = 2x exactly, so var(y) = 4 var(x) and cov(x, y) = 2 var(x)
+	for _, x := range []float64{1, 2, 3, 4, 5} {
+		if err := ms.AddVector([]float64{x, 2 * x}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ms.AddVector([]float64{1}); err == nil {
+		t.Error("AddVector with the wrong dimension succeeded, want error")
+	}
+
+	if ms.Count() != 5 || ms.Dim() != 2 {
+		t.Errorf("Count() = %d, Dim() = %d; want 5 and 2", ms.Count(), ms.Dim())
+	}
+	if m := ms.Mean(); m[0] != 3 || m[1] != 6 {
+		t.Errorf("Mean() = %v, want [3 6]", m)
+	}
+	want := [][]float64{{2.5, 5}, {5, 10}}
+	assertMatrix(t, "Covariance()", ms.Covariance(), want)
+
+	// Chan's merge of two halves matches adding everything to one
+	a, b := NewMultivariateStats(2), NewMultivariateStats(2)
+	for _, x := range []float64{1, 2} {
+		a.AddVector([]float64{x, 2 * x})
+	}
+	for _, x := range []float64{3, 4, 5} {
+		b.AddVector([]float64{x, 2 * x})
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	if a.Count() != 5 {
+		t.Errorf("merged Count() = %d, want 5", a.Count())
+	}
+	assertMatrix(t, "merged Covariance()", a.Covariance(), want)
+	if err := a.Merge(NewMultivariateStats(2)); err != nil || a.Count() != 5 {
+		t.Errorf("Merge of empty stats = %v, count %d; want a no-op", err, a.Count())
+	}
+}
+
+// assertMatrix compares two matrices with a small tolerance
+func assertMatrix(t *testing.T, name string, got, want [][]float64) {
+	t.Helper()
+	for i := range want {
+		for j := range want[i] {
+			if math.Abs(got[i][j]-want[i][j]) > 1e-9 {
+				t.Errorf("%s = %v, want %v", name, got, want)
+				return
+			}
+		}
+	}
+}
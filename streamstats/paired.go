@@ -0,0 +1,201 @@
+package streamstats
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// PairedStreamStats relates two streams observed together, e.g. request
+// size and latency: X and Y are full streams of each side, and the pairs
+// feed online covariance, Pearson correlation and a least-squares line
+type PairedStreamStats struct {
+	X, Y *DataStreamStats
+
+	mu sync.Mutex
+	co comoments
+}
+
+// comoments are the running means and co-moments of (x, y) pairs
+type comoments struct {
+	n            int64
+	meanX, meanY float64
+	m2x, m2y     float64 // Sums of squared deviations
+	cxy          float64 // Sum of (x - meanX)(y - meanY)
+}
+
+// add updates the co-moments with one pair using Welford's update
+func (c *comoments) add(x, y float64) {
+	c.n++
+	n := float64(c.n)
+	dx := x - c.meanX
+	dy := y - c.meanY
+	c.meanX += dx / n
+	c.meanY += dy / n
+	c.m2x += dx * (x - c.meanX)
+	c.m2y += dy * (y - c.meanY)
+	c.cxy += dx * (y - c.meanY)
+}
+
+// merge combines two sets of co-moments with Chan's parallel formulas
+func (c *comoments) merge(o comoments) {
+	if o.n == 0 {
+		return
+	}
+	if c.n == 0 {
+		*c = o
+		return
+	}
+	n := float64(c.n + o.n)
+	dx := o.meanX - c.meanX
+	dy := o.meanY - c.meanY
+	f := float64(c.n) * float64(o.n) / n
+	c.m2x += o.m2x + dx*dx*f
+	c.m2y += o.m2y + dy*dy*f
+	c.cxy += o.cxy + dx*dy*f
+	c.meanX += dx * float64(o.n) / n
+	c.meanY += dy * float64(o.n) / n
+	c.n += o.n
+}
+
+// Regression is the least-squares line y = Slope·x + Intercept
+type Regression struct {
+	Slope     float64
+	Intercept float64
+	R2        float64 // Fraction of the variance of y explained by x
+}
+
+// PairedSnapshot is a point-in-time copy of paired statistics
+type PairedSnapshot struct {
+	X, Y        Snapshot
+	Pairs       int64
+	Covariance  float64
+	Correlation float64
+	Regression  Regression
+}
+
+// NewPairedStreamStats creates paired stats; X and Y are created from
+// opts, named after it with "/x" and "/y"
+func NewPairedStreamStats(opts Options) *PairedStreamStats {
+	name := opts.Name
+	opts.Name = name + "/x"
+	x := NewDataStreamStatsWithOptions(opts)
+	opts.Name = name + "/y"
+	return &PairedStreamStats{X: x, Y: NewDataStreamStatsWithOptions(opts)}
+}
+
+// AddPair adds one observation of both sides. Pairs with a NaN are
+// dropped entirely. X and Y apply their own options, so a rate limiter or
+// NonNegative may reject a side that still counts as a pair.
+func (ps *PairedStreamStats) AddPair(x, y float64) {
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return
+	}
+	ps.mu.Lock()
+	ps.co.add(x, y)
+	ps.mu.Unlock()
+
+	ps.X.AddNumber(x)
+	ps.Y.AddNumber(y)
+}
+
+// Pairs returns the number of pairs added
+func (ps *PairedStreamStats) Pairs() int64 {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.co.n
+}
+
+// Covariance returns the sample covariance of x and y
+func (ps *PairedStreamStats) Covariance() float64 {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.co.covariance()
+}
+
+// Correlation returns the Pearson correlation of x and y, 0 when either
+// side has no variance
+func (ps *PairedStreamStats) Correlation() float64 {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.co.correlation()
+}
+
+// Regression fits y on x by least squares
+func (ps *PairedStreamStats) Regression() Regression {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.co.regression()
+}
+
+func (c comoments) covariance() float64 {
+	if c.n < 2 {
+		return 0
+	}
+	return c.cxy / float64(c.n-1)
+}
+
+func (c comoments) correlation() float64 {
+	if c.m2x == 0 || c.m2y == 0 {
+		return 0
+	}
+	return c.cxy / math.Sqrt(c.m2x*c.m2y)
+}
+
+func (c comoments) regression() Regression {
+	if c.m2x == 0 {
+		return Regression{Intercept: c.meanY}
+	}
+	slope := c.cxy / c.m2x
+	r := c.correlation()
+	return Regression{Slope: slope, Intercept: c.meanY - slope*c.meanX, R2: r * r}
+}
+
+// Snapshot collects both sides and the paired statistics
+func (ps *PairedStreamStats) Snapshot() PairedSnapshot {
+	ps.mu.Lock()
+	co := ps.co
+	ps.mu.Unlock()
+
+	return PairedSnapshot{
+		X:           ps.X.Snapshot(),
+		Y:           ps.Y.Snapshot(),
+		Pairs:       co.n,
+		Covariance:  co.covariance(),
+		Correlation: co.correlation(),
+		Regression:  co.regression(),
+	}
+}
+
+// Merge folds other into ps, both sides with DataStreamStats.Merge and the
+// co-moments with Chan's parallel formulas
+func (ps *PairedStreamStats) Merge(other *PairedStreamStats) error {
+	if ps == other {
+		return fmt.Errorf("%w paired stats into themselves", ErrIncompatibleMerge)
+	}
+	if err := ps.X.checkMergeable(other.X); err != nil {
+		return err
+	}
+	if err := ps.Y.checkMergeable(other.Y); err != nil {
+		return err
+	}
+
+	other.mu.Lock()
+	co := other.co
+	other.mu.Unlock()
+
+	ps.mu.Lock()
+	ps.co.merge(co)
+	ps.mu.Unlock()
+
+	if err := ps.X.Merge(other.X); err != nil {
+		return err
+	}
+	return ps.Y.Merge(other.Y)
+}
+
+// Stop stops both streams
+func (ps *PairedStreamStats) Stop() {
+	ps.X.Stop()
+	ps.Y.Stop()
+}
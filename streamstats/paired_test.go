@@ -0,0 +1,89 @@
+package streamstats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestPairedStreamStats(t *testing.T) {
+	ps := NewPairedStreamStats(Options{Name: "size_latency", Capacity: 100})
+	defer ps.Stop()
+
+	// y = 2x + 1 exactly
+	for x := 1.0; x <= 10; x++ {
+		ps.AddPair(x, 2*x+1)
+	}
+	ps.AddPair(math.NaN(), 1)
+
+	if got := ps.Pairs(); got != 10 {
+		t.Errorf("Pairs() = %d, want 10", got)
+	}
+	if got := ps.Covariance(); math.Abs(got-2*55.0/6) > 1e-12 {
+		t.Errorf("Covariance() = %v, want %v", got, 2*55.0/6)
+	}
+	if got := ps.Correlation(); math.Abs(got-1) > 1e-12 {
+		t.Errorf("Correlation() = %v, want 1", got)
+	}
+	reg := ps.Regression()
+	if math.Abs(reg.Slope-2) > 1e-12 || math.Abs(reg.Intercept-1) > 1e-12 || math.Abs(reg.R2-1) > 1e-12 {
+		t.Errorf("Regression() = %+v, want slope 2, intercept 1, R2 1", reg)
+	}
+
+	snap := ps.Snapshot()
+	if snap.X.Name != "size_latency/x" || snap.Y.Lifetime.Max != 21 || snap.Pairs != 10 {
+		t.Errorf("Snapshot() = %+v", snap)
+	}
+}
+
+func TestPairedMerge(t *testing.T) {
+	whole := NewPairedStreamStats(Options{Capacity: 100})
+	a := NewPairedStreamStats(Options{Capacity: 100})
+	b := NewPairedStreamStats(Options{Capacity: 100})
+	for _, ps := range []*PairedStreamStats{whole, a, b} {
+		defer ps.Stop()
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		x := r.NormFloat64()
+		y := 0.5*x + r.NormFloat64()
+		whole.AddPair(x, y)
+		if i%4 == 0 {
+			a.AddPair(x, y)
+		} else {
+			b.AddPair(x, y)
+		}
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatalf("Merge() = %v", err)
+	}
+
+	if got, want := a.Correlation(), whole.Correlation(); math.Abs(got-want) > 1e-12 {
+		t.Errorf("Correlation() = %v, want %v", got, want)
+	}
+	if got, want := a.Regression(), whole.Regression(); math.Abs(got.Slope-want.Slope) > 1e-12 ||
+		math.Abs(got.Intercept-want.Intercept) > 1e-12 {
+		t.Errorf("Regression() = %+v, want %+v", got, want)
+	}
+	if got := a.X.Count(); got != 1000 {
+		t.Errorf("X.Count() = %d, want 1000", got)
+	}
+	if err := a.Merge(a); err == nil {
+		t.Error("Merge() into itself succeeded, want error")
+	}
+}
+
+func TestPairedNoVariance(t *testing.T) {
+	ps := NewPairedStreamStats(Options{Capacity: 10})
+	defer ps.Stop()
+	ps.AddPair(3, 1)
+	ps.AddPair(3, 5)
+
+	if got := ps.Correlation(); got != 0 {
+		t.Errorf("Correlation() = %v, want 0 without variance in x", got)
+	}
+	if got := ps.Regression(); got != (Regression{Intercept: 3}) {
+		t.Errorf("Regression() = %+v, want the mean of y as intercept", got)
+	}
+}
@@ -0,0 +1,126 @@
+package streamstats
+
+import "math"
+
+const (
+	// pcaIterations bounds power iterations per component
+	pcaIterations = 500
+	// pcaTolerance stops iterating once the direction settles
+	pcaTolerance = 1e-10
+)
+
+// Component is a principal component of a multivariate stream
+type Component struct {
+	Direction []float64 // Unit vector of metric weights
+	Variance  float64   // Variance along Direction (eigenvalue)
+	Explained float64   // Fraction of total variance explained
+}
+
+// PrincipalComponents returns the top k principal components of the
+// online covariance estimate, showing which combinations of metrics drive
+// variance. Components are found by power iteration with deflation, which
+// costs O(k·dim²) per iteration and needs no stored samples. It returns
+// nil for k <= 0.
+func (ms *MultivariateStats) PrincipalComponents(k int) []Component {
+	if k <= 0 {
+		return nil
+	}
+	ms.mu.Lock()
+	cov := ms.covariance()
+	ms.mu.Unlock()
+
+	if k > ms.dim {
+		k = ms.dim
+	}
+	var total float64
+	for i := range cov {
+		total += cov[i][i]
+	}
+
+	out := make([]Component, 0, k)
+	for c := 0; c < k; c++ {
+		vec, val := powerIteration(cov)
+		if val <= 0 {
+			break
+		}
+		comp := Component{Direction: vec, Variance: val}
+		if total > 0 {
+			comp.Explained = val / total
+		}
+		out = append(out, comp)
+
+		// Deflate: remove the found component from the matrix
+		for i := range cov {
+			for j := range cov[i] {
+				cov[i][j] -= val * vec[i] * vec[j]
+			}
+		}
+	}
+	return out
+}
+
+// powerIteration returns the dominant eigenvector and eigenvalue of the
+// symmetric matrix m
+func powerIteration(m [][]float64) ([]float64, float64) {
+	n := len(m)
+	v := make([]float64, n)
+	for i := range v {
+		// Uneven start avoids being orthogonal to the answer by symmetry
+		v[i] = 1 / math.Sqrt(float64(n)) * (1 + float64(i)/float64(n))
+	}
+	normalizeVec(v)
+
+	var val float64
+	next := make([]float64, n)
+	for it := 0; it < pcaIterations; it++ {
+		for i := range next {
+			next[i] = 0
+			for j := range v {
+				next[i] += m[i][j] * v[j]
+			}
+		}
+		val = normalizeVec(next)
+		if val == 0 {
+			return v, 0
+		}
+
+		var diff float64
+		for i := range v {
+			diff += math.Abs(next[i] - v[i])
+		}
+		copy(v, next)
+		if diff < pcaTolerance {
+			break
+		}
+	}
+
+	// Orient consistently: largest weight positive
+	maxIdx := 0
+	for i := range v {
+		if math.Abs(v[i]) > math.Abs(v[maxIdx]) {
+			maxIdx = i
+		}
+	}
+	if v[maxIdx] < 0 {
+		for i := range v {
+			v[i] = -v[i]
+		}
+	}
+	return v, val
+}
+
+// normalizeVec scales v to unit length and returns its previous length
+func normalizeVec(v []float64) float64 {
+	var norm float64
+	for _, x := range v {
+		norm += x * x
+	}
+	norm = math.Sqrt(norm)
+	if norm == 0 {
+		return 0
+	}
+	for i := range v {
+		v[i] /= norm
+	}
+	return norm
+}
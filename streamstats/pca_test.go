@@ -0,0 +1,50 @@
+package streamstats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestPrincipalComponents(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	ms := NewMultivariateStats(3)
+	// Variance is dominated by x and y moving together; z is small noise
+	for i := 0; i < 20000; i++ {
+		s := 10 * r.NormFloat64()
+		ms.AddVector([]float64{s + r.NormFloat64(), s + r.NormFloat64(), 0.1 * r.NormFloat64()})
+	}
+
+	comps := ms.PrincipalComponents(5)
+	if len(comps) != 3 {
+		t.Fatalf("PrincipalComponents(5) returned %d components, want 3 (the dimension)", len(comps))
+	}
+	first := comps[0]
+	if d := first.Direction; math.Abs(d[0]-math.Sqrt2/2) > 0.01 || math.Abs(d[1]-math.Sqrt2/2) > 0.01 || math.Abs(d[2]) > 0.01 {
+		t.Errorf("first direction = %v, want about [0.71 0.71 0]", d)
+	}
+	if math.Abs(first.Variance-201) > 10 || first.Explained < 0.99 {
+		t.Errorf("first component variance %v explains %v, want about 201 and over 99%%", first.Variance, first.Explained)
+	}
+	for i := 1; i < len(comps); i++ {
+		if comps[i].Variance > comps[i-1].Variance {
+			t.Errorf("components not ordered by variance: %v after %v", comps[i].Variance, comps[i-1].Variance)
+		}
+		var dot float64
+		for j := range first.Direction {
+			dot += first.Direction[j] * comps[i].Direction[j]
+		}
+		if math.Abs(dot) > 0.01 {
+			t.Errorf("component %d is not orthogonal to the first: dot %v", i, dot)
+		}
+	}
+
+	if got := NewMultivariateStats(2).PrincipalComponents(1); len(got) != 0 {
+		t.Errorf("PrincipalComponents() without samples = %v, want none", got)
+	}
+	for _, k := range []int{0, -1} {
+		if got := ms.PrincipalComponents(k); got != nil {
+			t.Errorf("PrincipalComponents(%d) = %v, want nil", k, got)
+		}
+	}
+}
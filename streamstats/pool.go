@@ -0,0 +1,85 @@
+package streamstats
+
+import (
+	"math"
+	"sync"
+)
+
+// Recorder is a lightweight, single-goroutine accumulator for per-request
+// scoped stats (e.g. retry latencies of one request). Recorders come from
+// a RecorderPool and are folded into its parent stream on release.
+type Recorder struct {
+	samples []float64
+	sum     float64
+	min     float64
+	max     float64
+}
+
+// Add records v
+func (r *Recorder) Add(v float64) {
+	r.samples = append(r.samples, v)
+	r.sum += v
+	r.min = math.Min(r.min, v)
+	r.max = math.Max(r.max, v)
+}
+
+// Count returns the number of recorded samples
+func (r *Recorder) Count() int { return len(r.samples) }
+
+// Sum returns the sum of recorded samples
+func (r *Recorder) Sum() float64 { return r.sum }
+
+// Mean returns the mean of recorded samples
+func (r *Recorder) Mean() float64 {
+	if len(r.samples) == 0 {
+		return 0
+	}
+	return r.sum / float64(len(r.samples))
+}
+
+// Min returns the smallest recorded sample, +Inf when empty
+func (r *Recorder) Min() float64 { return r.min }
+
+// Max returns the largest recorded sample, -Inf when empty
+func (r *Recorder) Max() float64 { return r.max }
+
+// reset empties r keeping its buffer
+func (r *Recorder) reset() {
+	r.samples = r.samples[:0]
+	r.sum = 0
+	r.min = math.Inf(1)
+	r.max = math.Inf(-1)
+}
+
+// RecorderPool hands out reusable Recorders that merge into a parent
+// stream on release, avoiding a full DataStreamStats per request
+type RecorderPool struct {
+	parent *DataStreamStats
+	pool   sync.Pool
+}
+
+// NewRecorderPool creates a pool whose recorders feed parent
+func NewRecorderPool(parent *DataStreamStats) *RecorderPool {
+	p := &RecorderPool{parent: parent}
+	p.pool.New = func() any {
+		r := &Recorder{}
+		r.reset()
+		return r
+	}
+	return p
+}
+
+// Acquire returns an empty recorder
+func (p *RecorderPool) Acquire() *Recorder {
+	return p.pool.Get().(*Recorder)
+}
+
+// Release adds the recorder's samples to the parent stream and returns
+// the recorder to the pool; it must not be used afterwards
+func (p *RecorderPool) Release(r *Recorder) {
+	for _, v := range r.samples {
+		p.parent.AddNumber(v)
+	}
+	r.reset()
+	p.pool.Put(r)
+}
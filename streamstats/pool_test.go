@@ -0,0 +1,39 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRecorderPool(t *testing.T) {
+	parent := NewDataStreamStats(100)
+	defer parent.Stop()
+	pool := NewRecorderPool(parent)
+
+	r := pool.Acquire()
+	if r.Count() != 0 || !math.IsInf(r.Min(), 1) || !math.IsInf(r.Max(), -1) || r.Mean() != 0 {
+		t.Errorf("fresh recorder has count %d, min %v, max %v; want empty", r.Count(), r.Min(), r.Max())
+	}
+	for _, v := range []float64{3, 1, 2} {
+		r.Add(v)
+	}
+	if r.Count() != 3 || r.Sum() != 6 || r.Mean() != 2 || r.Min() != 1 || r.Max() != 3 {
+		t.Errorf("recorder count %d, sum %v, mean %v, min %v, max %v; want 3, 6, 2, 1, 3",
+			r.Count(), r.Sum(), r.Mean(), r.Min(), r.Max())
+	}
+	pool.Release(r)
+
+	if parent.Count() != 3 || parent.GetMax() != 3 {
+		t.Errorf("parent has %d samples, max %v; want the 3 released samples", parent.Count(), parent.GetMax())
+	}
+
+	// Reused recorders start empty
+	r = pool.Acquire()
+	if r.Count() != 0 || r.Sum() != 0 {
+		t.Errorf("reacquired recorder has count %d, sum %v; want empty", r.Count(), r.Sum())
+	}
+	pool.Release(r)
+	if parent.Count() != 3 {
+		t.Errorf("releasing an empty recorder changed the parent to %d samples", parent.Count())
+	}
+}
@@ -0,0 +1,58 @@
+package streamstats
+
+// presetCapacity is the window size of the preset constructors
+const presetCapacity = 1000
+
+// NewLatencyStats creates a stream for request latencies in milliseconds.
+// Percentiles are answered over the whole stream with 1% relative error,
+// jitter between consecutive requests is tracked, and the derived field
+// tail_ratio (p99 / p50) shows how heavy the tail is.
+func NewLatencyStats(name string) *DataStreamStats {
+	ds := NewDataStreamStatsWithOptions(Options{
+		Name:             name,
+		Capacity:         presetCapacity,
+		NonNegative:      true,
+		RelativeAccuracy: 0.01,
+		TrackJitter:      true,
+		Unit:             Millisecond,
+	})
+	ds.mustDefineDerived("tail_ratio", "p99 / p50")
+	return ds
+}
+
+// NewSizeStats creates a stream for payload sizes in whole bytes.
+// Percentiles are answered over the whole stream with 2% relative error,
+// and the derived field total is the sum of all sizes.
+func NewSizeStats(name string) *DataStreamStats {
+	ds := NewDataStreamStatsWithOptions(Options{
+		Name:             name,
+		Capacity:         presetCapacity,
+		NonNegative:      true,
+		RelativeAccuracy: 0.02,
+		Quantum:          1,
+		Unit:             Byte,
+	})
+	ds.mustDefineDerived("total", "mean * count")
+	return ds
+}
+
+// NewErrorRateStats creates a stream of request outcomes: add 1 for a
+// failed request and 0 for a successful one. The mean is then the error
+// rate, Snapshot().Window.Mean the error rate of the last requests, and the
+// derived field error_pct the lifetime error rate as a percentage.
+func NewErrorRateStats(name string) *DataStreamStats {
+	ds := NewDataStreamStatsWithOptions(Options{
+		Name:     name,
+		Capacity: presetCapacity,
+	})
+	ds.mustDefineDerived("error_pct", "mean * 100")
+	return ds
+}
+
+// mustDefineDerived defines a derived field whose expression is known to
+// be valid
+func (ds *DataStreamStats) mustDefineDerived(name, expr string) {
+	if err := ds.DefineDerived(name, expr); err != nil {
+		panic(err)
+	}
+}
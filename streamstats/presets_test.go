@@ -0,0 +1,45 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPresets(t *testing.T) {
+	latency := NewLatencyStats("api")
+	defer latency.Stop()
+	for i := 1; i <= 100; i++ {
+		latency.AddNumber(float64(i))
+	}
+	latency.AddNumber(-1)
+	snap := latency.Snapshot()
+	if snap.Unit != "ms" || snap.Jitter == nil || latency.GetNegativeCount() != 1 {
+		t.Errorf("latency preset: unit %q, jitter %v, %d negatives; want ms, jitter and 1 rejected",
+			snap.Unit, snap.Jitter, latency.GetNegativeCount())
+	}
+	if got, ok := snap.Derived["tail_ratio"]; !ok || math.Abs(got-2) > 0.05 {
+		t.Errorf("Derived[tail_ratio] = %v, %v; want about p99/p50 = 2", got, ok)
+	}
+
+	sizes := NewSizeStats("payload")
+	defer sizes.Stop()
+	for _, v := range []float64{100.4, 200, 299.6} {
+		sizes.AddNumber(v)
+	}
+	snap = sizes.Snapshot()
+	if snap.Unit != "B" || snap.Quantum != 1 {
+		t.Errorf("size preset: unit %q, quantum %v; want B and 1", snap.Unit, snap.Quantum)
+	}
+	if got := snap.Derived["total"]; math.Abs(got-600) > 1e-9 {
+		t.Errorf("Derived[total] = %v, want 600", got)
+	}
+
+	errs := NewErrorRateStats("checkout")
+	defer errs.Stop()
+	for i := 0; i < 40; i++ {
+		errs.AddNumber(float64(i % 4 / 3)) // One failure in four
+	}
+	if got := errs.Snapshot().Derived["error_pct"]; got != 25 {
+		t.Errorf("Derived[error_pct] = %v, want 25", got)
+	}
+}
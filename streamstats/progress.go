@@ -0,0 +1,51 @@
+package streamstats
+
+import "time"
+
+// Progress estimates completion of a finite workload
+type Progress struct {
+	Expected  int64
+	Completed int64
+	Percent   float64
+	Rate      float64       // Samples per second since the first sample
+	ETA       time.Duration // Zero when complete or the rate is unknown
+}
+
+// SetExpectedTotal declares how many samples a batch job will add, enabling
+// completion percentage, rate and ETA in snapshots
+func (ds *DataStreamStats) SetExpectedTotal(n int64) {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	ds.expectedTotal = n
+}
+
+// GetProgress returns the current progress, or nil without an expected total
+func (ds *DataStreamStats) GetProgress() *Progress {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	return ds.progress()
+}
+
+// progress computes Progress; callers hold minMaxLock
+func (ds *DataStreamStats) progress() *Progress {
+	if ds.expectedTotal <= 0 {
+		return nil
+	}
+
+	p := &Progress{
+		Expected:  ds.expectedTotal,
+		Completed: ds.count,
+		Percent:   float64(ds.count) / float64(ds.expectedTotal) * 100,
+	}
+	if ds.count > 0 {
+		if elapsed := ds.now().Sub(ds.firstSample).Seconds(); elapsed > 0 {
+			p.Rate = float64(ds.count) / elapsed
+		}
+	}
+	if remaining := ds.expectedTotal - ds.count; remaining > 0 && p.Rate > 0 {
+		p.ETA = time.Duration(float64(remaining) / p.Rate * float64(time.Second))
+	}
+	return p
+}
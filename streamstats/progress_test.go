@@ -0,0 +1,41 @@
+package streamstats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Now: func() time.Time { return now }})
+	defer ds.Stop()
+
+	if p := ds.GetProgress(); p != nil {
+		t.Errorf("GetProgress() without an expected total = %+v, want nil", p)
+	}
+	ds.SetExpectedTotal(100)
+	if p := ds.GetProgress(); p == nil || p.Completed != 0 || p.Rate != 0 || p.ETA != 0 {
+		t.Errorf("GetProgress() before samples = %+v, want no rate or ETA", p)
+	}
+
+	// 25 samples in 5 seconds leave 75 to go at 5/s
+	for i := 0; i < 25; i++ {
+		ds.AddNumber(1)
+	}
+	now = now.Add(5 * time.Second)
+	p := ds.GetProgress()
+	want := Progress{Expected: 100, Completed: 25, Percent: 25, Rate: 5, ETA: 15 * time.Second}
+	if p == nil || *p != want {
+		t.Errorf("GetProgress() = %+v, want %+v", p, want)
+	}
+	if snap := ds.Snapshot(); snap.Progress == nil || *snap.Progress != want {
+		t.Errorf("Snapshot().Progress = %+v, want %+v", snap.Progress, want)
+	}
+
+	for i := 0; i < 75; i++ {
+		ds.AddNumber(1)
+	}
+	if p := ds.GetProgress(); p.Percent != 100 || p.ETA != 0 {
+		t.Errorf("GetProgress() when complete = %+v, want 100%% and no ETA", p)
+	}
+}
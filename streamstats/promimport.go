@@ -0,0 +1,96 @@
+package streamstats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// promResponse is a Prometheus HTTP API query response
+type promResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][2]any          `json:"values"` // Range queries
+			Value  [2]any            `json:"value"`  // Instant queries
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// timedSample is a sample with its timestamp
+type timedSample struct {
+	t time.Time
+	v float64
+}
+
+// ImportPrometheus reads a Prometheus query API response, such as the body
+// of /api/v1/query_range, and adds its samples in timestamp order with
+// their original timestamps, so historical data can seed windows,
+// baselines and forecasts. Every series of the result goes into ds, so
+// query a single series or aggregate in PromQL. Timestamps are taken as
+// is: the skew checks of AddNumberAt do not apply to imported history.
+// NaN samples, including staleness markers, are skipped. It returns the
+// number of samples added.
+func (ds *DataStreamStats) ImportPrometheus(r io.Reader) (int, error) {
+	ds.lazyInit()
+	var resp promResponse
+	if err := json.NewDecoder(r).Decode(&resp); err != nil {
+		return 0, fmt.Errorf("import into stream %q: %w", ds.name, err)
+	}
+	if resp.Status != "success" {
+		return 0, fmt.Errorf("import into stream %q: query failed: %s: %s", ds.name, resp.ErrorType, resp.Error)
+	}
+
+	if rt := resp.Data.ResultType; rt != "matrix" && rt != "vector" {
+		return 0, fmt.Errorf("import into stream %q: unsupported result type %q", ds.name, rt)
+	}
+
+	var samples []timedSample
+	for _, series := range resp.Data.Result {
+		points := series.Values
+		if resp.Data.ResultType == "vector" {
+			points = [][2]any{series.Value}
+		}
+		for _, p := range points {
+			s, err := parsePromPoint(p)
+			if err != nil {
+				return 0, fmt.Errorf("import into stream %q: %w", ds.name, err)
+			}
+			if !math.IsNaN(s.v) {
+				samples = append(samples, s)
+			}
+		}
+	}
+
+	slices.SortStableFunc(samples, func(a, b timedSample) int { return a.t.Compare(b.t) })
+	for _, s := range samples {
+		ds.addAt(s.t, s.v)
+	}
+	return len(samples), nil
+}
+
+// parsePromPoint parses a [unix seconds, "value"] pair
+func parsePromPoint(p [2]any) (timedSample, error) {
+	sec, ok := p[0].(float64)
+	if !ok {
+		return timedSample{}, fmt.Errorf("invalid timestamp %v", p[0])
+	}
+	str, ok := p[1].(string)
+	if !ok {
+		return timedSample{}, fmt.Errorf("invalid sample value %v", p[1])
+	}
+	v, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return timedSample{}, fmt.Errorf("invalid sample value %q", str)
+	}
+	// Prometheus timestamps have millisecond precision
+	return timedSample{t: time.UnixMilli(int64(math.Round(sec * 1000))), v: v}, nil
+}
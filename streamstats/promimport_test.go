@@ -0,0 +1,66 @@
+package streamstats
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const promRange = `{"status":"success","data":{"resultType":"matrix","result":[
+	{"metric":{"instance":"a"},"values":[[1700000000,"1"],[1700000060,"3"],[1700000120,"NaN"]]},
+	{"metric":{"instance":"b"},"values":[[1700000030.5,"2"],[1700000090,"+Inf"]]}
+]}}`
+
+func TestImportPrometheusRange(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, EventWindow: time.Minute})
+	defer ds.Stop()
+
+	n, err := ds.ImportPrometheus(strings.NewReader(promRange))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 {
+		t.Errorf("imported %d samples, want 4", n)
+	}
+	if got := ds.Count(); got != 4 {
+		t.Errorf("Count() = %d, want 4", got)
+	}
+	// Samples arrive in timestamp order, so the watermark is the last one
+	if ws, _ := ds.Watermark(); !ws.Watermark.Equal(time.UnixMilli(1700000090000)) {
+		t.Errorf("watermark = %v, want the last timestamp", ws.Watermark)
+	}
+	if got := ds.windowValues(); len(got) != 4 || got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Errorf("window = %v, want samples in timestamp order", got)
+	}
+}
+
+func TestImportPrometheusInstant(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+
+	body := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"42"]}]}}`
+	if n, err := ds.ImportPrometheus(strings.NewReader(body)); err != nil || n != 1 {
+		t.Fatalf("ImportPrometheus() = %d, %v, want 1 sample", n, err)
+	}
+	if got := ds.GetMean(); got != 42 {
+		t.Errorf("GetMean() = %v, want 42", got)
+	}
+}
+
+func TestImportPrometheusErrors(t *testing.T) {
+	for name, body := range map[string]string{
+		"failed query": `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+		"scalar":       `{"status":"success","data":{"resultType":"scalar","result":[]}}`,
+		"bad value":    `{"status":"success","data":{"resultType":"matrix","result":[{"values":[[1,"x"]]}]}}`,
+		"not json":     `<html>`,
+	} {
+		ds := NewDataStreamStats(10)
+		if _, err := ds.ImportPrometheus(strings.NewReader(body)); err == nil {
+			t.Errorf("%s: ImportPrometheus() returned no error", name)
+		}
+		if ds.Count() != 0 {
+			t.Errorf("%s: samples were added", name)
+		}
+		ds.Stop()
+	}
+}
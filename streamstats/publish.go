@@ -0,0 +1,86 @@
+package streamstats
+
+import "time"
+
+// defaultSmoothingInterval is how often a stream with Options.Smoothing
+// but neither publish option publishes
+const defaultSmoothingInterval = 10 * time.Second
+
+// publishWorker publishes a snapshot whenever AddNumber signals that
+// Options.PublishEvery samples arrived, and every Options.PublishInterval
+func (ds *DataStreamStats) publishWorker() {
+	interval := ds.opts.PublishInterval
+	if interval <= 0 && ds.opts.PublishEvery <= 0 {
+		interval = defaultSmoothingInterval
+	}
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ds.publishChan:
+		case <-tick:
+		case <-ds.stopChan:
+			return
+		}
+		snap := ds.Snapshot()
+		if ds.opts.Smoothing > 0 {
+			snap.Smoothed = smooth(ds.published.Load(), snap.Window, ds.opts.Smoothing)
+		}
+		ds.published.Store(&snap)
+	}
+}
+
+// SmoothedStats are exponential moving averages of the window percentiles
+// over successive published snapshots, see Options.Smoothing
+type SmoothedStats struct {
+	P50     float64 `json:"p50"`
+	P95     float64 `json:"p95"`
+	P99     float64 `json:"p99"`
+	Updates int64   `json:"updates"` // Publications with window samples folded in
+}
+
+// smooth folds the percentiles of w into the smoothed values of the
+// previous publication; empty windows keep the previous values
+func smooth(prev *Snapshot, w WindowStats, weight float64) *SmoothedStats {
+	var s SmoothedStats
+	if prev != nil && prev.Smoothed != nil {
+		s = *prev.Smoothed
+	}
+	if w.Count == 0 {
+		return &s
+	}
+	if s.Updates == 0 {
+		s.P50, s.P95, s.P99 = w.P50, w.P95, w.P99
+	} else {
+		weight = min(weight, 1)
+		s.P50 += weight * (w.P50 - s.P50)
+		s.P95 += weight * (w.P95 - s.P95)
+		s.P99 += weight * (w.P99 - s.P99)
+	}
+	s.Updates++
+	return &s
+}
+
+// Published returns the last published snapshot without taking any lock,
+// or nil before the first publication. The snapshot is shared between
+// readers and must not be modified.
+func (ds *DataStreamStats) Published() *Snapshot {
+	ds.lazyInit()
+	return ds.published.Load()
+}
+
+// PublishedAge returns how old the last published snapshot is; ok is
+// false before the first publication
+func (ds *DataStreamStats) PublishedAge() (age time.Duration, ok bool) {
+	ds.lazyInit()
+	snap := ds.published.Load()
+	if snap == nil {
+		return 0, false
+	}
+	return ds.now().Sub(snap.Time), true
+}
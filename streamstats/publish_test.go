@@ -0,0 +1,98 @@
+package streamstats
+
+import (
+	"testing"
+	"time"
+)
+
+// waitPublished polls until a snapshot with at least count samples is
+// published
+func waitPublished(t *testing.T, ds *DataStreamStats, count int64) *Snapshot {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if snap := ds.Published(); snap != nil && snap.Lifetime.Count >= count {
+			return snap
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("no snapshot with %d samples published", count)
+	return nil
+}
+
+func TestPublishEvery(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 100, PublishEvery: 10})
+	defer ds.Stop()
+
+	if ds.Published() != nil {
+		t.Fatal("Published() before any sample is not nil")
+	}
+	if _, ok := ds.PublishedAge(); ok {
+		t.Fatal("PublishedAge() before any sample is ok")
+	}
+
+	for i := 1; i <= 10; i++ {
+		ds.AddNumber(float64(i))
+	}
+	snap := waitPublished(t, ds, 10)
+	if snap.Lifetime.Mean != 5.5 {
+		t.Errorf("published mean = %v, want 5.5", snap.Lifetime.Mean)
+	}
+	if age, ok := ds.PublishedAge(); !ok || age < 0 {
+		t.Errorf("PublishedAge() = %v, %v", age, ok)
+	}
+}
+
+func TestPublishInterval(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 100, PublishInterval: time.Millisecond})
+	defer ds.Stop()
+
+	ds.AddNumber(3)
+	if snap := waitPublished(t, ds, 1); snap.Lifetime.Max != 3 {
+		t.Errorf("published max = %v, want 3", snap.Lifetime.Max)
+	}
+}
+
+func TestPublishSmoothing(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, PublishEvery: 10, Smoothing: 0.5})
+	defer ds.Stop()
+
+	for i := 0; i < 10; i++ {
+		ds.AddNumber(100)
+	}
+	snap := waitPublished(t, ds, 10)
+	if snap.Smoothed == nil || snap.Smoothed.P99 != 100 || snap.Smoothed.Updates != 1 {
+		t.Fatalf("first Smoothed = %+v, want raw P99 100", snap.Smoothed)
+	}
+
+	// A full window of spikes moves the smoothed value halfway
+	for i := 0; i < 10; i++ {
+		ds.AddNumber(300)
+	}
+	snap = waitPublished(t, ds, 20)
+	if snap.Window.P99 != 300 {
+		t.Errorf("raw P99 = %v, want 300", snap.Window.P99)
+	}
+	if snap.Smoothed.P99 != 200 || snap.Smoothed.P50 != 200 || snap.Smoothed.Updates != 2 {
+		t.Errorf("Smoothed = %+v, want P50 and P99 200", snap.Smoothed)
+	}
+}
+
+func TestSmoothEmptyWindow(t *testing.T) {
+	prev := &Snapshot{Smoothed: &SmoothedStats{P50: 1, P95: 2, P99: 3, Updates: 4}}
+	if s := smooth(prev, WindowStats{}, 0.3); *s != *prev.Smoothed {
+		t.Errorf("smooth over empty window = %+v, want %+v", *s, *prev.Smoothed)
+	}
+	if s := smooth(nil, WindowStats{}, 0.3); s.Updates != 0 {
+		t.Errorf("smooth with no history = %+v", *s)
+	}
+}
+
+func TestSmoothingPublishesByDefault(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Smoothing: 0.5})
+	defer ds.Stop()
+	// Smoothing without a publish option still starts the publish worker
+	if ds.publishChan == nil {
+		t.Error("stream with Smoothing does not publish")
+	}
+}
@@ -0,0 +1,79 @@
+package streamstats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// QQPoint pairs a theoretical quantile with the matching sample quantile
+type QQPoint struct {
+	Theoretical float64
+	Sample      float64
+}
+
+// QQPoints returns quantile-quantile points of the recent samples against
+// kind with the given params (as returned by FitDistribution). Points lying
+// on the line y = x indicate a good fit.
+func (ds *DataStreamStats) QQPoints(kind DistributionKind, params map[string]float64) ([]QQPoint, error) {
+	ds.lazyInit()
+	values := ds.windowValues()
+	sort.Float64s(values)
+
+	points := make([]QQPoint, len(values))
+	for i, v := range values {
+		// Hazen plotting positions keep p strictly inside (0, 1)
+		p := (float64(i) + 0.5) / float64(len(values))
+		q, err := quantile(kind, params, p)
+		if err != nil {
+			return nil, err
+		}
+		points[i] = QQPoint{Theoretical: q, Sample: v}
+	}
+	return points, nil
+}
+
+// quantile is the inverse CDF of kind at p
+func quantile(kind DistributionKind, params map[string]float64, p float64) (float64, error) {
+	param := func(name string) (float64, error) {
+		v, ok := params[name]
+		if !ok {
+			return 0, fmt.Errorf("%s distribution needs parameter %q", kind, name)
+		}
+		return v, nil
+	}
+
+	switch kind {
+	case Normal, LogNormal:
+		mu, err := param("mu")
+		if err != nil {
+			return 0, err
+		}
+		sigma, err := param("sigma")
+		if err != nil {
+			return 0, err
+		}
+		q := mu + sigma*math.Sqrt2*math.Erfinv(2*p-1)
+		if kind == LogNormal {
+			q = math.Exp(q)
+		}
+		return q, nil
+	case Exponential:
+		lambda, err := param("lambda")
+		if err != nil {
+			return 0, err
+		}
+		return -math.Log(1-p) / lambda, nil
+	case Pareto:
+		xm, err := param("xm")
+		if err != nil {
+			return 0, err
+		}
+		alpha, err := param("alpha")
+		if err != nil {
+			return 0, err
+		}
+		return xm / math.Pow(1-p, 1/alpha), nil
+	}
+	return 0, fmt.Errorf("unknown distribution %q", kind)
+}
@@ -0,0 +1,61 @@
+package streamstats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestQQPoints(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	ds := fedStream(t, 2000, func() float64 { return r.ExpFloat64() / 2 })
+	fit, err := ds.FitDistribution(Exponential)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	points, err := ds.QQPoints(Exponential, fit.Params)
+	if err != nil {
+		t.Fatalf("QQPoints: %v", err)
+	}
+	if len(points) != 2000 {
+		t.Fatalf("QQPoints() returned %d points, want 2000", len(points))
+	}
+	for i := 1; i < len(points); i++ {
+		if points[i].Theoretical < points[i-1].Theoretical || points[i].Sample < points[i-1].Sample {
+			t.Fatalf("QQPoints() not ascending at %d", i)
+		}
+	}
+	// A good fit lies near y = x in the bulk of the distribution
+	mid := points[len(points)/2]
+	if math.Abs(mid.Sample-mid.Theoretical) > 0.05 {
+		t.Errorf("median point %+v is far from y = x", mid)
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	for _, tc := range []struct {
+		kind   DistributionKind
+		params map[string]float64
+		p      float64
+		want   float64
+	}{
+		{Normal, map[string]float64{"mu": 5, "sigma": 2}, 0.5, 5},
+		{Normal, map[string]float64{"mu": 0, "sigma": 1}, 0.975, 1.959964},
+		{LogNormal, map[string]float64{"mu": 0, "sigma": 1}, 0.5, 1},
+		{Exponential, map[string]float64{"lambda": 2}, 0.5, math.Ln2 / 2},
+		{Pareto, map[string]float64{"xm": 1, "alpha": 1}, 0.5, 2},
+	} {
+		got, err := quantile(tc.kind, tc.params, tc.p)
+		if err != nil || math.Abs(got-tc.want) > 1e-6 {
+			t.Errorf("quantile(%s, %v, %v) = %v, %v; want %v", tc.kind, tc.params, tc.p, got, err, tc.want)
+		}
+	}
+
+	if _, err := quantile(Normal, map[string]float64{"mu": 0}, 0.5); err == nil {
+		t.Error("quantile without sigma succeeded, want error")
+	}
+	if _, err := quantile("weibull", nil, 0.5); err == nil {
+		t.Error("quantile of an unknown distribution succeeded, want error")
+	}
+}
@@ -0,0 +1,126 @@
+package streamstats
+
+import (
+	"fmt"
+	"math"
+)
+
+// QuantileEstimator answers percentile queries over every sample of a
+// stream in bounded memory. Implementations need not be safe for
+// concurrent use; DataStreamStats serializes access.
+type QuantileEstimator interface {
+	// Add records a sample
+	Add(v float64)
+	// Quantile returns the approximate pth percentile, p in [0, 100]
+	Quantile(p float64) float64
+	// Count returns the number of samples added
+	Count() int64
+	// Merge adds the samples summarized by other, which must be an
+	// estimator of the same kind and configuration
+	Merge(other QuantileEstimator) error
+}
+
+// TDigest returns a constructor of t-digest estimators for
+// Options.Quantiles. Memory is bounded by about 2·compression centroids
+// regardless of stream length. Errors are bounded in rank rather than in
+// value: for percentile q the rank error shrinks in proportion to q(1-q),
+// so the tails (p1, p99.9) are far more accurate than the median. With
+// the common compression of 100, the median is typically within 0.5% of
+// its true rank.
+func TDigest(compression float64) func() QuantileEstimator {
+	return func() QuantileEstimator { return NewTDigestEstimator(compression) }
+}
+
+// DDSketch returns a constructor of log-bucketed estimators for
+// Options.Quantiles. Every percentile is within relativeAccuracy (e.g.
+// 0.01) of the true value, including for negative samples. Memory grows
+// with the logarithm of the value range, not with the stream length:
+// at 1% accuracy, values spanning nine orders of magnitude use about
+// 1000 buckets.
+func DDSketch(relativeAccuracy float64) func() QuantileEstimator {
+	return func() QuantileEstimator { return NewDDSketchEstimator(relativeAccuracy) }
+}
+
+// TDigestEstimator is a merging t-digest QuantileEstimator
+type TDigestEstimator struct {
+	td    *tdigest
+	count int64
+}
+
+// NewTDigestEstimator creates a t-digest with the given compression
+func NewTDigestEstimator(compression float64) *TDigestEstimator {
+	return &TDigestEstimator{td: newTDigest(compression)}
+}
+
+func (te *TDigestEstimator) Add(v float64) {
+	te.td.add(v, 1)
+	te.count++
+}
+
+func (te *TDigestEstimator) Quantile(p float64) float64 { return te.td.quantile(p) }
+func (te *TDigestEstimator) Count() int64               { return te.count }
+
+func (te *TDigestEstimator) Merge(other QuantileEstimator) error {
+	o, ok := other.(*TDigestEstimator)
+	if !ok {
+		return fmt.Errorf("%w %T into a t-digest", ErrIncompatibleMerge, other)
+	}
+	te.td.merge(o.td)
+	te.count += o.count
+	return nil
+}
+
+func (te *TDigestEstimator) memoryUsage() int64 { return te.td.memoryUsage() }
+
+// DDSketchEstimator is a log-bucketed QuantileEstimator with relative
+// error guarantees, keeping separate buckets for negative samples
+type DDSketchEstimator struct {
+	pos *logBuckets // Non-negative samples, including zeros
+	neg *logBuckets // Negated negative samples
+}
+
+// NewDDSketchEstimator creates a sketch with the given relative accuracy
+func NewDDSketchEstimator(relativeAccuracy float64) *DDSketchEstimator {
+	return &DDSketchEstimator{
+		pos: newLogBuckets(relativeAccuracy),
+		neg: newLogBuckets(relativeAccuracy),
+	}
+}
+
+func (de *DDSketchEstimator) Add(v float64) {
+	if v < 0 {
+		de.neg.add(-v)
+	} else {
+		de.pos.add(v)
+	}
+}
+
+func (de *DDSketchEstimator) Quantile(p float64) float64 {
+	total := de.Count()
+	if total == 0 {
+		return 0
+	}
+	rank := max(int64(math.Ceil(p/100*float64(total))), 1)
+	if rank <= de.neg.total {
+		// Negative samples are stored negated, so ranks run backwards
+		return -de.neg.valueAtRank(de.neg.total - rank + 1)
+	}
+	return de.pos.valueAtRank(rank - de.neg.total)
+}
+
+func (de *DDSketchEstimator) Count() int64 { return de.pos.total + de.neg.total }
+
+func (de *DDSketchEstimator) Merge(other QuantileEstimator) error {
+	o, ok := other.(*DDSketchEstimator)
+	if !ok {
+		return fmt.Errorf("%w %T into a DDSketch", ErrIncompatibleMerge, other)
+	}
+	if err := de.pos.merge(o.pos); err != nil {
+		return err
+	}
+	return de.neg.merge(o.neg)
+}
+
+func (de *DDSketchEstimator) memoryUsage() int64 {
+	return int64(len(de.pos.counts)+len(de.neg.counts)) * bucketBytes
+}
@@ -0,0 +1,84 @@
+package streamstats
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestTDigestEstimatorRankError(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	te := NewTDigestEstimator(100)
+	values := make([]float64, 100000)
+	for i := range values {
+		values[i] = r.NormFloat64()
+		te.Add(values[i])
+	}
+	sort.Float64s(values)
+
+	if got := te.Count(); got != int64(len(values)) {
+		t.Fatalf("Count() = %d, want %d", got, len(values))
+	}
+	for _, p := range []float64{1, 10, 50, 90, 99, 99.9} {
+		got := te.Quantile(p)
+		rank := float64(sort.SearchFloat64s(values, got)) / float64(len(values)) * 100
+		if math.Abs(rank-p) > 0.5 {
+			t.Errorf("Quantile(%v) = %v has rank %.3f", p, got, rank)
+		}
+	}
+}
+
+func TestDDSketchEstimatorRelativeError(t *testing.T) {
+	const accuracy = 0.01
+	r := rand.New(rand.NewSource(2))
+	de := NewDDSketchEstimator(accuracy)
+	values := make([]float64, 100000)
+	for i := range values {
+		// Lognormal magnitudes across several decades, a third negative
+		values[i] = math.Exp(r.NormFloat64() * 3)
+		if i%3 == 0 {
+			values[i] = -values[i]
+		}
+		de.Add(values[i])
+	}
+	sort.Float64s(values)
+
+	for _, p := range []float64{1, 10, 30, 50, 90, 99, 100} {
+		want := sortedPercentile(values, p)
+		got := de.Quantile(p)
+		if math.Abs(got-want) > accuracy*math.Abs(want)*1.0001 {
+			t.Errorf("Quantile(%v) = %v, want %v within %v", p, got, want, accuracy)
+		}
+	}
+}
+
+func TestQuantilesOption(t *testing.T) {
+	for name, engine := range map[string]func() QuantileEstimator{
+		"tdigest":  TDigest(100),
+		"ddsketch": DDSketch(0.01),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Quantiles: engine})
+			defer ds.Stop()
+
+			for i := 1; i <= 10000; i++ {
+				ds.AddNumber(float64(i))
+			}
+
+			// The window only holds 9991..10000, the sketch the whole stream
+			if got := ds.GetMedian(); math.Abs(got-5000) > 100 {
+				t.Errorf("GetMedian() = %v, want about 5000", got)
+			}
+			if got := ds.GetPercentile(10); math.Abs(got-1000) > 20 {
+				t.Errorf("GetPercentile(10) = %v, want about 1000", got)
+			}
+			if got := ds.MemoryUsage(); got.Heaps != 0 || got.Sketch == 0 {
+				t.Errorf("MemoryUsage() = %+v, want sketch memory and no heaps", got)
+			}
+			if s := ds.Finalize(); math.Abs(s.Median-5000) > 100 {
+				t.Errorf("Finalize().Median = %v, want about 5000", s.Median)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package streamstats
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestQuantum(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Quantum: 0.5})
+	defer ds.Stop()
+	for _, v := range []float64{1.1, 1.3, 2.74, 3.9} {
+		ds.AddNumber(v)
+	}
+
+	// Quantile structures see rounded values
+	if got := ds.GetWindowStats().Samples; !slices.Equal(got, []float64{1, 1.5, 2.5, 4}) {
+		t.Errorf("window samples = %v, want [1 1.5 2.5 4]", got)
+	}
+	if got := ds.GetMedian(); got != 2 {
+		t.Errorf("GetMedian() = %v, want 2 from the rounded values", got)
+	}
+	if got := ds.GetPercentile(100); got != 4 {
+		t.Errorf("p100 = %v, want 4", got)
+	}
+
+	// Aggregates stay exact
+	if ds.GetMin() != 1.1 || ds.GetMax() != 3.9 || math.Abs(ds.GetMean()-2.26) > 1e-12 {
+		t.Errorf("min/mean/max = %v/%v/%v, want the exact 1.1/2.26/3.9", ds.GetMin(), ds.GetMean(), ds.GetMax())
+	}
+	if got := ds.Snapshot().Quantum; got != 0.5 {
+		t.Errorf("Snapshot().Quantum = %v, want 0.5", got)
+	}
+	if got := ds.Finalize().Quantum; got != 0.5 {
+		t.Errorf("Finalize().Quantum = %v, want 0.5", got)
+	}
+}
@@ -0,0 +1,46 @@
+package streamstats
+
+import (
+	"fmt"
+	"time"
+)
+
+// QueryRange returns the pth percentile of the samples between from and
+// to, merged from the digests of the stored rollups overlapping the range:
+// the closed event windows with Options.EventWindow, or the epochs and the
+// samples before the first one otherwise. Checkpoints persist both. The
+// range is rounded out to whole rollups, and a zero bound leaves that side
+// open.
+func (ds *DataStreamStats) QueryRange(from, to time.Time, p float64) (float64, error) {
+	ds.lazyInit()
+	if err := checkPercentile(p); err != nil {
+		return 0, err
+	}
+	r, err := ds.RangeRollup(from, to)
+	if err != nil {
+		return 0, err
+	}
+	return r.Percentile(p), nil
+}
+
+// RangeRollup merges the stored rollups overlapping [from, to) as
+// QueryRange does, for the count, sum, mean, min and max of the range
+func (ds *DataStreamStats) RangeRollup(from, to time.Time) (Rollup, error) {
+	ds.lazyInit()
+	now := ds.now()
+	var covering []Rollup
+	for r := range ds.StoredRollups() {
+		end := r.End
+		if end.IsZero() {
+			end = now
+		}
+		if (to.IsZero() || r.Start.Before(to)) && (from.IsZero() || end.After(from)) {
+			covering = append(covering, r)
+		}
+	}
+	merged := MergeRollups(covering...)
+	if merged.Count == 0 {
+		return Rollup{}, fmt.Errorf("stream %q has no rollups between %v and %v: %w", ds.name, from, to, ErrEmptyStream)
+	}
+	return merged, nil
+}
@@ -0,0 +1,134 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestQueryRange(t *testing.T) {
+	t0 := time.Unix(1699999980, 0) // Minute-aligned
+	opts := Options{Capacity: 10, EventWindow: time.Minute, Now: func() time.Time { return t0.Add(time.Hour) }}
+	ds := NewDataStreamStatsWithOptions(opts)
+	defer ds.Stop()
+
+	// Minute m holds the values 100m+1 .. 100m+100
+	for m := 0; m < 5; m++ {
+		for i := 1; i <= 100; i++ {
+			ds.AddNumberAt(t0.Add(time.Duration(m)*time.Minute+time.Duration(i)*100*time.Millisecond), float64(100*m+i))
+		}
+	}
+	ds.AdvanceWatermark(t0.Add(10 * time.Minute))
+
+	got, err := ds.QueryRange(t0.Add(time.Minute), t0.Add(3*time.Minute), 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(got-200) > 2 {
+		t.Errorf("QueryRange(1m, 3m, 50) = %v, want about 200", got)
+	}
+	r, _ := ds.RangeRollup(t0.Add(90*time.Second), time.Time{})
+	if r.Count != 400 || r.Min != 101 || r.Max != 500 {
+		t.Errorf("RangeRollup(1m30s, open) = count %d, min %v, max %v, want minutes 1-4", r.Count, r.Min, r.Max)
+	}
+	if _, err := ds.QueryRange(t0.Add(time.Hour), time.Time{}, 50); err == nil {
+		t.Error("QueryRange() without rollups returned no error")
+	}
+
+	// Closed windows survive a checkpoint
+	data, err := ds.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewDataStreamStatsWithOptions(opts)
+	defer restored.Stop()
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := restored.QueryRange(t0.Add(time.Minute), t0.Add(3*time.Minute), 50); err != nil || again != got {
+		t.Errorf("restored QueryRange() = %v, %v, want %v", again, err, got)
+	}
+}
+
+func TestQueryRangeEpochs(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+
+	start := time.Now()
+	ds.SetEpoch("a")
+	for i := 1; i <= 100; i++ {
+		ds.AddNumber(float64(i))
+	}
+	ds.SetEpoch("b")
+	for i := 101; i <= 200; i++ {
+		ds.AddNumber(float64(i))
+	}
+
+	got, err := ds.QueryRange(start, time.Time{}, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(got-100) > 2 {
+		t.Errorf("QueryRange() over both epochs = %v, want about 100", got)
+	}
+}
+
+func TestQueryRangePreEpochAndCheckpoint(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := t0
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Now: func() time.Time { return now }})
+	defer ds.Stop()
+	for i := 1; i <= 100; i++ {
+		ds.AddNumber(float64(i))
+	}
+
+	// Samples before any epoch are covered
+	if got, err := ds.QueryRange(time.Time{}, time.Time{}, 50); err != nil || math.Abs(got-50) > 1.5 {
+		t.Errorf("QueryRange() without epochs = %v, %v, want about 50", got, err)
+	}
+	now = t0.Add(time.Minute)
+	ds.SetEpoch("v1")
+	ds.AddNumber(5)
+	if got, err := ds.QueryRange(time.Time{}, time.Time{}, 50); err != nil || math.Abs(got-50) > 1.5 {
+		t.Errorf("QueryRange() after SetEpoch = %v, %v, want about 50", got, err)
+	}
+
+	// Epoch digests survive a checkpoint, and the open epoch keeps going
+	data, err := ds.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewDataStreamStatsWithOptions(Options{Capacity: 10, Now: func() time.Time { return now }})
+	defer restored.Stop()
+	if err := restored.UnmarshalJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	restored.AddNumber(7)
+	rs := restored.Rollups()
+	if len(rs) != 2 || rs[0].Count != 100 || rs[1].Label != "v1" || rs[1].Count != 2 || rs[1].Max != 7 {
+		t.Fatalf("restored Rollups() = %+v, want 100 pre-epoch samples and 2 in v1", rs)
+	}
+	if got, err := restored.QueryRange(time.Time{}, now, 50); err != nil || math.Abs(got-50) > 1.5 {
+		t.Errorf("restored QueryRange() = %v, %v, want about 50", got, err)
+	}
+
+	// The binary encoding nests the epoch's checkpoint too
+	bin, err := restored.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again := NewDataStreamStats(10)
+	defer again.Stop()
+	if err := again.UnmarshalBinary(bin); err != nil {
+		t.Fatal(err)
+	}
+	if got := again.Rollups(); len(got) != 2 || got[1].Count != 2 {
+		t.Errorf("Rollups() after a binary round trip = %+v, want 2 with v1 holding 2 samples", got)
+	}
+
+	restored.SetEpoch("v2")
+	epochs := restored.Epochs()
+	if len(epochs) != 2 || epochs[0].Label != "v1" || epochs[0].Summary.Count != 2 || epochs[0].Summary.Max != 7 {
+		t.Errorf("Epochs() after restore = %+v, want v1 closed with 2 samples", epochs)
+	}
+}
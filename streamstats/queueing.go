@@ -0,0 +1,62 @@
+package streamstats
+
+import "math"
+
+// Concurrency applies Little's Law, L = λW: the average number of requests
+// in the system given the arrival rate and the mean time spent in it.
+// Rate and latency must use the same time unit (e.g. req/s and seconds).
+func Concurrency(arrivalRate, latency float64) float64 {
+	return arrivalRate * latency
+}
+
+// StreamConcurrency applies Little's Law to a stream of arrival rate
+// samples and a stream of latency samples using their means
+func StreamConcurrency(rate, latency *DataStreamStats) float64 {
+	return Concurrency(rate.GetMean(), latency.GetMean())
+}
+
+// Utilization returns ρ = λ / (c·μ) for c servers each completing
+// serviceRate requests per time unit
+func Utilization(arrivalRate, serviceRate float64, servers int) float64 {
+	return arrivalRate / (float64(servers) * serviceRate)
+}
+
+// MM1 holds the steady-state predictions of an M/M/1 queue
+type MM1 struct {
+	Utilization float64 // ρ = λ/μ
+	QueueLength float64 // Lq, mean number waiting
+	InSystem    float64 // L, mean number waiting or in service
+	Wait        float64 // Wq, mean time spent waiting
+	Response    float64 // W, mean time waiting plus service
+}
+
+// PredictMM1 returns M/M/1 predictions for the given arrival and service
+// rates. ok is false when ρ ≥ 1 and the queue grows without bound.
+func PredictMM1(arrivalRate, serviceRate float64) (m MM1, ok bool) {
+	rho := arrivalRate / serviceRate
+	if rho >= 1 {
+		return MM1{
+			Utilization: rho,
+			QueueLength: math.Inf(1),
+			InSystem:    math.Inf(1),
+			Wait:        math.Inf(1),
+			Response:    math.Inf(1),
+		}, false
+	}
+	return MM1{
+		Utilization: rho,
+		QueueLength: rho * rho / (1 - rho),
+		InSystem:    rho / (1 - rho),
+		Wait:        rho / (serviceRate - arrivalRate),
+		Response:    1 / (serviceRate - arrivalRate),
+	}, true
+}
+
+// ServiceRateFromLatency estimates μ from the mean of a service time stream
+func ServiceRateFromLatency(serviceTime *DataStreamStats) float64 {
+	mean := serviceTime.GetMean()
+	if mean == 0 {
+		return math.Inf(1)
+	}
+	return 1 / mean
+}
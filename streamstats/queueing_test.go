@@ -0,0 +1,63 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLittlesLaw(t *testing.T) {
+	if got := Concurrency(100, 0.05); math.Abs(got-5) > 1e-12 {
+		t.Errorf("Concurrency(100/s, 50ms) = %v, want 5", got)
+	}
+	if got := Utilization(90, 10, 10); got != 0.9 {
+		t.Errorf("Utilization(90, 10, 10 servers) = %v, want 0.9", got)
+	}
+
+	rate := fedStream(t, 4, func() float64 { return 200 })
+	latency := NewDataStreamStats(4)
+	defer latency.Stop()
+	for _, v := range []float64{0.01, 0.03} {
+		latency.AddNumber(v)
+	}
+	if got := StreamConcurrency(rate, latency); math.Abs(got-4) > 1e-12 {
+		t.Errorf("StreamConcurrency() = %v, want 4", got)
+	}
+	if got := ServiceRateFromLatency(latency); math.Abs(got-50) > 1e-9 {
+		t.Errorf("ServiceRateFromLatency() = %v, want 50/s", got)
+	}
+	empty := NewDataStreamStats(4)
+	defer empty.Stop()
+	if got := ServiceRateFromLatency(empty); !math.IsInf(got, 1) {
+		t.Errorf("ServiceRateFromLatency(empty) = %v, want +Inf", got)
+	}
+}
+
+func TestPredictMM1(t *testing.T) {
+	m, ok := PredictMM1(8, 10)
+	want := MM1{Utilization: 0.8, QueueLength: 3.2, InSystem: 4, Wait: 0.4, Response: 0.5}
+	if !ok {
+		t.Fatal("PredictMM1(8, 10) is unstable, want stable")
+	}
+	for _, f := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"Utilization", m.Utilization, want.Utilization},
+		{"QueueLength", m.QueueLength, want.QueueLength},
+		{"InSystem", m.InSystem, want.InSystem},
+		{"Wait", m.Wait, want.Wait},
+		{"Response", m.Response, want.Response},
+	} {
+		if math.Abs(f.got-f.want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", f.name, f.got, f.want)
+		}
+	}
+	// Little's Law holds within the model
+	if math.Abs(m.InSystem-8*m.Response) > 1e-9 {
+		t.Errorf("L = %v, want λW = %v", m.InSystem, 8*m.Response)
+	}
+
+	if m, ok := PredictMM1(10, 10); ok || !math.IsInf(m.Response, 1) {
+		t.Errorf("PredictMM1(10, 10) = %+v, %v; want an unbounded queue", m, ok)
+	}
+}
@@ -0,0 +1,58 @@
+package streamstats
+
+import (
+	"sync"
+	"time"
+)
+
+// TokenBucket is a token-bucket rate limiter for ingestion.
+// A single bucket can be shared by several streams to limit a tenant.
+type TokenBucket struct {
+	mu       sync.Mutex
+	rate     float64 // Tokens added per second
+	burst    float64 // Maximum number of tokens
+	tokens   float64
+	last     time.Time
+	allowed  int64
+	rejected int64
+	now      func() time.Time
+}
+
+// NewTokenBucket creates a bucket refilling at rate tokens/sec up to burst
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+// Allow reports whether one more sample may be ingested
+func (tb *TokenBucket) Allow() bool {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	now := tb.now()
+	tb.tokens += now.Sub(tb.last).Seconds() * tb.rate
+	if tb.tokens > tb.burst {
+		tb.tokens = tb.burst
+	}
+	tb.last = now
+
+	if tb.tokens < 1 {
+		tb.rejected++
+		return false
+	}
+	tb.tokens--
+	tb.allowed++
+	return true
+}
+
+// Counts returns how many requests were allowed and rejected so far
+func (tb *TokenBucket) Counts() (allowed, rejected int64) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	return tb.allowed, tb.rejected
+}
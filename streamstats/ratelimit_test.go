@@ -0,0 +1,62 @@
+package streamstats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tb := NewTokenBucket(10, 3)
+	tb.now = func() time.Time { return now }
+	tb.last = now
+
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if tb.Allow() {
+			allowed++
+		}
+	}
+	if allowed != 3 {
+		t.Errorf("burst allowed %d samples, want 3", allowed)
+	}
+
+	// 10 tokens/s refill one token every 100ms, up to the burst
+	now = now.Add(100 * time.Millisecond)
+	if !tb.Allow() || tb.Allow() {
+		t.Error("after 100ms the bucket should hold exactly one token")
+	}
+	now = now.Add(time.Hour)
+	allowed = 0
+	for i := 0; i < 5; i++ {
+		if tb.Allow() {
+			allowed++
+		}
+	}
+	if allowed != 3 {
+		t.Errorf("after an hour the bucket allowed %d samples, want the burst of 3", allowed)
+	}
+
+	if a, r := tb.Counts(); a != 7 || r != 5 {
+		t.Errorf("Counts() = %d allowed, %d rejected; want 7 and 5", a, r)
+	}
+}
+
+func TestSharedLimiter(t *testing.T) {
+	tenant := NewTokenBucket(0, 4) // Four tokens, never refilled
+	a := NewDataStreamStatsWithOptions(Options{Capacity: 10, Limiter: tenant})
+	defer a.Stop()
+	b := NewDataStreamStatsWithOptions(Options{Capacity: 10, Limiter: tenant})
+	defer b.Stop()
+
+	for i := 0; i < 3; i++ {
+		a.AddNumber(1)
+		b.AddNumber(1)
+	}
+	if got := a.Count() + b.Count(); got != 4 {
+		t.Errorf("streams sharing a 4-token bucket accepted %d samples, want 4", got)
+	}
+	if got := a.GetShedCount() + b.GetShedCount(); got != 2 {
+		t.Errorf("shed %d samples, want 2", got)
+	}
+}
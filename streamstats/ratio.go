@@ -0,0 +1,116 @@
+package streamstats
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// wilsonZ is the normal quantile of the 95% Wilson confidence interval
+const wilsonZ = 1.96
+
+// RatioStats is a success ratio with its 95% Wilson confidence interval
+type RatioStats struct {
+	Successes int64
+	Total     int64
+	Ratio     float64 // Successes / Total, 0 when Total is 0
+	Lower     float64
+	Upper     float64
+}
+
+// RatioSnapshot holds the ratio of the current window and of the lifetime
+type RatioSnapshot struct {
+	Window   RatioStats
+	Lifetime RatioStats
+}
+
+// RatioTracker tracks a success ratio such as availability over tumbling
+// time windows. Unlike a plain mean, its Wilson interval stays meaningful
+// for windows with few requests or ratios close to 0 or 1.
+type RatioTracker struct {
+	mu          sync.Mutex
+	window      time.Duration
+	windowStart time.Time
+	successes   int64 // Current window
+	total       int64
+	lifeSucc    int64
+	lifeTotal   int64
+}
+
+// NewRatioTracker creates a tracker whose window restarts every window
+func NewRatioTracker(window time.Duration) *RatioTracker {
+	return &RatioTracker{window: window, windowStart: time.Now()}
+}
+
+// Record adds one outcome
+func (rt *RatioTracker) Record(success bool) {
+	var s int64
+	if success {
+		s = 1
+	}
+	rt.RecordN(s, 1)
+}
+
+// RecordN adds total outcomes of which successes succeeded
+func (rt *RatioTracker) RecordN(successes, total int64) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	rt.rotate()
+	rt.successes += successes
+	rt.total += total
+	rt.lifeSucc += successes
+	rt.lifeTotal += total
+}
+
+// rotate starts a new window once the current one has elapsed; callers
+// hold mu
+func (rt *RatioTracker) rotate() {
+	if rt.window <= 0 {
+		return
+	}
+	if elapsed := time.Since(rt.windowStart); elapsed >= rt.window {
+		rt.windowStart = rt.windowStart.Add(elapsed.Truncate(rt.window))
+		rt.successes, rt.total = 0, 0
+	}
+}
+
+// Snapshot returns the current window and lifetime ratios
+func (rt *RatioTracker) Snapshot() RatioSnapshot {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	rt.rotate()
+	return RatioSnapshot{
+		Window:   ratioStats(rt.successes, rt.total),
+		Lifetime: ratioStats(rt.lifeSucc, rt.lifeTotal),
+	}
+}
+
+// ratioStats computes the ratio and its Wilson score interval
+func ratioStats(successes, total int64) RatioStats {
+	rs := RatioStats{Successes: successes, Total: total}
+	if total == 0 {
+		rs.Upper = 1
+		return rs
+	}
+	n := float64(total)
+	p := float64(successes) / n
+	z2 := wilsonZ * wilsonZ
+	center := (p + z2/(2*n)) / (1 + z2/n)
+	half := wilsonZ / (1 + z2/n) * math.Sqrt(p*(1-p)/n+z2/(4*n*n))
+
+	rs.Ratio = p
+	rs.Lower = max(0, center-half)
+	rs.Upper = min(1, center+half)
+	return rs
+}
+
+// AttachRatio includes rt in the stream's snapshots, e.g. the availability
+// of the requests whose latencies the stream records
+func (ds *DataStreamStats) AttachRatio(rt *RatioTracker) {
+	ds.lazyInit()
+	ds.cachedLock.Lock()
+	defer ds.cachedLock.Unlock()
+	ds.ratio = rt
+}
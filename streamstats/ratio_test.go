@@ -0,0 +1,57 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRatioStats(t *testing.T) {
+	for _, tc := range []struct {
+		successes, total    int64
+		ratio, lower, upper float64
+	}{
+		{0, 0, 0, 0, 1},
+		{10, 10, 1, 0.7225, 1},
+		{0, 10, 0, 0, 0.2775},
+		{50, 100, 0.5, 0.4038, 0.5962},
+	} {
+		rs := ratioStats(tc.successes, tc.total)
+		if rs.Ratio != tc.ratio || math.Abs(rs.Lower-tc.lower) > 1e-4 || math.Abs(rs.Upper-tc.upper) > 1e-4 {
+			t.Errorf("ratioStats(%d, %d) = %+v, want ratio %v in [%v, %v]", tc.successes, tc.total, rs, tc.ratio, tc.lower, tc.upper)
+		}
+	}
+}
+
+func TestRatioTracker(t *testing.T) {
+	rt := NewRatioTracker(time.Minute)
+	for i := 0; i < 10; i++ {
+		rt.Record(i != 0)
+	}
+	rt.RecordN(5, 10)
+
+	snap := rt.Snapshot()
+	if snap.Window.Successes != 14 || snap.Window.Total != 20 || snap.Lifetime != snap.Window {
+		t.Errorf("Snapshot() = %+v, want 14 of 20 in both window and lifetime", snap)
+	}
+
+	// Once the window elapses only the lifetime keeps the outcomes
+	rt.mu.Lock()
+	rt.windowStart = rt.windowStart.Add(-90 * time.Second)
+	rt.mu.Unlock()
+	rt.Record(false)
+	snap = rt.Snapshot()
+	if snap.Window.Total != 1 || snap.Window.Ratio != 0 {
+		t.Errorf("new window = %+v, want the one failure", snap.Window)
+	}
+	if snap.Lifetime.Total != 21 || snap.Lifetime.Successes != 14 {
+		t.Errorf("lifetime = %+v, want 14 of 21", snap.Lifetime)
+	}
+
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+	ds.AttachRatio(rt)
+	if got := ds.Snapshot().Ratio; got == nil || got.Lifetime.Total != 21 {
+		t.Errorf("Snapshot().Ratio = %+v, want the attached tracker", got)
+	}
+}
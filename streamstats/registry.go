@@ -0,0 +1,550 @@
+package streamstats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Labels are the name/value pairs that distinguish streams of one metric,
+// e.g. per endpoint or per tenant
+type Labels map[string]string
+
+// StatsRegistry creates and looks up named, labeled streams concurrently
+// and exports them in Prometheus text format or JSON
+type StatsRegistry struct {
+	mu      sync.RWMutex
+	opts    Options
+	streams map[string]*registeredStream
+	gauges  map[string]*registeredStream
+}
+
+// registeredStream is one series of a registry, a stream or a gauge
+type registeredStream struct {
+	name   string
+	labels Labels
+	key    string // Prometheus series identity, name{k="v",...}
+	ds     *DataStreamStats
+	gauge  *RegistryGauge // Set instead of ds for gauges
+}
+
+// generation advances whenever the series has something new to export
+func (rs *registeredStream) generation() uint64 {
+	if rs.gauge != nil {
+		return rs.gauge.generation.Load()
+	}
+	return rs.ds.Generation()
+}
+
+// RegistryGauge is a registry series holding one current value, for
+// values that are aggregates already, such as SLIs. It is exported as a
+// Prometheus gauge under its own name.
+type RegistryGauge struct {
+	bits       atomic.Uint64
+	generation atomic.Uint64
+}
+
+// Set replaces the value of the gauge
+func (g *RegistryGauge) Set(v float64) {
+	g.bits.Store(math.Float64bits(v))
+	g.generation.Add(1)
+}
+
+// Value returns the current value of the gauge
+func (g *RegistryGauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+// NewStatsRegistry creates a registry; streams are created on first use
+// from opts with the series identity as Name
+func NewStatsRegistry(opts Options) *StatsRegistry {
+	return &StatsRegistry{
+		opts:    opts,
+		streams: make(map[string]*registeredStream),
+		gauges:  make(map[string]*registeredStream),
+	}
+}
+
+// Get returns the stream of a metric and label set, creating it if
+// needed. Names and label names are sanitized to Prometheus rules.
+func (r *StatsRegistry) Get(name string, labels Labels) *DataStreamStats {
+	name = sanitizeMetricName(name)
+	key := seriesKey(name, labels)
+
+	r.mu.RLock()
+	rs, ok := r.streams[key]
+	r.mu.RUnlock()
+	if ok {
+		return rs.ds
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rs, ok := r.streams[key]; ok {
+		return rs.ds
+	}
+	opts := r.opts
+	opts.Name = key
+	rs = &registeredStream{
+		name:   name,
+		labels: sanitizeLabels(labels),
+		key:    key,
+		ds:     NewDataStreamStatsWithOptions(opts),
+	}
+	r.streams[key] = rs
+	return rs.ds
+}
+
+// Gauge returns the gauge of a metric and label set, creating it at 0 if
+// needed. Names are sanitized like Get's; a name must not be used for both
+// streams and gauges.
+func (r *StatsRegistry) Gauge(name string, labels Labels) *RegistryGauge {
+	name = sanitizeMetricName(name)
+	key := seriesKey(name, labels)
+
+	r.mu.RLock()
+	rs, ok := r.gauges[key]
+	r.mu.RUnlock()
+	if ok {
+		return rs.gauge
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rs, ok := r.gauges[key]; ok {
+		return rs.gauge
+	}
+	rs = &registeredStream{
+		name:   name,
+		labels: sanitizeLabels(labels),
+		key:    key,
+		gauge:  &RegistryGauge{},
+	}
+	r.gauges[key] = rs
+	return rs.gauge
+}
+
+// Len returns the number of series, streams and gauges
+func (r *StatsRegistry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.streams) + len(r.gauges)
+}
+
+// Stop stops every stream of the registry
+func (r *StatsRegistry) Stop() {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, rs := range r.streams {
+		rs.ds.Stop()
+	}
+}
+
+// page returns up to limit series ordered by metric name then identity,
+// starting after the series identified by cursor, and the cursor of the
+// next page or "" when this is the last one
+func (r *StatsRegistry) page(cursor string, limit int) ([]*registeredStream, string) {
+	r.mu.RLock()
+	all := make([]*registeredStream, 0, len(r.streams)+len(r.gauges))
+	for _, rs := range r.streams {
+		all = append(all, rs)
+	}
+	for _, rs := range r.gauges {
+		all = append(all, rs)
+	}
+	r.mu.RUnlock()
+
+	slices.SortFunc(all, compareSeries)
+	from := 0
+	if cursor != "" {
+		after := &registeredStream{name: cursorName(cursor), key: cursor}
+		from, _ = slices.BinarySearchFunc(all, after, compareSeries)
+		if from < len(all) && all[from].key == cursor {
+			from++
+		}
+	}
+	all = all[from:]
+	if limit <= 0 || len(all) <= limit {
+		return all, ""
+	}
+	return all[:limit], all[limit-1].key
+}
+
+// compareSeries orders series by metric name so each family is contiguous
+func compareSeries(a, b *registeredStream) int {
+	if c := strings.Compare(a.name, b.name); c != 0 {
+		return c
+	}
+	return strings.Compare(a.key, b.key)
+}
+
+// cursorName extracts the metric name from a series identity
+func cursorName(key string) string {
+	if i := strings.IndexByte(key, '{'); i >= 0 {
+		return key[:i]
+	}
+	return key
+}
+
+// String returns every series as a JSON array, so a registry can be
+// published with expvar.Publish
+func (r *StatsRegistry) String() string {
+	series, _ := r.page("", 0)
+	var sb strings.Builder
+	writeJSONSeries(&sb, series)
+	return sb.String()
+}
+
+// seriesView is what the exporters read from one series, taken once per
+// export so every format reports the same values
+type seriesView struct {
+	stats   Stats
+	custom  map[string]float64 // Registered custom statistics by name
+	derived map[string]float64 // Derived fields by name, see DefineDerived
+
+	// Retained exemplars ordered by value, see AddNumberWithExemplar
+	exemplars []Exemplar
+
+	// Window percentiles of the last published snapshot next to their
+	// smoothed values, nil without Options.Smoothing or a publication
+	smoothed *smoothedView
+}
+
+// smoothedView is the raw and smoothed window percentiles of one published
+// snapshot, see Options.Smoothing
+type smoothedView struct {
+	Raw      windowQuantiles `json:"raw"`
+	Smoothed SmoothedStats   `json:"smoothed"`
+}
+
+// windowQuantiles are the raw window percentiles smoothing starts from
+type windowQuantiles struct {
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+}
+
+// viewSeries reads the exported values of rs
+func viewSeries(rs *registeredStream) seriesView {
+	cached := rs.ds.GetCachedStats()
+	v := seriesView{
+		stats:     rs.ds.Stats(),
+		custom:    cached.custom,
+		derived:   cached.derived,
+		exemplars: rs.ds.Exemplars(),
+	}
+	if snap := rs.ds.Published(); snap != nil && snap.Smoothed != nil && snap.Smoothed.Updates > 0 {
+		v.smoothed = &smoothedView{
+			Raw:      windowQuantiles{P50: snap.Window.P50, P95: snap.Window.P95, P99: snap.Window.P99},
+			Smoothed: *snap.Smoothed,
+		}
+	}
+	return v
+}
+
+// seriesJSON is the JSON form of one series
+type seriesJSON struct {
+	Name      string             `json:"name"`
+	Labels    Labels             `json:"labels,omitempty"`
+	Source    *Source            `json:"source,omitempty"`
+	Stats     *Stats             `json:"stats,omitempty"`
+	Value     *float64           `json:"value,omitempty"` // Gauges only`
+	Custom    map[string]float64 `json:"custom,omitempty"`
+	Derived   map[string]float64 `json:"derived,omitempty"`
+	Exemplars []Exemplar         `json:"exemplars,omitempty"`
+	Smoothed  *smoothedView      `json:"smoothed,omitempty"`
+}
+
+// writeJSONSeries writes series as a JSON array, one element at a time
+func writeJSONSeries(w io.Writer, series []*registeredStream) {
+	io.WriteString(w, "[")
+	for i, rs := range series {
+		if i > 0 {
+			io.WriteString(w, ",")
+		}
+		if rs.gauge != nil {
+			v := finiteOrZero(rs.gauge.Value())
+			b, _ := json.Marshal(seriesJSON{Name: rs.name, Labels: rs.labels, Value: &v})
+			w.Write(b)
+			continue
+		}
+		v := viewSeries(rs)
+		st := v.stats
+		// JSON has no Inf or NaN
+		for _, f := range []*float64{&st.Sum, &st.Mean, &st.StdDev, &st.Median, &st.Min, &st.Max, &st.P95, &st.P99} {
+			*f = finiteOrZero(*f)
+		}
+		for _, m := range []map[string]float64{v.custom, v.derived} {
+			for k, f := range m {
+				m[k] = finiteOrZero(f)
+			}
+		}
+		if sm := v.smoothed; sm != nil {
+			for _, f := range []*float64{&sm.Raw.P50, &sm.Raw.P95, &sm.Raw.P99, &sm.Smoothed.P50, &sm.Smoothed.P95, &sm.Smoothed.P99} {
+				*f = finiteOrZero(*f)
+			}
+		}
+		src := rs.ds.source()
+		b, _ := json.Marshal(seriesJSON{
+			Name:      rs.name,
+			Labels:    rs.labels,
+			Source:    &src,
+			Stats:     &st,
+			Custom:    v.custom,
+			Derived:   v.derived,
+			Exemplars: v.exemplars,
+			Smoothed:  v.smoothed,
+		})
+		w.Write(b)
+	}
+	io.WriteString(w, "]")
+}
+
+// finiteOrZero returns v, or 0 for Inf and NaN
+func finiteOrZero(v float64) float64 {
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0
+	}
+	return v
+}
+
+// writePrometheus writes series in Prometheus text exposition format:
+// registry gauges as they are, and per stream metric a summary with the
+// median, p95 and p99 plus mean, min and max gauges, a name_<field> gauge
+// per custom statistic and derived field, a name_exemplar gauge linking
+// each quantile to the trace_id of its most recent exemplar, and with
+// Options.Smoothing name_window and name_smoothed gauges holding the raw
+// and smoothed window percentiles. Series must be ordered by metric name.
+func writePrometheus(w io.Writer, series []*registeredStream) {
+	for len(series) > 0 {
+		n := 1
+		for n < len(series) && series[n].name == series[0].name && (series[n].gauge == nil) == (series[0].gauge == nil) {
+			n++
+		}
+		family := series[:n]
+		series = series[n:]
+
+		name := family[0].name
+		if family[0].gauge != nil {
+			fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+			for _, rs := range family {
+				fmt.Fprintf(w, "%s%s %s\n", name, formatLabels(rs.labels, "", ""), formatValue(rs.gauge.Value()))
+			}
+			continue
+		}
+
+		views := make([]seriesView, len(family))
+		for i, rs := range family {
+			views[i] = viewSeries(rs)
+		}
+
+		fmt.Fprintf(w, "# TYPE %s summary\n", name)
+		for i, rs := range family {
+			st := views[i].stats
+			for _, q := range []struct {
+				label string
+				value float64
+			}{{"0.5", st.Median}, {"0.95", st.P95}, {"0.99", st.P99}} {
+				fmt.Fprintf(w, "%s%s %s\n", name, formatLabels(rs.labels, "quantile", q.label), formatValue(q.value))
+			}
+			fmt.Fprintf(w, "%s_sum%s %s\n", name, formatLabels(rs.labels, "", ""), formatValue(st.Sum))
+			fmt.Fprintf(w, "%s_count%s %d\n", name, formatLabels(rs.labels, "", ""), st.Count)
+		}
+		for _, g := range []struct {
+			suffix string
+			value  func(Stats) float64
+		}{
+			{"_mean", func(st Stats) float64 { return st.Mean }},
+			{"_min", func(st Stats) float64 { return st.Min }},
+			{"_max", func(st Stats) float64 { return st.Max }},
+		} {
+			fmt.Fprintf(w, "# TYPE %s%s gauge\n", name, g.suffix)
+			for i, rs := range family {
+				fmt.Fprintf(w, "%s%s%s %s\n", name, g.suffix, formatLabels(rs.labels, "", ""), formatValue(g.value(views[i].stats)))
+			}
+		}
+		writeNamedGauges(w, name, family, views, func(v seriesView) map[string]float64 { return v.custom })
+		writeNamedGauges(w, name, family, views, func(v seriesView) map[string]float64 { return v.derived })
+		writeExemplars(w, name, family, views)
+		writeSmoothed(w, name, family, views)
+	}
+}
+
+// writeSmoothed writes the raw window percentiles of the series with
+// smoothing as name_window and their smoothed values as name_smoothed,
+// both labeled by quantile
+func writeSmoothed(w io.Writer, name string, family []*registeredStream, views []seriesView) {
+	if !slices.ContainsFunc(views, func(v seriesView) bool { return v.smoothed != nil }) {
+		return
+	}
+	for _, g := range []struct {
+		suffix string
+		values func(*smoothedView) [3]float64
+	}{
+		{"_window", func(sm *smoothedView) [3]float64 { return [3]float64{sm.Raw.P50, sm.Raw.P95, sm.Raw.P99} }},
+		{"_smoothed", func(sm *smoothedView) [3]float64 {
+			return [3]float64{sm.Smoothed.P50, sm.Smoothed.P95, sm.Smoothed.P99}
+		}},
+	} {
+		fmt.Fprintf(w, "# TYPE %s%s gauge\n", name, g.suffix)
+		for i, rs := range family {
+			if views[i].smoothed == nil {
+				continue
+			}
+			for j, v := range g.values(views[i].smoothed) {
+				q := [...]string{"0.5", "0.95", "0.99"}[j]
+				fmt.Fprintf(w, "%s%s%s %s\n", name, g.suffix, formatLabels(rs.labels, "quantile", q), formatValue(v))
+			}
+		}
+	}
+}
+
+// writeExemplars writes the value of the most recent exemplar in the
+// bucket of each quantile, labeled with its quantile and trace_id. The
+// text format has no exemplar syntax, so they are exported as a gauge.
+func writeExemplars(w io.Writer, name string, family []*registeredStream, views []seriesView) {
+	header := false
+	for i, rs := range family {
+		v := views[i]
+		if len(v.exemplars) == 0 {
+			continue
+		}
+		for _, q := range []struct {
+			label string
+			value float64
+		}{{"0.5", v.stats.Median}, {"0.95", v.stats.P95}, {"0.99", v.stats.P99}} {
+			ex, ok := latestExemplar(v.exemplars, q.value)
+			if !ok {
+				continue
+			}
+			if !header {
+				fmt.Fprintf(w, "# TYPE %s_exemplar gauge\n", name)
+				header = true
+			}
+			labels := maps.Clone(rs.labels)
+			if labels == nil {
+				labels = make(Labels, 1)
+			}
+			labels["trace_id"] = ex.TraceID
+			fmt.Fprintf(w, "%s_exemplar%s %s\n", name, formatLabels(labels, "quantile", q.label), formatValue(ex.Value))
+		}
+	}
+}
+
+// writeNamedGauges writes a name_<key> gauge for every key of the maps
+// values returns, each with the series that have that key
+func writeNamedGauges(w io.Writer, name string, family []*registeredStream, views []seriesView, values func(seriesView) map[string]float64) {
+	var keys []string
+	for _, v := range views {
+		for k := range values(v) {
+			if !slices.Contains(keys, k) {
+				keys = append(keys, k)
+			}
+		}
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		gauge := name + "_" + sanitizeMetricName(k)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", gauge)
+		for i, rs := range family {
+			if v, ok := values(views[i])[k]; ok {
+				fmt.Fprintf(w, "%s%s %s\n", gauge, formatLabels(rs.labels, "", ""), formatValue(v))
+			}
+		}
+	}
+}
+
+// formatValue formats a sample value the way Prometheus parses it
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// seriesKey returns the Prometheus identity of a series, independent of
+// the order labels were given in
+func seriesKey(name string, labels Labels) string {
+	return name + formatLabels(sanitizeLabels(labels), "", "")
+}
+
+// formatLabels renders {k="v",...} sorted by name, with an optional extra
+// label appended; it returns "" without labels
+func formatLabels(labels Labels, extraName, extraValue string) string {
+	if len(labels) == 0 && extraName == "" {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, k := range names {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, "%s=\"%s\"", k, escapeLabelValue(labels[k]))
+	}
+	if extraName != "" {
+		if len(names) > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, "%s=\"%s\"", extraName, extraValue)
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// labelEscaper escapes label values per the exposition format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabelValue escapes backslashes, quotes and newlines
+func escapeLabelValue(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+// sanitizeLabels returns labels with Prometheus-safe names, which unlike
+// metric names may not contain ':'
+func sanitizeLabels(labels Labels) Labels {
+	if len(labels) == 0 {
+		return nil
+	}
+	out := make(Labels, len(labels))
+	for k, v := range labels {
+		out[strings.ReplaceAll(sanitizeMetricName(k), ":", "_")] = v
+	}
+	return out
+}
+
+// sanitizeMetricName replaces characters outside [a-zA-Z0-9_:] with '_'
+// and prefixes a leading digit
+func sanitizeMetricName(name string) string {
+	if name == "" {
+		return "_"
+	}
+	b := []byte(name)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == ':' || c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	if b[0] >= '0' && b[0] <= '9' {
+		return "_" + string(b)
+	}
+	return string(b)
+}
@@ -0,0 +1,55 @@
+//go:build !tinygo
+
+package streamstats
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// ServeHTTP writes every series, Prometheus text format by default or JSON
+// with ?format=json, so scrapers that know nothing of paging see them all.
+// Listing clients may page instead: ?limit sets the page size (0 for all)
+// and ?cursor continues after a previous page; the next cursor is sent in
+// the X-Next-Cursor header and, for JSON, the "next" field. Series are
+// written as they are read, so large registries never build the whole
+// response in memory.
+func (r *StatsRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+	limit := 0
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	series, next := r.page(q.Get("cursor"), limit)
+	if next != "" {
+		w.Header().Set("X-Next-Cursor", next)
+	}
+
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+	if q.Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		writeJSON(bw, series, next)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writePrometheus(bw, series)
+}
+
+// writeJSON writes a page as {"next": cursor, "series": [...]}
+func writeJSON(w io.Writer, series []*registeredStream, next string) {
+	cursor, _ := json.Marshal(next)
+	fmt.Fprintf(w, `{"next":%s,"series":`, cursor)
+	writeJSONSeries(w, series)
+	io.WriteString(w, "}")
+}
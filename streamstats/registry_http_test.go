@@ -0,0 +1,111 @@
+//go:build !tinygo
+
+package streamstats
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRegistryPrometheus(t *testing.T) {
+	r := NewStatsRegistry(Options{Capacity: 100})
+	defer r.Stop()
+
+	lat := r.Get("latency", Labels{"path": `a"b`})
+	for i := 1; i <= 100; i++ {
+		lat.AddNumber(float64(i))
+	}
+	r.Get("bytes", nil).AddNumber(5)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE bytes summary\n",
+		"bytes_count 1\n",
+		"bytes_max 5\n",
+		"# TYPE latency summary\n",
+		`latency{path="a\"b",quantile="0.5"} 50.5` + "\n",
+		`latency{path="a\"b",quantile="0.99"} 99` + "\n",
+		`latency_sum{path="a\"b"} 5050` + "\n",
+		`latency_count{path="a\"b"} 100` + "\n",
+		"# TYPE latency_mean gauge\n",
+		`latency_min{path="a\"b"} 1` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("output lacks %q:\n%s", want, body)
+		}
+	}
+	if strings.Index(body, "bytes") > strings.Index(body, "latency") {
+		t.Error("metric families are not sorted by name")
+	}
+}
+
+func TestRegistryServesAllSeriesByDefault(t *testing.T) {
+	r := NewStatsRegistry(Options{Capacity: 10})
+	defer r.Stop()
+	for i := range 1500 {
+		r.Get("requests", Labels{"id": strconv.Itoa(i)}).AddNumber(1)
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if got := strings.Count(rec.Body.String(), "requests_count{"); got != 1500 {
+		t.Errorf("scrape has %d series, want 1500", got)
+	}
+	if next := rec.Header().Get("X-Next-Cursor"); next != "" {
+		t.Errorf("X-Next-Cursor = %q without ?limit, want none", next)
+	}
+}
+
+func TestRegistryPagination(t *testing.T) {
+	r := NewStatsRegistry(Options{Capacity: 10})
+	defer r.Stop()
+
+	for _, tenant := range []string{"a", "b", "c", "d", "e"} {
+		r.Get("payload", Labels{"tenant": tenant}).AddNumber(1)
+	}
+	r.Get("payload_total", nil).AddNumber(1)
+
+	var names []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("pagination did not terminate")
+		}
+		rec := httptest.NewRecorder()
+		target := "/metrics?format=json&limit=2&cursor=" + url.QueryEscape(cursor)
+		r.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+
+		var page struct {
+			Next   string
+			Series []seriesJSON
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatalf("invalid JSON %q: %v", rec.Body.String(), err)
+		}
+		if got := rec.Header().Get("X-Next-Cursor"); got != page.Next {
+			t.Errorf("X-Next-Cursor = %q, want %q", got, page.Next)
+		}
+		for _, s := range page.Series {
+			names = append(names, s.Name+"/"+s.Labels["tenant"])
+			if s.Stats.Count != 1 {
+				t.Errorf("series %s count = %d, want 1", s.Name, s.Stats.Count)
+			}
+		}
+		if page.Next == "" {
+			break
+		}
+		cursor = page.Next
+	}
+
+	want := "payload/a payload/b payload/c payload/d payload/e payload_total/"
+	if got := strings.Join(names, " "); got != want {
+		t.Errorf("paged series = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,178 @@
+package streamstats
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRegistryGet(t *testing.T) {
+	r := NewStatsRegistry(Options{Capacity: 10})
+	defer r.Stop()
+
+	a := r.Get("http.latency", Labels{"endpoint": "/a", "method": "GET"})
+	if b := r.Get("http.latency", Labels{"method": "GET", "endpoint": "/a"}); b != a {
+		t.Error("Get() with the same labels returned a different stream")
+	}
+	if c := r.Get("http.latency", Labels{"endpoint": "/b", "method": "GET"}); c == a {
+		t.Error("Get() with different labels returned the same stream")
+	}
+	if got, want := a.name, `http_latency{endpoint="/a",method="GET"}`; got != want {
+		t.Errorf("stream name = %q, want %q", got, want)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Get("size", nil).AddNumber(1)
+		}()
+	}
+	wg.Wait()
+	if got := r.Len(); got != 3 {
+		t.Errorf("Len() = %d, want 3", got)
+	}
+	if got := r.Get("size", nil).Count(); got != 8 {
+		t.Errorf("Count() = %d, want 8", got)
+	}
+}
+
+func TestRegistryExpvar(t *testing.T) {
+	r := NewStatsRegistry(Options{Capacity: 10})
+	defer r.Stop()
+	r.Get("x", Labels{"k": "v"}).AddNumber(2)
+
+	var series []seriesJSON
+	if err := json.Unmarshal([]byte(r.String()), &series); err != nil {
+		t.Fatalf("String() is not JSON: %v", err)
+	}
+	if len(series) != 1 || series[0].Stats.Mean != 2 || series[0].Labels["k"] != "v" {
+		t.Errorf("String() = %+v", series)
+	}
+}
+
+func TestRegistryExportsCustomStatistics(t *testing.T) {
+	r := NewStatsRegistry(Options{Capacity: 10})
+	defer r.Stop()
+	a := r.Get("jobs", Labels{"queue": "a"})
+	if err := a.RegisterStatistic(&counterStat{}); err != nil {
+		t.Fatal(err)
+	}
+	a.AddNumber(1)
+	a.AddNumber(2)
+	r.Get("jobs", Labels{"queue": "b"}).AddNumber(3)
+
+	series, _ := r.page("", 0)
+	var sb strings.Builder
+	writePrometheus(&sb, series)
+	body := sb.String()
+	if !strings.Contains(body, "# TYPE jobs_counter gauge\n"+`jobs_counter{queue="a"} 2`+"\n") {
+		t.Errorf("Prometheus output lacks the custom statistic gauge:\n%s", body)
+	}
+	if strings.Contains(body, `jobs_counter{queue="b"}`) {
+		t.Errorf("Prometheus output has a gauge for a series without the statistic:\n%s", body)
+	}
+
+	var js []seriesJSON
+	if err := json.Unmarshal([]byte(r.String()), &js); err != nil {
+		t.Fatal(err)
+	}
+	if len(js) != 2 || js[0].Custom["counter"] != 2 || js[1].Custom != nil {
+		t.Errorf("JSON custom statistics = %+v", js)
+	}
+}
+
+func TestRegistryExportsDerivedFields(t *testing.T) {
+	r := NewStatsRegistry(Options{Capacity: 100})
+	defer r.Stop()
+	ds := r.Get("latency", nil)
+	if err := ds.DefineDerived("spread", "max - min"); err != nil {
+		t.Fatal(err)
+	}
+	ds.AddNumber(10)
+	ds.AddNumber(25)
+
+	series, _ := r.page("", 0)
+	var sb strings.Builder
+	writePrometheus(&sb, series)
+	if want := "# TYPE latency_spread gauge\nlatency_spread 15\n"; !strings.Contains(sb.String(), want) {
+		t.Errorf("Prometheus output lacks %q:\n%s", want, sb.String())
+	}
+
+	var js []seriesJSON
+	if err := json.Unmarshal([]byte(r.String()), &js); err != nil {
+		t.Fatal(err)
+	}
+	if len(js) != 1 || js[0].Derived["spread"] != 15 {
+		t.Errorf("JSON derived fields = %+v", js)
+	}
+}
+
+func TestRegistryExportsExemplars(t *testing.T) {
+	r := NewStatsRegistry(Options{Capacity: 100})
+	defer r.Stop()
+	ds := r.Get("latency", Labels{"path": "/a"})
+	for i := 1; i <= 100; i++ {
+		ds.AddNumber(float64(i))
+	}
+	ds.AddNumberWithExemplar(99, "slow")
+	ds.AddNumberWithExemplar(1, "fast")
+	r.Get("latency", Labels{"path": "/b"}).AddNumber(1)
+
+	series, _ := r.page("", 0)
+	var sb strings.Builder
+	writePrometheus(&sb, series)
+	body := sb.String()
+	if want := "# TYPE latency_exemplar gauge\n" + `latency_exemplar{path="/a",trace_id="slow",quantile="0.99"} 99`; !strings.Contains(body, want) {
+		t.Errorf("Prometheus output lacks %q:\n%s", want, body)
+	}
+	if strings.Contains(body, `latency_exemplar{path="/b"`) {
+		t.Errorf("Prometheus output has an exemplar for a series without any:\n%s", body)
+	}
+
+	var js []seriesJSON
+	if err := json.Unmarshal([]byte(r.String()), &js); err != nil {
+		t.Fatal(err)
+	}
+	if len(js) != 2 || len(js[0].Exemplars) != 2 || js[0].Exemplars[1].TraceID != "slow" || js[1].Exemplars != nil {
+		t.Errorf("JSON exemplars = %+v", js)
+	}
+}
+
+func TestRegistryGauges(t *testing.T) {
+	r := NewStatsRegistry(Options{Capacity: 10})
+	defer r.Stop()
+	g := r.Gauge("queue.depth", Labels{"queue": "a"})
+	if r.Gauge("queue.depth", Labels{"queue": "a"}) != g {
+		t.Error("Gauge() with the same labels returned a different gauge")
+	}
+	g.Set(3)
+	g.Set(7)
+	r.Get("queue_wait", nil).AddNumber(1)
+	if got := r.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+
+	var js []seriesJSON
+	if err := json.Unmarshal([]byte(r.String()), &js); err != nil {
+		t.Fatal(err)
+	}
+	if len(js) != 2 || js[0].Name != "queue_depth" || js[0].Value == nil || *js[0].Value != 7 || js[0].Stats != nil {
+		t.Errorf("JSON gauge = %+v, want queue_depth with value 7 and no stats", js)
+	}
+
+	var sb strings.Builder
+	rr := NewRegistryReporter(r, &sb, "prometheus", 0)
+	if n, err := rr.Report(); n != 2 || err != nil {
+		t.Fatalf("Report() = %d, %v, want 2 series", n, err)
+	}
+	if want := "# TYPE queue_depth gauge\n" + `queue_depth{queue="a"} 7` + "\n"; !strings.Contains(sb.String(), want) {
+		t.Errorf("report lacks %q:\n%s", want, sb.String())
+	}
+	g.Set(8)
+	if n, _ := rr.Report(); n != 1 {
+		t.Errorf("Report() after Set = %d series, want only the gauge", n)
+	}
+}
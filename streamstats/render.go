@@ -0,0 +1,153 @@
+//go:build !tinygo
+
+package streamstats
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"slices"
+	"strings"
+)
+
+// histBin is one bucket of a histogram built from snapshot samples
+type histBin struct {
+	Lo, Hi float64
+	Count  int
+	Width  int // Bar width in percent of the fullest bin
+}
+
+// windowHistogram buckets the finite values into n equal-width bins
+func windowHistogram(values []float64, n int) []histBin {
+	lo, hi, ok := finiteRange(values)
+	if !ok || n <= 0 {
+		return nil
+	}
+	if hi == lo {
+		count := 0
+		for _, v := range values {
+			if v == lo {
+				count++
+			}
+		}
+		return []histBin{{Lo: lo, Hi: hi, Count: count, Width: 100}}
+	}
+
+	step := (hi - lo) / float64(n)
+	bins := make([]histBin, n)
+	for i := range bins {
+		bins[i].Lo = lo + float64(i)*step
+		bins[i].Hi = lo + float64(i+1)*step
+	}
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		bins[min(max(int((v-lo)/step), 0), n-1)].Count++
+	}
+
+	most := 0
+	for _, b := range bins {
+		if b.Count > most {
+			most = b.Count
+		}
+	}
+	for i := range bins {
+		bins[i].Width = bins[i].Count * 100 / most
+	}
+	return bins
+}
+
+// RenderMarkdown writes a Markdown report with a summary table and,
+// per snapshot, a histogram and trend line of its recent samples
+func RenderMarkdown(w io.Writer, snaps ...Snapshot) error {
+	var sb strings.Builder
+
+	sb.WriteString("# Stream statistics\n\n")
+	sb.WriteString("| Stream | Count | Mean | Median | Min | Max | Window | Window p50 | Window p95 | Window p99 |\n")
+	sb.WriteString("|---|---:|---:|---:|---:|---:|---:|---:|---:|---:|\n")
+	for _, s := range snaps {
+		l, w := s.Lifetime, s.Window
+		fmt.Fprintf(&sb, "| %s | %d | %.2f | %.2f | %.2f | %.2f | %d | %.2f | %.2f | %.2f |\n",
+			s.Name, l.Count, l.Mean, l.Median, l.Min, l.Max, w.Count, w.P50, w.P95, w.P99)
+	}
+
+	for _, s := range snaps {
+		fmt.Fprintf(&sb, "\n## %s\n\n", s.Name)
+		fmt.Fprintf(&sb, "Trend: `%s`\n\n", sparkline(s.Window.Samples))
+		sb.WriteString("```\n")
+		for _, b := range windowHistogram(s.Window.Samples, 10) {
+			fmt.Fprintf(&sb, "%10.2f - %-10.2f %6d %s\n", b.Lo, b.Hi, b.Count, strings.Repeat("#", b.Width/5))
+		}
+		sb.WriteString("```\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// htmlReport is the standalone HTML report layout
+var htmlReport = template.Must(template.New("html").Funcs(template.FuncMap{
+	"histogram": func(values []float64) []histBin { return windowHistogram(values, 10) },
+	"trend":     trendPoints,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Stream statistics</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.bar { background: #4a90d9; height: 12px; }
+</style>
+</head>
+<body>
+<h1>Stream statistics</h1>
+<table>
+<tr><th>Stream</th><th>Count</th><th>Mean</th><th>Median</th><th>Min</th><th>Max</th><th>Window</th><th>Window p50</th><th>Window p95</th><th>Window p99</th></tr>
+{{range .}}<tr><td>{{.Name}}</td>{{with .Lifetime}}<td>{{.Count}}</td><td>{{printf "%.2f" .Mean}}</td><td>{{printf "%.2f" .Median}}</td><td>{{printf "%.2f" .Min}}</td><td>{{printf "%.2f" .Max}}</td>{{end}}{{with .Window}}<td>{{.Count}}</td><td>{{printf "%.2f" .P50}}</td><td>{{printf "%.2f" .P95}}</td><td>{{printf "%.2f" .P99}}</td>{{end}}</tr>
+{{end}}</table>
+{{range .}}
+<h2>{{.Name}}</h2>
+<svg width="400" height="60" viewBox="0 0 400 60"><polyline fill="none" stroke="#4a90d9" points="{{trend .Window.Samples 400 60}}"/></svg>
+<table>
+{{range histogram .Window.Samples}}<tr><td>{{printf "%.2f" .Lo}} - {{printf "%.2f" .Hi}}</td><td>{{.Count}}</td><td style="width:300px"><div class="bar" style="width:{{.Width}}%"></div></td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
+
+// RenderHTML writes a standalone HTML report for the given snapshots
+func RenderHTML(w io.Writer, snaps ...Snapshot) error {
+	return htmlReport.Execute(w, snaps)
+}
+
+// trendPoints scales the finite values into SVG polyline points for a
+// w x h box
+func trendPoints(values []float64, w, h int) string {
+	lo, hi, ok := finiteRange(values)
+	if !ok {
+		return ""
+	}
+	values = slices.DeleteFunc(slices.Clone(values), func(v float64) bool {
+		return math.IsNaN(v) || math.IsInf(v, 0)
+	})
+
+	var sb strings.Builder
+	for i, v := range values {
+		x := 0.0
+		if len(values) > 1 {
+			x = float64(i) * float64(w) / float64(len(values)-1)
+		}
+		y := float64(h) / 2
+		if hi > lo {
+			y = float64(h) - (v-lo)/(hi-lo)*float64(h)
+		}
+		fmt.Fprintf(&sb, "%.1f,%.1f ", x, y)
+	}
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,84 @@
+//go:build !tinygo
+
+package streamstats
+
+import (
+	"io"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// templateOps are the stress test operations that render templates, which
+// TinyGo builds leave out
+func templateOps(ds, child *DataStreamStats) []func(*rand.Rand) {
+	return []func(*rand.Rand){
+		func(r *rand.Rand) { ds.Report(io.Discard, "{{.Lifetime.Count}} {{.Custom}} {{.Derived}}") },
+		func(r *rand.Rand) { RenderMarkdown(io.Discard, ds.Snapshot(), child.Snapshot()) },
+	}
+}
+
+func TestRenderMarkdownAndHTML(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Name: "latency", Capacity: 100})
+	defer ds.Stop()
+	for i := 1; i <= 20; i++ {
+		ds.AddNumber(float64(i))
+	}
+	snap := ds.Snapshot()
+
+	var md strings.Builder
+	if err := RenderMarkdown(&md, snap); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"| latency | 20 | 10.50 |", "## latency", "Trend: `", "1.00 - 2.90"} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("RenderMarkdown() lacks %q:\n%s", want, md.String())
+		}
+	}
+
+	var html strings.Builder
+	if err := RenderHTML(&html, snap); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<td>latency</td>", "<polyline", "<h2>latency</h2>"} {
+		if !strings.Contains(html.String(), want) {
+			t.Errorf("RenderHTML() lacks %q", want)
+		}
+	}
+}
+
+func TestRenderNonFinite(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Name: "inf", Capacity: 10})
+	defer ds.Stop()
+	for _, v := range []float64{1, math.Inf(1), 5, math.Inf(-1), 9} {
+		ds.AddNumber(v)
+	}
+	snap := ds.Snapshot()
+
+	var sb strings.Builder
+	if err := RenderMarkdown(&sb, snap); err != nil {
+		t.Fatal(err)
+	}
+	if err := RenderHTML(&sb, snap); err != nil {
+		t.Fatal(err)
+	}
+
+	values := []float64{1, math.NaN(), 5, math.Inf(1), 9, math.Inf(-1)}
+	total := 0
+	for _, b := range windowHistogram(values, 4) {
+		total += b.Count
+	}
+	if total != 3 {
+		t.Errorf("windowHistogram() counted %d values, want the 3 finite ones", total)
+	}
+	if got := windowHistogram([]float64{math.NaN(), math.Inf(1)}, 4); got != nil {
+		t.Errorf("windowHistogram() of non-finite values = %v, want nil", got)
+	}
+	if got := windowHistogram([]float64{3, math.Inf(1), 3}, 4); len(got) != 1 || got[0].Count != 2 {
+		t.Errorf("windowHistogram() of one finite value = %v, want one bin of 2", got)
+	}
+	if got, want := trendPoints(values, 10, 10), "0.0,10.0 5.0,5.0 10.0,0.0"; got != want {
+		t.Errorf("trendPoints() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build tinygo
+
+package streamstats
+
+import "math/rand"
+
+// templateOps is empty, TinyGo builds have no template rendering
+func templateOps(ds, child *DataStreamStats) []func(*rand.Rand) { return nil }
@@ -0,0 +1,85 @@
+//go:build !tinygo
+
+package streamstats
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Report renders tmpl with text/template against the current statistics.
+// Templates see a Snapshot and can call:
+//
+//	percentile P      the Pth percentile of the stream
+//	duration V UNIT   V interpreted in UNIT ("ns", "us", "ms", "s") as a time.Duration
+//	sparkline VALUES  a unicode sparkline of a []float64, e.g. .Window.Samples
+func (ds *DataStreamStats) Report(w io.Writer, tmpl string) error {
+	ds.lazyInit()
+	t, err := template.New("report").Funcs(template.FuncMap{
+		"percentile": ds.GetPercentile,
+		"duration":   formatDuration,
+		"sparkline":  sparkline,
+	}).Parse(tmpl)
+	if err != nil {
+		return err
+	}
+	return t.Execute(w, ds.Snapshot())
+}
+
+// formatDuration renders v, expressed in unit, as a time.Duration
+func formatDuration(v float64, unit string) (string, error) {
+	scale := map[string]time.Duration{
+		"ns": time.Nanosecond,
+		"us": time.Microsecond,
+		"ms": time.Millisecond,
+		"s":  time.Second,
+	}
+	d, ok := scale[unit]
+	if !ok {
+		return "", fmt.Errorf("unknown duration unit %q", unit)
+	}
+	return time.Duration(v * float64(d)).String(), nil
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders values as a row of unicode block characters;
+// non-finite values are left out
+func sparkline(values []float64) string {
+	lo, hi, ok := finiteRange(values)
+	if !ok {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		idx := 0
+		if hi > lo {
+			idx = int((v - lo) / (hi - lo) * float64(len(sparkBlocks)-1))
+		}
+		sb.WriteRune(sparkBlocks[min(max(idx, 0), len(sparkBlocks)-1)])
+	}
+	return sb.String()
+}
+
+// finiteRange returns the minimum and maximum of the finite values; ok is
+// false when there are none
+func finiteRange(values []float64) (lo, hi float64, ok bool) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+		ok = true
+	}
+	return lo, hi, ok
+}
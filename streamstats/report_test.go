@@ -0,0 +1,67 @@
+//go:build !tinygo
+
+package streamstats
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+	for i := 1; i <= 8; i++ {
+		ds.AddNumber(float64(i))
+	}
+
+	var sb strings.Builder
+	tmpl := `{{.Lifetime.Count}} {{percentile 50}} {{duration 1.5 "s"}} {{sparkline .Window.Samples}}`
+	if err := ds.Report(&sb, tmpl); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sb.String(), "8 4 1.5s ▁▂▃▄▅▆▇█"; got != want {
+		t.Errorf("Report() = %q, want %q", got, want)
+	}
+
+	if err := ds.Report(&sb, `{{duration 1 "weeks"}}`); err == nil {
+		t.Error("Report() with an unknown duration unit succeeded")
+	}
+	if err := ds.Report(&sb, `{{.Missing`); err == nil {
+		t.Error("Report() with a malformed template succeeded")
+	}
+}
+
+func TestReportNonFinite(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+	for _, v := range []float64{1, math.Inf(1), 2, math.Inf(-1), 3} {
+		ds.AddNumber(v)
+	}
+
+	var sb strings.Builder
+	if err := ds.Report(&sb, `{{sparkline .Window.Samples}}`); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sb.String(), "▁▄█"; got != want {
+		t.Errorf("sparkline with ±Inf = %q, want %q", got, want)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   string
+	}{
+		{nil, ""},
+		{[]float64{5, 5}, "▁▁"},
+		{[]float64{0, 7}, "▁█"},
+		{[]float64{math.NaN(), math.Inf(1)}, ""},
+		{[]float64{0, math.NaN(), 7}, "▁█"},
+	}
+	for _, tt := range tests {
+		if got := sparkline(tt.values); got != tt.want {
+			t.Errorf("sparkline(%v) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
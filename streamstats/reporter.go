@@ -0,0 +1,113 @@
+package streamstats
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"time"
+)
+
+// Generation returns a counter that advances whenever the stream accepts
+// samples, merges another stream or restores a checkpoint. A stream whose
+// generation is unchanged has nothing new to export.
+func (ds *DataStreamStats) Generation() uint64 {
+	ds.lazyInit()
+	return ds.generation.Load()
+}
+
+// RegistryReporter periodically exports the series of a registry that
+// changed since its previous report, so sparse keyed workloads where most
+// series are idle only export the few that received samples
+type RegistryReporter struct {
+	reg      *StatsRegistry
+	w        io.Writer
+	json     bool
+	mu       sync.Mutex
+	reported map[string]uint64 // Generation of each series at its last report
+	err      error             // Last periodic report error
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewRegistryReporter writes reg's changed series to w every interval until
+// Stop, or only on Report when interval is 0. Series are written in
+// Prometheus text format, or as a JSON array when format is "json".
+func NewRegistryReporter(reg *StatsRegistry, w io.Writer, format string, interval time.Duration) *RegistryReporter {
+	rr := &RegistryReporter{
+		reg:      reg,
+		w:        w,
+		json:     format == "json",
+		reported: make(map[string]uint64),
+		stopChan: make(chan struct{}),
+	}
+	if interval > 0 {
+		go rr.worker(interval)
+	}
+	return rr
+}
+
+// worker reports every interval until Stop
+func (rr *RegistryReporter) worker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := rr.Report(); err != nil {
+				rr.mu.Lock()
+				rr.err = err
+				rr.mu.Unlock()
+			}
+		case <-rr.stopChan:
+			return
+		}
+	}
+}
+
+// Report writes the series that changed since the previous report, or
+// every series with samples on the first one, and returns how many it
+// wrote. Series are marked reported only once the write succeeded.
+func (rr *RegistryReporter) Report() (int, error) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	all, _ := rr.reg.page("", 0)
+	changed := all[:0]
+	gens := make([]uint64, 0, len(all))
+	for _, rs := range all {
+		if gen := rs.generation(); gen != rr.reported[rs.key] {
+			changed = append(changed, rs)
+			gens = append(gens, gen)
+		}
+	}
+	if len(changed) == 0 {
+		return 0, nil
+	}
+
+	bw := bufio.NewWriter(rr.w)
+	if rr.json {
+		writeJSONSeries(bw, changed)
+		io.WriteString(bw, "\n")
+	} else {
+		writePrometheus(bw, changed)
+	}
+	if err := bw.Flush(); err != nil {
+		return 0, err
+	}
+	for i, rs := range changed {
+		rr.reported[rs.key] = gens[i]
+	}
+	return len(changed), nil
+}
+
+// Err returns the error of the last failed periodic report, or nil
+func (rr *RegistryReporter) Err() error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	return rr.err
+}
+
+// Stop stops periodic reporting; the registry keeps running
+func (rr *RegistryReporter) Stop() {
+	rr.stopOnce.Do(func() { close(rr.stopChan) })
+}
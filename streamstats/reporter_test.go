@@ -0,0 +1,127 @@
+package streamstats
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRegistryReporterChangedOnly(t *testing.T) {
+	r := NewStatsRegistry(Options{Capacity: 10})
+	defer r.Stop()
+	a := r.Get("latency", Labels{"tenant": "a"})
+	b := r.Get("latency", Labels{"tenant": "b"})
+	r.Get("latency", Labels{"tenant": "idle"})
+
+	var buf bytes.Buffer
+	rr := NewRegistryReporter(r, &buf, "json", 0)
+	defer rr.Stop()
+
+	a.AddNumber(1)
+	b.AddNumber(2)
+	if n, err := rr.Report(); err != nil || n != 2 {
+		t.Fatalf("first Report() = %d, %v, want 2 series", n, err)
+	}
+
+	buf.Reset()
+	if n, _ := rr.Report(); n != 0 || buf.Len() != 0 {
+		t.Errorf("Report() without samples wrote %d series: %q", n, buf.String())
+	}
+
+	b.AddNumber(3)
+	if n, _ := rr.Report(); n != 1 {
+		t.Fatalf("Report() = %d series, want 1", n)
+	}
+	var series []seriesJSON
+	if err := json.Unmarshal(buf.Bytes(), &series); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	if series[0].Labels["tenant"] != "b" || series[0].Stats.Count != 2 {
+		t.Errorf("reported %+v, want tenant b with 2 samples", series[0])
+	}
+}
+
+func TestRegistryReporterPrometheus(t *testing.T) {
+	r := NewStatsRegistry(Options{Capacity: 10})
+	defer r.Stop()
+	r.Get("bytes", nil).AddNumber(5)
+	r.Get("idle", nil)
+
+	var sb strings.Builder
+	rr := NewRegistryReporter(r, &sb, "", 0)
+	defer rr.Stop()
+	rr.Report()
+	if body := sb.String(); !strings.Contains(body, "bytes_count 1\n") || strings.Contains(body, "idle") {
+		t.Errorf("Report() wrote %q, want only bytes", body)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("push failed") }
+
+func TestRegistryReporterRetriesFailed(t *testing.T) {
+	r := NewStatsRegistry(Options{Capacity: 10})
+	defer r.Stop()
+	r.Get("bytes", nil).AddNumber(5)
+
+	rr := NewRegistryReporter(r, failingWriter{}, "", 0)
+	defer rr.Stop()
+	if _, err := rr.Report(); err == nil {
+		t.Fatal("Report() to a failing writer returned no error")
+	}
+
+	var sb strings.Builder
+	rr.w = &sb
+	if n, err := rr.Report(); err != nil || n != 1 {
+		t.Errorf("Report() after a failure = %d, %v, want the series again", n, err)
+	}
+}
+
+func TestRegistryReporterSmoothed(t *testing.T) {
+	r := NewStatsRegistry(Options{Capacity: 10, PublishEvery: 10, Smoothing: 0.5})
+	defer r.Stop()
+	ds := r.Get("latency", nil)
+	r.Get("other", nil).AddNumber(1)
+	for n, v := range []float64{100, 300} {
+		for i := 0; i < 10; i++ {
+			ds.AddNumber(v)
+		}
+		waitPublished(t, ds, int64(10*(n+1)))
+	}
+
+	var sb strings.Builder
+	rr := NewRegistryReporter(r, &sb, "", 0)
+	defer rr.Stop()
+	rr.Report()
+	body := sb.String()
+	for _, want := range []string{
+		"# TYPE latency_window gauge\n" + `latency_window{quantile="0.5"} 300`,
+		"# TYPE latency_smoothed gauge\n" + `latency_smoothed{quantile="0.5"} 200`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Report() lacks %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "other_smoothed") {
+		t.Errorf("Report() has smoothed values for an unpublished series:\n%s", body)
+	}
+
+	var buf bytes.Buffer
+	rr = NewRegistryReporter(r, &buf, "json", 0)
+	defer rr.Stop()
+	rr.Report()
+	var series []seriesJSON
+	if err := json.Unmarshal(buf.Bytes(), &series); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	sm := series[0].Smoothed
+	if sm == nil || sm.Raw.P99 != 300 || sm.Smoothed.P99 != 200 || sm.Smoothed.Updates != 2 || series[0].Stats.Max != 300 {
+		t.Errorf("JSON smoothed = %+v, want raw 300 and smoothed 200", sm)
+	}
+	if series[1].Smoothed != nil {
+		t.Errorf("JSON has smoothed values for %q", series[1].Name)
+	}
+}
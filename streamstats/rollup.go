@@ -0,0 +1,135 @@
+package streamstats
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// Rollup aggregates the samples of one period, such as an epoch, so that
+// periods can be combined into coarser ones (minutes into hours). It keeps
+// sums and counts rather than a mean, and a digest rather than percentile
+// values, because averaging means or percentiles of periods with unequal
+// counts gives wrong results. Combine rollups with MergeRollups.
+type Rollup struct {
+	Label string
+	Start time.Time
+	End   time.Time // Zero while the period is open
+	Count int64
+	Sum   float64
+	Min   float64
+	Max   float64
+
+	// Annotations made during the period, see Annotate
+	Annotations []Annotation
+
+	digest *tdigest // Compressed, read-only once the rollup is built
+}
+
+// Mean returns the count-weighted mean of the period
+func (r Rollup) Mean() float64 {
+	if r.Count == 0 {
+		return 0
+	}
+	return r.Sum / float64(r.Count)
+}
+
+// Percentile returns the approximate pth percentile of the period
+func (r Rollup) Percentile(p float64) float64 {
+	if r.digest == nil {
+		return 0
+	}
+	return r.digest.quantile(p)
+}
+
+// centroids returns the weighted stand-ins for the samples of the period
+// in ascending order
+func (r Rollup) centroids() []centroid {
+	if r.digest == nil {
+		return nil
+	}
+	r.digest.compress()
+	return r.digest.centroids
+}
+
+// MergeRollups combines periods into one, adding counts and sums and
+// merging digests so every sample keeps its weight
+func MergeRollups(rs ...Rollup) Rollup {
+	out := Rollup{Min: math.Inf(1), Max: math.Inf(-1), digest: newTDigest(lifetimeCompression)}
+	open := false
+	for _, r := range rs {
+		if r.Count == 0 {
+			continue
+		}
+		if out.Start.IsZero() || r.Start.Before(out.Start) {
+			out.Start = r.Start
+		}
+		open = open || r.End.IsZero()
+		if r.End.After(out.End) {
+			out.End = r.End
+		}
+		out.Count += r.Count
+		out.Sum += r.Sum
+		out.Min = math.Min(out.Min, r.Min)
+		out.Max = math.Max(out.Max, r.Max)
+		out.digest.merge(r.digest)
+		out.Annotations = append(out.Annotations, r.Annotations...)
+	}
+	if open {
+		out.End = time.Time{}
+	}
+	if out.Count == 0 {
+		out.Min, out.Max = 0, 0
+	}
+	out.digest.compress()
+	slices.SortStableFunc(out.Annotations, func(a, b Annotation) int { return a.Time.Compare(b.Time) })
+	return out
+}
+
+// Rollups returns a rollup per epoch, closed epochs first, see SetEpoch.
+// The samples added before the first epoch lead as an unlabeled rollup,
+// which stays open and covers every sample while no epoch was set.
+func (ds *DataStreamStats) Rollups() []Rollup {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	ds.epochLock.Lock()
+	defer ds.epochLock.Unlock()
+
+	var out []Rollup
+	switch {
+	case len(ds.closedDigests) == 0 && ds.count > 0:
+		out = append(out, newRollup("", ds.firstSample, time.Time{}, ds.count, ds.totalSum, ds.minVal, ds.maxVal, ds.epochDigest))
+	case ds.preEpoch.Count > 0:
+		pre := ds.preEpoch
+		out = append(out, newRollup("", pre.Start, pre.End, pre.Count, pre.Sum, pre.Min, pre.Max, ds.closedDigests[0]))
+	}
+	for i, ep := range ds.closedEpochs {
+		s := ep.Summary
+		out = append(out, newRollup(ep.Label, ep.Started, ep.Ended, s.Count, s.Sum, s.Min, s.Max, ds.closedDigests[i+1]))
+	}
+	if ds.epoch != nil {
+		ep := ds.epoch.view()
+		l := ep.Snapshot.Lifetime
+		out = append(out, newRollup(ep.Label, ep.Started, time.Time{}, l.Count, l.Sum, l.Min, l.Max, ds.epochDigest))
+	}
+	ds.annotateRollups(out)
+	return out
+}
+
+// newRollup builds a rollup with a private, compressed copy of td
+func newRollup(label string, start, end time.Time, count int64, sum, lo, hi float64, td *tdigest) Rollup {
+	digest := newTDigest(td.compression)
+	digest.merge(td)
+	digest.compress()
+	return Rollup{
+		Label:  label,
+		Start:  start,
+		End:    end,
+		Count:  count,
+		Sum:    sum,
+		Min:    lo,
+		Max:    hi,
+		digest: digest,
+	}
+}
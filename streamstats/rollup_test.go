@@ -0,0 +1,94 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMergeRollups(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := t0
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Now: func() time.Time { return now }})
+	defer ds.Stop()
+
+	// A quiet minute of 10 slow samples and a busy one of 990 fast ones
+	ds.SetEpoch("00:00")
+	for i := 0; i < 10; i++ {
+		ds.AddNumber(100)
+	}
+	now = t0.Add(time.Minute)
+	ds.SetEpoch("00:01")
+	for i := 0; i < 990; i++ {
+		ds.AddNumber(1)
+	}
+	now = t0.Add(2 * time.Minute)
+
+	rollups := ds.Rollups()
+	if len(rollups) != 2 || rollups[0].Label != "00:00" || rollups[1].Label != "00:01" {
+		t.Fatalf("Rollups() = %+v, want the two minutes", rollups)
+	}
+	if r := rollups[0]; r.Count != 10 || r.Mean() != 100 || !r.Start.Equal(t0) || !r.End.Equal(t0.Add(time.Minute)) {
+		t.Errorf("first minute = %+v, want 10 samples of 100 from %v to %v", r, t0, t0.Add(time.Minute))
+	}
+	if !rollups[1].End.IsZero() {
+		t.Errorf("open epoch ends at %v, want zero", rollups[1].End)
+	}
+
+	hour := MergeRollups(rollups...)
+	if hour.Count != 1000 || hour.Min != 1 || hour.Max != 100 {
+		t.Errorf("merged count %d, min %v, max %v; want 1000, 1, 100", hour.Count, hour.Min, hour.Max)
+	}
+	// Averaging the two means would give 50.5
+	if want := (10*100 + 990*1) / 1000.0; math.Abs(hour.Mean()-want) > 1e-9 {
+		t.Errorf("merged Mean() = %v, want the weighted %v", hour.Mean(), want)
+	}
+	if got := hour.Percentile(95); got != 1 {
+		t.Errorf("merged p95 = %v, want 1 as 99%% of samples are 1", got)
+	}
+	if !hour.Start.Equal(t0) || !hour.End.IsZero() {
+		t.Errorf("merged period %v to %v, want an open period from %v", hour.Start, hour.End, t0)
+	}
+
+	empty := MergeRollups(Rollup{})
+	if empty.Count != 0 || empty.Min != 0 || empty.Max != 0 || empty.Mean() != 0 {
+		t.Errorf("MergeRollups(empty) = %+v, want zeros", empty)
+	}
+	if got := (Rollup{}).Percentile(50); got != 0 {
+		t.Errorf("Percentile of an empty rollup = %v, want 0", got)
+	}
+}
+
+func TestRollupsBeforeFirstEpoch(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := t0
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Now: func() time.Time { return now }})
+	defer ds.Stop()
+	if rs := ds.Rollups(); len(rs) != 0 {
+		t.Errorf("Rollups() of an empty stream = %+v, want none", rs)
+	}
+
+	// Without epochs one open rollup covers the stream
+	for i := 1; i <= 100; i++ {
+		ds.AddNumber(float64(i))
+	}
+	rs := ds.Rollups()
+	if len(rs) != 1 || rs[0].Label != "" || rs[0].Count != 100 || !rs[0].Start.Equal(t0) || !rs[0].End.IsZero() {
+		t.Fatalf("Rollups() without epochs = %+v, want one open rollup of 100 samples", rs)
+	}
+
+	// The first epoch closes the leading rollup
+	now = t0.Add(time.Minute)
+	ds.SetEpoch("v1")
+	ds.AddNumber(1000)
+	rs = ds.Rollups()
+	if len(rs) != 2 {
+		t.Fatalf("Rollups() after SetEpoch = %+v, want 2", rs)
+	}
+	if r := rs[0]; r.Label != "" || r.Count != 100 || r.Sum != 5050 || r.Min != 1 || r.Max != 100 || !r.End.Equal(now) {
+		t.Errorf("leading rollup = %+v, want the 100 samples before v1 ending at %v", r, now)
+	}
+	if got := MergeRollups(rs...).Percentile(50); math.Abs(got-51) > 1 {
+		t.Errorf("merged p50 = %v, want about 51", got)
+	}
+}
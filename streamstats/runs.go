@@ -0,0 +1,50 @@
+package streamstats
+
+// RunStats describes runs and drawdowns of the window samples in order
+type RunStats struct {
+	LongestIncreasing int     // Longest run of strictly increasing samples
+	LongestDecreasing int     // Longest run of strictly decreasing samples
+	MaxDrawdown       float64 // Largest drop from a running peak to a later sample
+	MaxRunUp          float64 // Largest rise from a running trough to a later sample
+}
+
+// GetRunStats computes run lengths and the maximum drawdown and run-up
+// over the window, useful for financial and queue-depth streams
+func (ds *DataStreamStats) GetRunStats() RunStats {
+	ds.lazyInit()
+	return runStats(ds.windowValues())
+}
+
+// runStats scans values oldest first
+func runStats(values []float64) RunStats {
+	var rs RunStats
+	if len(values) == 0 {
+		return rs
+	}
+
+	inc, dec := 1, 1
+	rs.LongestIncreasing, rs.LongestDecreasing = 1, 1
+	peak, trough := values[0], values[0]
+	for i := 1; i < len(values); i++ {
+		v, prev := values[i], values[i-1]
+
+		switch {
+		case v > prev:
+			inc++
+			dec = 1
+		case v < prev:
+			dec++
+			inc = 1
+		default:
+			inc, dec = 1, 1
+		}
+		rs.LongestIncreasing = max(rs.LongestIncreasing, inc)
+		rs.LongestDecreasing = max(rs.LongestDecreasing, dec)
+
+		peak = max(peak, v)
+		trough = min(trough, v)
+		rs.MaxDrawdown = max(rs.MaxDrawdown, peak-v)
+		rs.MaxRunUp = max(rs.MaxRunUp, v-trough)
+	}
+	return rs
+}
@@ -0,0 +1,34 @@
+package streamstats
+
+import "testing"
+
+func TestRunStats(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   RunStats
+	}{
+		{"empty", nil, RunStats{}},
+		{"single", []float64{5}, RunStats{LongestIncreasing: 1, LongestDecreasing: 1}},
+		{"flat", []float64{3, 3, 3}, RunStats{LongestIncreasing: 1, LongestDecreasing: 1}},
+		{"peak then crash", []float64{1, 2, 3, 10, 4, 2, 6},
+			RunStats{LongestIncreasing: 4, LongestDecreasing: 3, MaxDrawdown: 8, MaxRunUp: 9}},
+		{"trough then rally", []float64{9, 5, 1, 4, 8, 3},
+			RunStats{LongestIncreasing: 3, LongestDecreasing: 3, MaxDrawdown: 8, MaxRunUp: 7}},
+	}
+	for _, tt := range tests {
+		if got := runStats(tt.values); got != tt.want {
+			t.Errorf("%s: runStats(%v) = %+v, want %+v", tt.name, tt.values, got, tt.want)
+		}
+	}
+
+	// GetRunStats only sees the window
+	ds := NewDataStreamStats(3)
+	defer ds.Stop()
+	for _, v := range []float64{100, 1, 2, 3} {
+		ds.AddNumber(v)
+	}
+	if got := ds.GetRunStats(); got.LongestIncreasing != 3 || got.MaxDrawdown != 0 {
+		t.Errorf("GetRunStats() = %+v, want the increasing window 1, 2, 3", got)
+	}
+}
@@ -0,0 +1,125 @@
+//go:build !tinygo
+
+package streamstats
+
+import (
+	"math"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+// RuntimeMetrics maps the runtime/metrics collected by a RuntimeCollector
+// to registry metric names. Gauges record one sample per collection;
+// histograms record each new observation at its bucket midpoint.
+var RuntimeMetrics = map[string]string{
+	"/sched/pauses/total/gc:seconds":     "go_gc_pause_seconds",
+	"/memory/classes/heap/objects:bytes": "go_heap_objects_bytes",
+	"/gc/heap/goal:bytes":                "go_gc_heap_goal_bytes",
+	"/sched/goroutines:goroutines":       "go_goroutines",
+}
+
+// RuntimeCollector periodically reads Go runtime metrics (GC pauses, heap,
+// goroutines) into streams of a StatsRegistry, so they are summarized and
+// exported alongside application metrics
+type RuntimeCollector struct {
+	mu       sync.Mutex
+	reg      *StatsRegistry
+	samples  []metrics.Sample
+	prev     map[string][]uint64 // Histogram counts at the previous collection
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewRuntimeCollector collects RuntimeMetrics into reg every interval, or
+// only on Collect when interval is 0. Metrics the running Go version does
+// not support are skipped.
+func NewRuntimeCollector(reg *StatsRegistry, interval time.Duration) *RuntimeCollector {
+	supported := make(map[string]bool)
+	for _, d := range metrics.All() {
+		supported[d.Name] = true
+	}
+	rc := &RuntimeCollector{
+		reg:      reg,
+		prev:     make(map[string][]uint64),
+		stopChan: make(chan struct{}),
+	}
+	for name := range RuntimeMetrics {
+		if supported[name] {
+			rc.samples = append(rc.samples, metrics.Sample{Name: name})
+		}
+	}
+	if interval > 0 {
+		go rc.worker(interval)
+	}
+	return rc
+}
+
+// worker collects every interval until Stop
+func (rc *RuntimeCollector) worker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rc.Collect()
+		case <-rc.stopChan:
+			return
+		}
+	}
+}
+
+// Collect reads the runtime metrics once
+func (rc *RuntimeCollector) Collect() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	metrics.Read(rc.samples)
+	for _, s := range rc.samples {
+		ds := rc.reg.Get(RuntimeMetrics[s.Name], nil)
+		switch s.Value.Kind() {
+		case metrics.KindUint64:
+			ds.AddNumber(float64(s.Value.Uint64()))
+		case metrics.KindFloat64:
+			ds.AddNumber(s.Value.Float64())
+		case metrics.KindFloat64Histogram:
+			rc.addHistogram(ds, s.Name, s.Value.Float64Histogram())
+		}
+	}
+}
+
+// addHistogram records the observations added to h since the previous
+// collection at their bucket midpoints
+func (rc *RuntimeCollector) addHistogram(ds *DataStreamStats, name string, h *metrics.Float64Histogram) {
+	prev := rc.prev[name]
+	for i, n := range h.Counts {
+		if i < len(prev) {
+			n -= prev[i]
+		}
+		if n == 0 {
+			continue
+		}
+		v := bucketMidpoint(h.Buckets[i], h.Buckets[i+1])
+		for ; n > 0; n-- {
+			ds.AddNumber(v)
+		}
+	}
+	rc.prev[name] = append(prev[:0], h.Counts...)
+}
+
+// bucketMidpoint returns the middle of [lo, hi), or its finite bound when
+// the bucket is open-ended
+func bucketMidpoint(lo, hi float64) float64 {
+	switch {
+	case math.IsInf(lo, -1):
+		return hi
+	case math.IsInf(hi, 1):
+		return lo
+	}
+	return (lo + hi) / 2
+}
+
+// Stop stops periodic collection; the registry's streams keep running
+func (rc *RuntimeCollector) Stop() {
+	rc.stopOnce.Do(func() { close(rc.stopChan) })
+}
@@ -0,0 +1,48 @@
+//go:build !tinygo
+
+package streamstats
+
+import (
+	"math"
+	"runtime"
+	"testing"
+)
+
+func TestRuntimeCollector(t *testing.T) {
+	reg := NewStatsRegistry(Options{Capacity: 100})
+	defer reg.Stop()
+	rc := NewRuntimeCollector(reg, 0)
+	defer rc.Stop()
+
+	rc.Collect()
+	runtime.GC()
+	runtime.GC()
+	rc.Collect()
+
+	if got := reg.Get("go_goroutines", nil).Count(); got != 2 {
+		t.Errorf("go_goroutines count = %d, want 2", got)
+	}
+	if got := reg.Get("go_heap_objects_bytes", nil).GetMin(); got <= 0 {
+		t.Errorf("go_heap_objects_bytes min = %v, want > 0", got)
+	}
+	pauses := reg.Get("go_gc_pause_seconds", nil)
+	if got := pauses.Count(); got < 2 {
+		t.Errorf("go_gc_pause_seconds count = %d, want at least 2 after two GCs", got)
+	}
+	if got := pauses.GetMax(); got <= 0 || got > 1 {
+		t.Errorf("go_gc_pause_seconds max = %v, want a pause in (0, 1]", got)
+	}
+}
+
+func TestBucketMidpoint(t *testing.T) {
+	inf := math.Inf(1)
+	for _, c := range []struct{ lo, hi, want float64 }{
+		{1, 3, 2},
+		{-inf, 5, 5},
+		{7, inf, 7},
+	} {
+		if got := bucketMidpoint(c.lo, c.hi); got != c.want {
+			t.Errorf("bucketMidpoint(%v, %v) = %v, want %v", c.lo, c.hi, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package streamstats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ReadSamples reads one column (0-based) of numbers from CSV or
+// whitespace-separated text. Blank lines and lines starting with '#' are
+// skipped, as is a non-numeric first line (a header). NaN and infinite
+// values are rejected.
+func ReadSamples(r io.Reader, column int) ([]float64, error) {
+	var out []float64
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(text, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == ';'
+		})
+		if column >= len(fields) {
+			return nil, fmt.Errorf("line %d: no column %d", line, column)
+		}
+		v, err := strconv.ParseFloat(fields[column], 64)
+		if err != nil {
+			if len(out) == 0 && line == 1 {
+				continue // Header
+			}
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("line %d: non-finite value %q", line, fields[column])
+		}
+		out = append(out, v)
+	}
+	return out, sc.Err()
+}
+
+// SampleSummary describes one dataset in a SampleComparison
+type SampleSummary struct {
+	Count  int
+	Mean   float64
+	StdDev float64
+	P50    float64
+	P95    float64
+	P99    float64
+}
+
+// SampleComparison is the result of comparing two datasets, e.g. a
+// baseline and a canary
+type SampleComparison struct {
+	A, B         SampleSummary
+	WelchP       float64 // Welch's t-test p-value for the means
+	MannWhitneyP float64 // Mann-Whitney U p-value for the distributions
+	KS           float64 // Kolmogorov-Smirnov distance
+	KSP          float64 // Asymptotic KS p-value
+	CohensD      float64 // Standardized mean difference, B - A
+	CliffsDelta  float64 // P(B > A) - P(B < A), -1..1
+	Verdict      string
+}
+
+// CompareSamples summarizes two datasets and runs the two-sample tests.
+// NaN samples are ignored. With fewer than 2 samples on either side the
+// verdict says so and the tests are not meaningful.
+func CompareSamples(a, b []float64) SampleComparison {
+	a = appendNotNaN(nil, a)
+	b = appendNotNaN(nil, b)
+	sort.Float64s(a)
+	sort.Float64s(b)
+
+	c := SampleComparison{
+		A:      summarizeSamples(a),
+		B:      summarizeSamples(b),
+		WelchP: welchT(a, b),
+		KS:     ksDistance(a, b),
+	}
+	u, p := mannWhitneyU(a, b)
+	c.MannWhitneyP = p
+	if len(a) > 0 && len(b) > 0 {
+		// u counts pairs where a wins, so flip it for B relative to A
+		c.CliffsDelta = 1 - 2*u/(float64(len(a))*float64(len(b)))
+		ne := float64(len(a)) * float64(len(b)) / float64(len(a)+len(b))
+		c.KSP = kolmogorovSF((math.Sqrt(ne) + 0.12 + 0.11/math.Sqrt(ne)) * c.KS)
+	}
+	pooled := math.Sqrt((c.A.StdDev*c.A.StdDev + c.B.StdDev*c.B.StdDev) / 2)
+	if pooled > 0 {
+		c.CohensD = (c.B.Mean - c.A.Mean) / pooled
+	}
+	c.Verdict = verdict(c)
+	return c
+}
+
+// summarizeSamples summarizes an ascending slice
+func summarizeSamples(sorted []float64) SampleSummary {
+	s := SampleSummary{
+		Count: len(sorted),
+		P50:   sortedPercentile(sorted, 50),
+		P95:   sortedPercentile(sorted, 95),
+		P99:   sortedPercentile(sorted, 99),
+	}
+	if len(sorted) > 1 {
+		mean, std := meanStd(sorted)
+		s.Mean = mean
+		s.StdDev = std * math.Sqrt(float64(len(sorted))/float64(len(sorted)-1))
+	} else if len(sorted) == 1 {
+		s.Mean = sorted[0]
+	}
+	return s
+}
+
+// kolmogorovSF is the survival function of the Kolmogorov distribution
+func kolmogorovSF(lambda float64) float64 {
+	if lambda < 0.2 {
+		return 1
+	}
+	var sum float64
+	sign := 1.0
+	for k := 1; k <= 100; k++ {
+		term := sign * math.Exp(-2*float64(k*k)*lambda*lambda)
+		sum += term
+		if math.Abs(term) < 1e-12 {
+			break
+		}
+		sign = -sign
+	}
+	return math.Min(1, math.Max(0, 2*sum))
+}
+
+// minCompareSamples is the smallest dataset CompareSamples draws a
+// conclusion from
+const minCompareSamples = 2
+
+// verdictInsufficient is the verdict when either dataset is too small
+const verdictInsufficient = "insufficient data: each dataset needs at least 2 samples"
+
+// verdict turns the test results into a one-line conclusion
+func verdict(c SampleComparison) string {
+	if c.A.Count < minCompareSamples || c.B.Count < minCompareSamples {
+		return verdictInsufficient
+	}
+	if c.MannWhitneyP >= significanceLevel && c.KSP >= significanceLevel {
+		return "no significant difference"
+	}
+
+	size := "negligible"
+	switch d := math.Abs(c.CliffsDelta); {
+	case d >= 0.474:
+		size = "large"
+	case d >= 0.33:
+		size = "medium"
+	case d >= 0.147:
+		size = "small"
+	}
+	switch {
+	case c.CliffsDelta > 0:
+		return fmt.Sprintf("B is significantly higher (%s effect)", size)
+	case c.CliffsDelta < 0:
+		return fmt.Sprintf("B is significantly lower (%s effect)", size)
+	}
+	return "distributions differ in shape, not location"
+}
@@ -0,0 +1,72 @@
+package streamstats
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCompareSamplesVerdict(t *testing.T) {
+	low := make([]float64, 50)
+	high := make([]float64, 50)
+	for i := range low {
+		low[i] = float64(i)
+		high[i] = float64(i + 100)
+	}
+
+	tests := []struct {
+		name string
+		a, b []float64
+		want string
+	}{
+		{"both empty", nil, nil, verdictInsufficient},
+		{"A empty", nil, low, verdictInsufficient},
+		{"B empty", low, nil, verdictInsufficient},
+		{"one sample", []float64{1}, low, verdictInsufficient},
+		{"same data", low, low, "no significant difference"},
+		{"B higher", low, high, "B is significantly higher (large effect)"},
+		{"B lower", high, low, "B is significantly lower (large effect)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := CompareSamples(tt.a, tt.b)
+			if c.Verdict != tt.want {
+				t.Errorf("Verdict = %q, want %q", c.Verdict, tt.want)
+			}
+			if c.A.Count != len(tt.a) || c.B.Count != len(tt.b) {
+				t.Errorf("counts = %d, %d, want %d, %d", c.A.Count, c.B.Count, len(tt.a), len(tt.b))
+			}
+		})
+	}
+}
+
+func TestCompareSamplesIgnoresNaN(t *testing.T) {
+	nan := math.NaN()
+	c := CompareSamples([]float64{1, nan, 2, 3}, []float64{nan, 4, 5, 6})
+	if c.A.Count != 3 || c.B.Count != 3 {
+		t.Errorf("counts = %d, %d, want 3, 3", c.A.Count, c.B.Count)
+	}
+	if c.KS != 1 || c.CliffsDelta != 1 {
+		t.Errorf("KS, CliffsDelta = %v, %v, want 1, 1", c.KS, c.CliffsDelta)
+	}
+}
+
+func TestReadSamples(t *testing.T) {
+	in := "latency,size\n# comment\n1.5,10\n\n2.5,20\n"
+	got, err := ReadSamples(strings.NewReader(in), 1)
+	if err != nil || len(got) != 2 || got[0] != 10 || got[1] != 20 {
+		t.Errorf("ReadSamples() = %v, %v, want [10 20]", got, err)
+	}
+	if _, err := ReadSamples(strings.NewReader("1\nx\n"), 0); err == nil {
+		t.Error("ReadSamples() with a non-numeric line succeeded")
+	}
+	for _, v := range []string{"NaN", "+Inf", "-inf"} {
+		_, err := ReadSamples(strings.NewReader("1\n2\n"+v+"\n4\n"), 0)
+		if err == nil || !strings.Contains(err.Error(), "line 3") {
+			t.Errorf("ReadSamples() with %s = %v, want a line 3 error", v, err)
+		}
+	}
+	if _, err := ReadSamples(strings.NewReader("1,2\n"), 5); err == nil {
+		t.Error("ReadSamples() of a missing column succeeded")
+	}
+}
@@ -0,0 +1,57 @@
+package streamstats
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Shedder answers "has p95 been above High for at least Hold" for gating
+// load shedding in the serving path. Once shedding, it keeps shedding until
+// p95 falls to Low or below, so decisions don't flap around the threshold.
+// ShouldShed reads the p95 cached by the percentile worker and costs O(1).
+type Shedder struct {
+	ds   *DataStreamStats
+	high float64
+	low  float64
+	hold time.Duration
+
+	mu         sync.Mutex
+	aboveSince time.Time // Zero while p95 is at or below high
+	shedding   bool
+}
+
+// NewShedder creates a shedder over the p95 of ds. low is clamped to high.
+func NewShedder(ds *DataStreamStats, high, low float64, hold time.Duration) *Shedder {
+	ds.lazyInit()
+	return &Shedder{ds: ds, high: high, low: min(low, high), hold: hold}
+}
+
+// ShouldShed reports whether load should currently be shed
+func (s *Shedder) ShouldShed() bool {
+	p95 := math.Float64frombits(s.ds.p95Bits.Load())
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if math.IsNaN(p95) {
+		return s.shedding
+	}
+	if s.shedding {
+		if p95 <= s.low {
+			s.shedding = false
+			s.aboveSince = time.Time{}
+		}
+		return s.shedding
+	}
+	if p95 <= s.high {
+		s.aboveSince = time.Time{}
+		return false
+	}
+	if s.aboveSince.IsZero() {
+		s.aboveSince = now
+	}
+	s.shedding = now.Sub(s.aboveSince) >= s.hold
+	return s.shedding
+}
@@ -0,0 +1,77 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// setP95 publishes p95 as the percentile worker would
+func setP95(ds *DataStreamStats, p95 float64) {
+	ds.p95Bits.Store(math.Float64bits(p95))
+}
+
+func TestShedder(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+	s := NewShedder(ds, 100, 80, 0)
+
+	if s.ShouldShed() {
+		t.Error("ShouldShed() before any p95, want false")
+	}
+	for _, step := range []struct {
+		p95  float64
+		want bool
+	}{
+		{90, false},        // Below high
+		{120, true},        // Above high with no hold
+		{90, true},         // Still above low, keeps shedding
+		{80, false},        // Back at low
+		{90, false},        // Below high again
+		{101, true},        // Above high
+		{math.NaN(), true}, // Unknown p95 keeps the decision
+	} {
+		setP95(ds, step.p95)
+		if got := s.ShouldShed(); got != step.want {
+			t.Errorf("ShouldShed() at p95 %v = %v, want %v", step.p95, got, step.want)
+		}
+	}
+
+	// low is clamped to high
+	if s := NewShedder(ds, 100, 200, 0); s.low != 100 {
+		t.Errorf("low = %v, want clamped to 100", s.low)
+	}
+}
+
+func TestShedderHold(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+	s := NewShedder(ds, 100, 80, time.Hour)
+
+	setP95(ds, 500)
+	if s.ShouldShed() {
+		t.Error("ShouldShed() before the hold elapsed, want false")
+	}
+	// Dipping below high restarts the hold
+	setP95(ds, 50)
+	s.ShouldShed()
+	if !s.aboveSince.IsZero() {
+		t.Error("hold still running after p95 fell below high")
+	}
+}
+
+func TestShedderOnStream(t *testing.T) {
+	ds := NewDataStreamStats(100)
+	defer ds.Stop()
+	s := NewShedder(ds, 100, 80, 0)
+	for i := 0; i < 100; i++ {
+		ds.AddNumber(1000)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !s.ShouldShed() {
+		if time.Now().After(deadline) {
+			t.Fatal("ShouldShed() = false with a p95 of 1000 after 5s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,72 @@
+package streamstats
+
+import (
+	"fmt"
+	"time"
+)
+
+// SizeRecorder records payload sizes in bytes and reports totals,
+// throughput and size percentiles
+type SizeRecorder struct {
+	ds    *DataStreamStats
+	start time.Time
+}
+
+// NewSizeRecorder creates a recorder backed by a NewSizeStats stream
+func NewSizeRecorder(name string) *SizeRecorder {
+	return &SizeRecorder{ds: NewSizeStats(name), start: time.Now()}
+}
+
+// Record adds a payload of n bytes
+func (sr *SizeRecorder) Record(n int64) {
+	sr.ds.AddNumber(float64(n))
+}
+
+// Stream returns the underlying stream
+func (sr *SizeRecorder) Stream() *DataStreamStats {
+	return sr.ds
+}
+
+// Total returns the number of bytes recorded
+func (sr *SizeRecorder) Total() float64 {
+	sr.ds.minMaxLock.Lock()
+	defer sr.ds.minMaxLock.Unlock()
+	return sr.ds.totalSum
+}
+
+// Throughput returns the bytes recorded per second since the recorder
+// was created
+func (sr *SizeRecorder) Throughput() float64 {
+	elapsed := time.Since(sr.start).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return sr.Total() / elapsed
+}
+
+// Percentile returns the pth percentile payload size in bytes
+func (sr *SizeRecorder) Percentile(p float64) float64 {
+	return sr.ds.GetPercentile(p)
+}
+
+// String summarizes the recorder with humanized sizes
+func (sr *SizeRecorder) String() string {
+	return fmt.Sprintf("total %s, %s/s, p50 %s, p95 %s, p99 %s",
+		HumanBytes(sr.Total()), HumanBytes(sr.Throughput()),
+		HumanBytes(sr.Percentile(50)), HumanBytes(sr.Percentile(95)), HumanBytes(sr.Percentile(99)))
+}
+
+// HumanBytes formats a byte count with binary prefixes, e.g. 1536 as
+// "1.5 KiB"
+func HumanBytes(v float64) string {
+	units := []Unit{Byte, Kibibyte, Mebibyte, Gibibyte}
+	i := 0
+	for i < len(units)-1 && v >= 1024 {
+		v /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", v, units[i].Name)
+	}
+	return fmt.Sprintf("%.1f %s", v, units[i].Name)
+}
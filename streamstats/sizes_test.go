@@ -0,0 +1,46 @@
+package streamstats
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestHumanBytes(t *testing.T) {
+	for _, tc := range []struct {
+		v    float64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KiB"},
+		{5 << 20, "5.0 MiB"},
+		{3 << 30, "3.0 GiB"},
+		{4096 << 30, "4096.0 GiB"},
+	} {
+		if got := HumanBytes(tc.v); got != tc.want {
+			t.Errorf("HumanBytes(%v) = %q, want %q", tc.v, got, tc.want)
+		}
+	}
+}
+
+func TestSizeRecorder(t *testing.T) {
+	sr := NewSizeRecorder("uploads")
+	defer sr.Stream().Stop()
+	for i := int64(1); i <= 100; i++ {
+		sr.Record(i << 10)
+	}
+
+	if got := sr.Total(); got != 5050<<10 {
+		t.Errorf("Total() = %v, want %v", got, 5050<<10)
+	}
+	if got := sr.Percentile(50); math.Abs(got-50<<10) > 0.02*50<<10 {
+		t.Errorf("Percentile(50) = %v, want 50 KiB within 2%%", got)
+	}
+	if sr.Throughput() <= 0 {
+		t.Errorf("Throughput() = %v, want positive", sr.Throughput())
+	}
+	if s := sr.String(); !strings.HasPrefix(s, "total 4.9 MiB, ") || !strings.Contains(s, "p50 ") {
+		t.Errorf("String() = %q", s)
+	}
+}
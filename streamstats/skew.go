@@ -0,0 +1,79 @@
+package streamstats
+
+import "time"
+
+// SkewPolicy decides what happens to samples whose timestamps are outside
+// the tolerated clock skew, see Options.MaxFutureSkew and MaxLateness
+type SkewPolicy int
+
+const (
+	// SkewClamp moves skewed timestamps to the nearest tolerated time
+	SkewClamp SkewPolicy = iota
+	// SkewReject drops skewed samples
+	SkewReject
+	// SkewSeparate diverts skewed samples with their original timestamps
+	// to a separate stream, see Skewed
+	SkewSeparate
+)
+
+// SkewCounts counts samples with skewed timestamps
+type SkewCounts struct {
+	Future int64 // Timestamps too far ahead of the clock
+	Late   int64 // Timestamps too far behind the clock
+}
+
+// AddNumberAt adds num observed at t, e.g. an event time reported by a
+// client. Time-based structures such as the decayed percentiles use t;
+// timestamps outside the tolerated skew are handled per Options.SkewPolicy.
+// Without MaxFutureSkew and MaxLateness every timestamp is accepted as is.
+func (ds *DataStreamStats) AddNumberAt(t time.Time, num float64) {
+	ds.lazyInit()
+	now := ds.now()
+	var tolerated time.Time
+	switch {
+	case ds.opts.MaxFutureSkew > 0 && t.After(now.Add(ds.opts.MaxFutureSkew)):
+		tolerated = now.Add(ds.opts.MaxFutureSkew)
+	case ds.opts.MaxLateness > 0 && t.Before(now.Add(-ds.opts.MaxLateness)):
+		tolerated = now.Add(-ds.opts.MaxLateness)
+	default:
+		ds.addAt(t, num)
+		return
+	}
+
+	ds.minMaxLock.Lock()
+	if t.After(now) {
+		ds.skew.Future++
+	} else {
+		ds.skew.Late++
+	}
+	ds.minMaxLock.Unlock()
+
+	switch ds.opts.SkewPolicy {
+	case SkewClamp:
+		ds.addAt(tolerated, num)
+	case SkewSeparate:
+		ds.skewed.addAt(t, num)
+	}
+}
+
+// addAt records num at t without skew checks
+func (ds *DataStreamStats) addAt(t time.Time, num float64) {
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	ds.add(t, num)
+}
+
+// GetSkewCounts returns the number of samples with skewed timestamps
+func (ds *DataStreamStats) GetSkewCounts() SkewCounts {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	return ds.skew
+}
+
+// Skewed returns the stream receiving skewed samples with SkewSeparate,
+// or nil for other policies
+func (ds *DataStreamStats) Skewed() *DataStreamStats {
+	ds.lazyInit()
+	return ds.skewed
+}
@@ -0,0 +1,53 @@
+package streamstats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSkewDisabledByDefault(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Now: func() time.Time { return now }})
+	defer ds.Stop()
+
+	ds.AddNumberAt(now.Add(time.Millisecond), 1)
+	ds.AddNumberAt(now.Add(time.Hour), 2)
+	ds.AddNumberAt(now.Add(-24*time.Hour), 3)
+	if got := ds.GetSkewCounts(); got != (SkewCounts{}) {
+		t.Errorf("GetSkewCounts() without bounds = %+v, want none", got)
+	}
+	if got := ds.Count(); got != 3 {
+		t.Errorf("Count() = %d, want 3", got)
+	}
+}
+
+func TestSkewPolicies(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, policy := range []SkewPolicy{SkewClamp, SkewReject, SkewSeparate} {
+		ds := NewDataStreamStatsWithOptions(Options{
+			Capacity:      10,
+			SkewPolicy:    policy,
+			MaxFutureSkew: time.Second,
+			MaxLateness:   time.Minute,
+			Now:           func() time.Time { return now },
+		})
+
+		ds.AddNumberAt(now.Add(500*time.Millisecond), 1) // Tolerated
+		ds.AddNumberAt(now.Add(time.Hour), 2)
+		ds.AddNumberAt(now.Add(-time.Hour), 3)
+
+		if got := ds.GetSkewCounts(); got != (SkewCounts{Future: 1, Late: 1}) {
+			t.Errorf("policy %d: GetSkewCounts() = %+v, want one future and one late", policy, got)
+		}
+		want := map[SkewPolicy]int64{SkewClamp: 3, SkewReject: 1, SkewSeparate: 1}[policy]
+		if got := ds.Count(); got != want {
+			t.Errorf("policy %d: Count() = %d, want %d", policy, got, want)
+		}
+		if policy == SkewSeparate {
+			if got := ds.Skewed().Count(); got != 2 {
+				t.Errorf("Skewed().Count() = %d, want 2", got)
+			}
+		}
+		ds.Stop()
+	}
+}
@@ -0,0 +1,111 @@
+package streamstats
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SLIConfig describes a service's indicators and objectives
+type SLIConfig struct {
+	Service string // Metric name prefix, e.g. "checkout"
+	Labels  Labels // Labels of every emitted series
+
+	Latency     *DataStreamStats // Request latencies, optional
+	Percentiles []float64        // Latency percentiles to emit, default 99
+
+	Availability       *RatioTracker // Request outcomes, optional
+	AvailabilityTarget float64       // Objective such as 0.999, enables the error budget
+}
+
+// SLI emits a service's indicators under conventional names so that
+// dashboards and alerts look the same across teams:
+//
+//	<service>_latency_p99             latency percentiles, p99.9 as _p99_9
+//	<service>_availability_ratio      success ratio of the current window
+//	<service>_error_budget_remaining  unspent fraction of the lifetime error budget
+//
+// Each collection sets same-named registry gauges, so the current values
+// reach every export of the registry under exactly these names.
+type SLI struct {
+	mu       sync.Mutex
+	reg      *StatsRegistry
+	cfg      SLIConfig
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewSLI collects cfg's indicators into reg every interval, or only on
+// Collect when interval is 0
+func NewSLI(reg *StatsRegistry, cfg SLIConfig, interval time.Duration) *SLI {
+	if len(cfg.Percentiles) == 0 {
+		cfg.Percentiles = []float64{99}
+	}
+	s := &SLI{
+		reg:      reg,
+		cfg:      cfg,
+		stopChan: make(chan struct{}),
+	}
+	if interval > 0 {
+		go s.worker(interval)
+	}
+	return s
+}
+
+// worker collects every interval until Stop
+func (s *SLI) worker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Collect()
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// Values returns the current indicators by series name. Indicators
+// without data, such as percentiles of an empty stream, are left out.
+func (s *SLI) Values() map[string]float64 {
+	prefix := sanitizeMetricName(s.cfg.Service) + "_"
+	values := make(map[string]float64)
+
+	if ds := s.cfg.Latency; ds != nil && ds.Count() > 0 {
+		for _, p := range s.cfg.Percentiles {
+			values[prefix+"latency_"+percentileSuffix(p)] = ds.GetPercentile(p)
+		}
+	}
+	if rt := s.cfg.Availability; rt != nil {
+		snap := rt.Snapshot()
+		if snap.Window.Total > 0 {
+			values[prefix+"availability_ratio"] = snap.Window.Ratio
+		}
+		if t := s.cfg.AvailabilityTarget; t > 0 && t < 1 && snap.Lifetime.Total > 0 {
+			// Negative once the budget is overspent
+			values[prefix+"error_budget_remaining"] = 1 - (1-snap.Lifetime.Ratio)/(1-t)
+		}
+	}
+	return values
+}
+
+// Collect sets the registry gauges to the current indicators once
+func (s *SLI) Collect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, v := range s.Values() {
+		s.reg.Gauge(name, s.cfg.Labels).Set(v)
+	}
+}
+
+// Stop stops periodic collection; the registry keeps the last values
+func (s *SLI) Stop() {
+	s.stopOnce.Do(func() { close(s.stopChan) })
+}
+
+// percentileSuffix names a percentile: 99 as p99 and 99.9 as p99_9
+func percentileSuffix(p float64) string {
+	return "p" + strings.ReplaceAll(strconv.FormatFloat(p, 'f', -1, 64), ".", "_")
+}
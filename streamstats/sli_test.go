@@ -0,0 +1,81 @@
+package streamstats
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSLIValues(t *testing.T) {
+	reg := NewStatsRegistry(Options{Capacity: 10})
+	defer reg.Stop()
+	lat := NewDataStreamStats(1000)
+	defer lat.Stop()
+	for i := 1; i <= 1000; i++ {
+		lat.AddNumber(float64(i))
+	}
+	rt := NewRatioTracker(0)
+	rt.RecordN(9995, 10000)
+
+	sli := NewSLI(reg, SLIConfig{
+		Service:            "checkout",
+		Labels:             Labels{"team": "payments"},
+		Latency:            lat,
+		Percentiles:        []float64{50, 99.9},
+		Availability:       rt,
+		AvailabilityTarget: 0.999,
+	}, 0)
+	defer sli.Stop()
+
+	got := sli.Values()
+	for name, want := range map[string]float64{
+		"checkout_latency_p50":            lat.GetPercentile(50),
+		"checkout_latency_p99_9":          lat.GetPercentile(99.9),
+		"checkout_availability_ratio":     0.9995,
+		"checkout_error_budget_remaining": 0.5,
+	} {
+		if v, ok := got[name]; !ok || math.Abs(v-want) > 1e-9 {
+			t.Errorf("%s = %v (present %v), want %v", name, v, ok, want)
+		}
+	}
+	if len(got) != 4 {
+		t.Errorf("Values() = %v, want 4 series", got)
+	}
+
+	// Collected values are exported as gauges under exactly these names
+	sli.Collect()
+	rt.RecordN(10000, 10000)
+	sli.Collect()
+	if got := reg.Gauge("checkout_error_budget_remaining", Labels{"team": "payments"}).Value(); math.Abs(got-0.75) > 1e-9 {
+		t.Errorf("error budget gauge = %v after Collect, want the current 0.75", got)
+	}
+	series, _ := reg.page("", 0)
+	var sb strings.Builder
+	writePrometheus(&sb, series)
+	body := sb.String()
+	for _, want := range []string{
+		"# TYPE checkout_availability_ratio gauge\n",
+		"# TYPE checkout_latency_p99_9 gauge\n",
+		`checkout_latency_p50{team="payments"} 500` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("export lacks %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "summary") || strings.Contains(body, "_count") {
+		t.Errorf("export summarizes the indicators:\n%s", body)
+	}
+}
+
+func TestSLIWithoutData(t *testing.T) {
+	reg := NewStatsRegistry(Options{Capacity: 10})
+	defer reg.Stop()
+	lat := NewDataStreamStats(10)
+	defer lat.Stop()
+
+	sli := NewSLI(reg, SLIConfig{Service: "api", Latency: lat, Availability: NewRatioTracker(0), AvailabilityTarget: 0.99}, 0)
+	defer sli.Stop()
+	if got := sli.Values(); len(got) != 0 {
+		t.Errorf("Values() without data = %v, want none", got)
+	}
+}
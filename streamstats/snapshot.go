@@ -0,0 +1,183 @@
+package streamstats
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Snapshot is a point-in-time copy of a stream's statistics. Lifetime
+// covers every sample since the stream was created, Window only the most
+// recent samples held in the ring buffer.
+type Snapshot struct {
+	Name        string
+	Source      Source // Identifies the stream and orders its snapshots
+	Time        time.Time
+	Unit        string  // Name of Options.Unit, empty if unitless
+	Quantum     float64 // Rounding applied before quantile structures, 0 if none
+	Lifetime    LifetimeStats
+	Window      WindowStats
+	Shed        int64 // Samples dropped by the rate limiter
+	Gaps        int64 // Intervals without samples, see Options.GapInterval
+	Health      Health
+	Skew        SkewCounts         // Samples with skewed timestamps, see AddNumberAt
+	Jitter      *WindowStats       // Window of consecutive deltas, with Options.TrackJitter
+	Progress    *Progress          // Set when an expected total was configured
+	Normalized  *Normalized        // Set when a baseline was configured
+	Ratio       *RatioSnapshot     // Set when a RatioTracker was attached
+	Histogram   *HistogramSnapshot // Set with Options.Histogram
+	Watermark   *WatermarkStatus   // Set with Options.EventWindow
+	Annotations []Annotation       // See Annotate
+	Engine      *EngineStatus      // Set with ExactBelow
+	Smoothed    *SmoothedStats     // Set on published snapshots with Options.Smoothing
+	Custom      map[string]float64
+	Derived     map[string]float64
+}
+
+// LifetimeStats are aggregates over every sample ever added
+type LifetimeStats struct {
+	Count  int64
+	Sum    float64
+	Mean   float64
+	StdDev float64 // Sample standard deviation
+	Min    float64
+	Max    float64
+	Median float64
+}
+
+// WindowStats are aggregates over the samples currently in the window
+type WindowStats struct {
+	Count    int
+	Capacity int
+	Mean     float64
+	Min      float64
+	Max      float64
+	P50      float64
+	P95      float64
+	P99      float64
+	Samples  []float64 // Oldest first
+}
+
+// Snapshot collects the current statistics
+func (ds *DataStreamStats) Snapshot() Snapshot {
+	ds.lazyInit()
+	cached := ds.GetCachedStats()
+
+	ds.minMaxLock.Lock()
+	lifetime := LifetimeStats{
+		Count: ds.count,
+		Sum:   ds.totalSum,
+		Min:   ds.minVal,
+		Max:   ds.maxVal,
+	}
+	if ds.count > 0 {
+		lifetime.Mean = ds.totalSum / float64(ds.count)
+	}
+	lifetime.StdDev = math.Sqrt(ds.moments.variance(1))
+	shed, gaps, health, skew := ds.shedCount, ds.gapIntervals, ds.health, ds.skew
+	progress := ds.progress()
+	var watermark *WatermarkStatus
+	if ws, ok := ds.watermarkLocked(); ok {
+		watermark = &ws
+	}
+	ds.minMaxLock.Unlock()
+	lifetime.Median = ds.GetMedian()
+
+	snap := Snapshot{
+		Name:        ds.name,
+		Source:      ds.source(),
+		Time:        ds.now(),
+		Unit:        ds.opts.Unit.Name,
+		Quantum:     ds.opts.Quantum,
+		Lifetime:    lifetime,
+		Window:      ds.GetWindowStats(),
+		Shed:        shed,
+		Gaps:        gaps,
+		Health:      health,
+		Skew:        skew,
+		Progress:    progress,
+		Watermark:   watermark,
+		Annotations: ds.Annotations(time.Time{}, time.Time{}),
+		Custom:      cached.custom,
+		Derived:     cached.derived,
+	}
+
+	if hs, ok := ds.GetHistogram(); ok {
+		snap.Histogram = &hs
+	}
+
+	ds.percentileLock.Lock()
+	if ee, ok := ds.quantiles.(*ExactEstimator); ok {
+		st := ee.Status()
+		snap.Engine = &st
+	}
+	ds.percentileLock.Unlock()
+
+	if ds.jitter != nil {
+		js := ds.jitter.Snapshot().Window
+		snap.Jitter = &js
+	}
+
+	ds.cachedLock.Lock()
+	if ds.baseline != nil {
+		snap.Normalized = normalize(snap.Window, ds.baseline)
+	}
+	rt := ds.ratio
+	ds.cachedLock.Unlock()
+
+	if rt != nil {
+		rs := rt.Snapshot()
+		snap.Ratio = &rs
+	}
+
+	return snap
+}
+
+// GetWindowStats summarizes the samples currently in the window: the last
+// Capacity samples, or those of the last Options.TimeWindow
+func (ds *DataStreamStats) GetWindowStats() WindowStats {
+	ds.lazyInit()
+	ds.percentileLock.Lock()
+	samples := ds.recentData.Values()
+	capacity := ds.recentData.cap
+	ds.percentileLock.Unlock()
+	return windowStats(samples, capacity)
+}
+
+// windowStats summarizes the window samples
+func windowStats(samples []float64, capacity int) WindowStats {
+	ws := WindowStats{
+		Count:    len(samples),
+		Capacity: capacity,
+		Samples:  samples,
+	}
+	if len(samples) == 0 {
+		return ws
+	}
+
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	ws.Mean = sum / float64(len(sorted))
+	ws.Min = sorted[0]
+	ws.Max = sorted[len(sorted)-1]
+	ws.P50 = sortedPercentile(sorted, 50)
+	ws.P95 = sortedPercentile(sorted, 95)
+	ws.P99 = sortedPercentile(sorted, 99)
+	return ws
+}
+
+// sortedPercentile returns the pth percentile of an ascending slice
+func sortedPercentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	index := int(math.Ceil((p/100)*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index]
+}
@@ -0,0 +1,52 @@
+package streamstats
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestSnapshotLifetimeAndWindow(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Name: "latency", Capacity: 4})
+	defer ds.Stop()
+	for i := 1; i <= 10; i++ {
+		ds.AddNumber(float64(i))
+	}
+
+	snap := ds.Snapshot()
+	if snap.Name != "latency" {
+		t.Errorf("Name = %q, want latency", snap.Name)
+	}
+
+	lt := snap.Lifetime
+	if lt.Count != 10 || lt.Sum != 55 || lt.Mean != 5.5 || lt.Min != 1 || lt.Max != 10 {
+		t.Errorf("Lifetime = %+v, want count 10, sum 55, mean 5.5 over 1..10", lt)
+	}
+	if want := math.Sqrt(55.0 / 6); math.Abs(lt.StdDev-want) > 1e-9 {
+		t.Errorf("Lifetime.StdDev = %v, want %v", lt.StdDev, want)
+	}
+
+	// The window only holds the last 4 samples
+	w := snap.Window
+	if w.Count != 4 || w.Capacity != 4 || !slices.Equal(w.Samples, []float64{7, 8, 9, 10}) {
+		t.Errorf("Window holds %d/%d samples %v, want 7..10", w.Count, w.Capacity, w.Samples)
+	}
+	if w.Mean != 8.5 || w.Min != 7 || w.Max != 10 || w.P50 != 8 || w.P99 != 10 {
+		t.Errorf("Window = %+v, want mean 8.5, p50 8, p99 10 over 7..10", w)
+	}
+	if got := ds.GetWindowStats(); got.Mean != w.Mean || got.Count != w.Count {
+		t.Errorf("GetWindowStats() = %+v, want the snapshot window %+v", got, w)
+	}
+}
+
+func TestSortedPercentile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	for _, tc := range []struct{ p, want float64 }{{0, 1}, {10, 1}, {11, 2}, {50, 5}, {100, 10}} {
+		if got := sortedPercentile(sorted, tc.p); got != tc.want {
+			t.Errorf("sortedPercentile(1..10, %v) = %v, want %v", tc.p, got, tc.want)
+		}
+	}
+	if got := sortedPercentile(nil, 50); got != 0 {
+		t.Errorf("sortedPercentile(nil) = %v, want 0", got)
+	}
+}
@@ -0,0 +1,61 @@
+//go:build soak
+
+package streamstats
+
+import (
+	"flag"
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+var (
+	soakStreams = flag.Int("soak.streams", 100, "number of streams to ingest into")
+	soakSamples = flag.Int("soak.samples", 5000000, "total samples per phase")
+	soakGrowth  = flag.Float64("soak.maxgrowth", 0.10, "tolerated heap growth between phases")
+)
+
+// heapAlloc returns the live heap after a full collection
+func heapAlloc() uint64 {
+	var m runtime.MemStats
+	runtime.GC()
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
+
+// TestSoakBoundedHeap ingests the same volume twice across many streams and
+// fails when the second phase grows the live heap by more than
+// -soak.maxgrowth, which means memory depends on stream length.
+//
+//	go test -tags soak -run TestSoakBoundedHeap -timeout 1h
+func TestSoakBoundedHeap(t *testing.T) {
+	streams := make([]*DataStreamStats, *soakStreams)
+	for i := range streams {
+		streams[i] = NewDataStreamStatsWithOptions(Options{Capacity: 1000, Quantiles: TDigest(100)})
+	}
+	defer func() {
+		for _, s := range streams {
+			s.Stop()
+		}
+	}()
+
+	ingest := func() {
+		for i := 0; i < *soakSamples; i++ {
+			streams[i%len(streams)].AddNumber(rand.ExpFloat64() * 100)
+		}
+	}
+
+	// The first phase fills windows and lets structures reach steady state
+	ingest()
+	warm := heapAlloc()
+
+	ingest()
+	end := heapAlloc()
+
+	growth := (float64(end) - float64(warm)) / float64(warm)
+	t.Logf("heap after warm-up %d bytes, after soak %d bytes (%+.1f%%)", warm, end, growth*100)
+	if growth > *soakGrowth {
+		t.Fatalf("heap grew %.1f%% between phases, limit %.1f%%", growth*100, *soakGrowth*100)
+	}
+}
@@ -0,0 +1,137 @@
+package streamstats
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// SpanTotal is the phase name under which a span's total duration is recorded
+const SpanTotal = "total"
+
+// SpanRecorder breaks request latency into named phases (auth, db, render)
+// and keeps one stream per phase, recorded in milliseconds
+type SpanRecorder struct {
+	mu      sync.Mutex
+	opts    Options
+	streams map[string]*DataStreamStats
+	closed  bool
+}
+
+// NewSpanRecorder creates a recorder; phase streams are created on first
+// use from opts with the phase as Name and Millisecond as Unit
+func NewSpanRecorder(opts Options) *SpanRecorder {
+	opts.Unit = Millisecond
+	return &SpanRecorder{
+		opts:    opts,
+		streams: make(map[string]*DataStreamStats),
+	}
+}
+
+// Stream returns the stream of a phase, creating it if needed
+func (sr *SpanRecorder) Stream(phase string) *DataStreamStats {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.stream(phase)
+}
+
+// stream looks up or creates a phase stream; callers hold mu
+func (sr *SpanRecorder) stream(phase string) *DataStreamStats {
+	ds, ok := sr.streams[phase]
+	if !ok {
+		opts := sr.opts
+		opts.Name = phase
+		ds = NewDataStreamStatsWithOptions(opts)
+		sr.streams[phase] = ds
+	}
+	return ds
+}
+
+// Phases returns the names of all phases seen so far
+func (sr *SpanRecorder) Phases() []string {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	names := make([]string, 0, len(sr.streams))
+	for name := range sr.streams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close stops every phase stream; spans finished afterwards are dropped.
+// The streams stay readable. Calling Close again has no effect.
+func (sr *SpanRecorder) Close() {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if sr.closed {
+		return
+	}
+	sr.closed = true
+	for _, ds := range sr.streams {
+		ds.Stop()
+	}
+}
+
+// Start begins a span for one request
+func (sr *SpanRecorder) Start() *Span {
+	return &Span{rec: sr, start: time.Now()}
+}
+
+// Span collects the phase timings of one request. It is not safe for
+// concurrent use.
+type Span struct {
+	rec      *SpanRecorder
+	start    time.Time
+	names    []string
+	timings  []time.Duration
+	finished bool
+}
+
+// Record adds d to the named phase; repeated phases accumulate
+func (s *Span) Record(phase string, d time.Duration) {
+	for i, name := range s.names {
+		if name == phase {
+			s.timings[i] += d
+			return
+		}
+	}
+	s.names = append(s.names, phase)
+	s.timings = append(s.timings, d)
+}
+
+// Phase starts timing a phase and returns the function that stops it,
+// e.g. defer span.Phase("db")()
+func (s *Span) Phase(phase string) func() {
+	start := time.Now()
+	return func() { s.Record(phase, time.Since(start)) }
+}
+
+// Finish folds every phase and the total duration into the recorder's
+// streams. All phases of a span are added under one lock, so spans never
+// interleave. Calling Finish again, or after the recorder's Close, has no
+// effect.
+func (s *Span) Finish() {
+	if s.finished {
+		return
+	}
+	s.finished = true
+	total := time.Since(s.start)
+
+	s.rec.mu.Lock()
+	defer s.rec.mu.Unlock()
+	if s.rec.closed {
+		return
+	}
+
+	for i, name := range s.names {
+		s.rec.stream(name).AddNumber(durationMillis(s.timings[i]))
+	}
+	s.rec.stream(SpanTotal).AddNumber(durationMillis(total))
+}
+
+// durationMillis converts d to fractional milliseconds
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,83 @@
+package streamstats
+
+import (
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSpanRecorder(t *testing.T) {
+	sr := NewSpanRecorder(Options{Capacity: 10})
+	defer sr.Close()
+
+	span := sr.Start()
+	span.Record("db", 2*time.Millisecond)
+	span.Record("auth", time.Millisecond)
+	span.Record("db", 3*time.Millisecond)
+	span.Finish()
+	span.Finish()
+
+	if got := sr.Phases(); !slices.Equal(got, []string{"auth", "db", SpanTotal}) {
+		t.Errorf("Phases() = %v, want auth, db and total", got)
+	}
+	db := sr.Stream("db")
+	if db.Count() != 1 || db.GetMax() != 5 {
+		t.Errorf("db phase has %d samples, max %v; want one sample of 5ms", db.Count(), db.GetMax())
+	}
+	if got := db.Snapshot().Unit; got != "ms" {
+		t.Errorf("db phase unit = %q, want ms", got)
+	}
+	if sr.Stream(SpanTotal).Count() != 1 {
+		t.Errorf("total phase has %d samples, want 1", sr.Stream(SpanTotal).Count())
+	}
+}
+
+func TestSpanRecorderClose(t *testing.T) {
+	before := runtime.NumGoroutine()
+	sr := NewSpanRecorder(Options{Capacity: 10})
+	span := sr.Start()
+	for _, phase := range []string{"auth", "db", "render"} {
+		span.Record(phase, time.Millisecond)
+	}
+	span.Finish()
+	sr.Close()
+	sr.Close()
+
+	// Spans finished after Close are dropped
+	late := sr.Start()
+	late.Record("db", time.Millisecond)
+	late.Finish()
+	if got := sr.Stream("db").Count(); got != 1 {
+		t.Errorf("db phase has %d samples after Close, want 1", got)
+	}
+
+	// Workers exit asynchronously once stopped
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("%d goroutines after Close, want at most %d", n, before)
+	}
+}
+
+func TestSpanPhase(t *testing.T) {
+	sr := NewSpanRecorder(Options{Capacity: 10})
+	defer sr.Close()
+
+	span := sr.Start()
+	stop := span.Phase("render")
+	time.Sleep(2 * time.Millisecond)
+	stop()
+	span.Finish()
+
+	render := sr.Stream("render").GetMax()
+	total := sr.Stream(SpanTotal).GetMax()
+	if render < 2 {
+		t.Errorf("render phase = %vms, want at least the 2ms slept", render)
+	}
+	if total < render {
+		t.Errorf("total %vms is shorter than its render phase %vms", total, render)
+	}
+}
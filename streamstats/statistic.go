@@ -0,0 +1,101 @@
+package streamstats
+
+import "fmt"
+
+// Statistic is a custom per-stream computation fed with every sample.
+// Registered statistics ride along in CachedStats under their Name.
+type Statistic interface {
+	Name() string
+	Observe(value float64)
+	Value() float64
+	Merge(other Statistic) error
+	Reset()
+}
+
+// MergeChecker is implemented by statistics that cannot merge some or all
+// others. Merge calls CheckMerge on every pair of same-named statistics
+// before it changes the stream, so a refused merge leaves it untouched.
+type MergeChecker interface {
+	CheckMerge(other Statistic) error
+}
+
+// RegisterStatistic attaches a custom statistic to the stream.
+// Names must be unique within a stream.
+func (ds *DataStreamStats) RegisterStatistic(st Statistic) error {
+	ds.lazyInit()
+	ds.pluginLock.Lock()
+	defer ds.pluginLock.Unlock()
+
+	for _, existing := range ds.plugins {
+		if existing.Name() == st.Name() {
+			return fmt.Errorf("statistic %q already registered", st.Name())
+		}
+	}
+	ds.plugins = append(ds.plugins, st)
+	return nil
+}
+
+// GetStatistic returns the current value of a registered statistic
+func (ds *DataStreamStats) GetStatistic(name string) (float64, bool) {
+	ds.lazyInit()
+	ds.pluginLock.Lock()
+	defer ds.pluginLock.Unlock()
+
+	for _, st := range ds.plugins {
+		if st.Name() == name {
+			return st.Value(), true
+		}
+	}
+	return 0, false
+}
+
+// ResetStatistics resets every registered statistic
+func (ds *DataStreamStats) ResetStatistics() {
+	ds.lazyInit()
+	ds.pluginLock.Lock()
+	defer ds.pluginLock.Unlock()
+
+	for _, st := range ds.plugins {
+		st.Reset()
+	}
+}
+
+// MergeStatistics merges other's custom statistics into same-named ones on ds
+func (ds *DataStreamStats) MergeStatistics(other *DataStreamStats) error {
+	ds.lazyInit()
+	return ds.eachStatisticPair(other, Statistic.Merge)
+}
+
+// checkStatistics asks every statistic of ds implementing MergeChecker
+// whether it can merge its same-named statistic of other
+func (ds *DataStreamStats) checkStatistics(other *DataStreamStats) error {
+	return ds.eachStatisticPair(other, func(st, o Statistic) error {
+		if mc, ok := st.(MergeChecker); ok {
+			return mc.CheckMerge(o)
+		}
+		return nil
+	})
+}
+
+// eachStatisticPair calls fn with every statistic of ds and the
+// same-named statistic of other, stopping at the first error
+func (ds *DataStreamStats) eachStatisticPair(other *DataStreamStats, fn func(st, o Statistic) error) error {
+	other.pluginLock.Lock()
+	theirs := append([]Statistic(nil), other.plugins...)
+	other.pluginLock.Unlock()
+
+	ds.pluginLock.Lock()
+	defer ds.pluginLock.Unlock()
+
+	for _, st := range ds.plugins {
+		for _, o := range theirs {
+			if o.Name() != st.Name() {
+				continue
+			}
+			if err := fn(st, o); err != nil {
+				return fmt.Errorf("merge statistic %q: %w", st.Name(), err)
+			}
+		}
+	}
+	return nil
+}
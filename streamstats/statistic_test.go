@@ -0,0 +1,54 @@
+package streamstats
+
+import "testing"
+
+// counterStat counts observed samples
+type counterStat struct{ n float64 }
+
+func (c *counterStat) Name() string    { return "counter" }
+func (c *counterStat) Observe(float64) { c.n++ }
+func (c *counterStat) Value() float64  { return c.n }
+func (c *counterStat) Reset()          { c.n = 0 }
+func (c *counterStat) Merge(o Statistic) error {
+	c.n += o.Value()
+	return nil
+}
+
+func TestRegisterStatisticSnapshot(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+
+	if err := ds.RegisterStatistic(&counterStat{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ds.RegisterStatistic(&counterStat{}); err == nil {
+		t.Error("RegisterStatistic() with a duplicate name succeeded")
+	}
+
+	// Snapshots after every sample must not serve a stale value
+	for i := 1; i <= 3; i++ {
+		ds.AddNumber(float64(i))
+		if got := ds.Snapshot().Custom["counter"]; got != float64(i) {
+			t.Errorf("Snapshot().Custom[counter] after %d samples = %v", i, got)
+		}
+	}
+	if got, ok := ds.GetStatistic("counter"); !ok || got != 3 {
+		t.Errorf("GetStatistic(counter) = %v, %v, want 3", got, ok)
+	}
+
+	other := NewDataStreamStats(10)
+	defer other.Stop()
+	other.RegisterStatistic(&counterStat{})
+	other.AddNumber(1)
+	if err := ds.MergeStatistics(other); err != nil {
+		t.Fatal(err)
+	}
+	if got := ds.GetCachedStats().custom["counter"]; got != 4 {
+		t.Errorf("counter after MergeStatistics = %v, want 4", got)
+	}
+
+	ds.ResetStatistics()
+	if got := ds.Snapshot().Custom["counter"]; got != 0 {
+		t.Errorf("counter after ResetStatistics = %v, want 0", got)
+	}
+}
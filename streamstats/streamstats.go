@@ -0,0 +1,1001 @@
+// Package streamstats computes descriptive statistics (mean, median,
+// min, max and percentiles) over streams of float64 samples.
+package streamstats
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kalpit-sharma-dev/math-stats/container"
+)
+
+// RingBuffer for storing recent data; the zero value holds the last 1000
+// values
+type RingBuffer struct {
+	data    []float64
+	data32  []float32 // Used instead of data by compact buffers
+	compact bool
+	times   []int64       // Sample times in Unix nanoseconds, when maxAge is set
+	maxAge  time.Duration // Samples older than this expire, 0 to keep the last cap
+	head    int
+	size    int
+	cap     int
+}
+
+func NewRingBuffer(cap int) *RingBuffer {
+	return &RingBuffer{
+		data: make([]float64, cap),
+		cap:  cap,
+	}
+}
+
+// NewCompactRingBuffer stores values as float32, halving memory at the
+// cost of precision beyond about 7 significant digits
+func NewCompactRingBuffer(cap int) *RingBuffer {
+	return &RingBuffer{
+		data32:  make([]float32, cap),
+		compact: true,
+		cap:     cap,
+	}
+}
+
+// NewTimedRingBuffer holds the values of the last maxAge, at most cap of
+// them. Expired values are evicted from the oldest end as values are
+// added and read.
+func NewTimedRingBuffer(cap int, maxAge time.Duration) *RingBuffer {
+	rb := NewRingBuffer(cap)
+	rb.maxAge = maxAge
+	return rb
+}
+
+func (rb *RingBuffer) Add(val float64) {
+	rb.AddAt(time.Time{}, val)
+}
+
+// AddAt adds val observed at t, or now when t is zero; only timed
+// buffers use t
+func (rb *RingBuffer) AddAt(t time.Time, val float64) {
+	// A zero RingBuffer holds defaultCapacity values
+	if rb.cap == 0 {
+		rb.cap = defaultCapacity
+	}
+	// Storage is allocated again after release
+	switch {
+	case rb.compact && rb.data32 == nil:
+		rb.data32 = make([]float32, rb.cap)
+	case !rb.compact && rb.data == nil:
+		rb.data = make([]float64, rb.cap)
+	}
+
+	if rb.compact {
+		rb.data32[rb.head] = float32(val)
+	} else {
+		rb.data[rb.head] = val
+	}
+	if rb.maxAge > 0 {
+		if t.IsZero() {
+			t = time.Now()
+		}
+		if rb.times == nil {
+			rb.times = make([]int64, rb.cap)
+		}
+		rb.times[rb.head] = t.UnixNano()
+		rb.expire(t)
+	}
+	rb.head = (rb.head + 1) % rb.cap
+	if rb.size < rb.cap {
+		rb.size++
+	}
+}
+
+// expire evicts values older than maxAge at now. Values are evicted in
+// the order they were added, so a late value expires once it is oldest.
+func (rb *RingBuffer) expire(now time.Time) {
+	cutoff := now.Add(-rb.maxAge).UnixNano()
+	for rb.size > 0 && rb.times[rb.start()] < cutoff {
+		rb.size--
+	}
+}
+
+// start returns the index of the oldest value
+func (rb *RingBuffer) start() int {
+	if rb.cap == 0 {
+		return 0
+	}
+	return (rb.head - rb.size + rb.cap) % rb.cap
+}
+
+// release empties the buffer and frees its storage
+func (rb *RingBuffer) release() {
+	rb.data, rb.data32, rb.times = nil, nil, nil
+	rb.head, rb.size = 0, 0
+}
+
+// Values returns the buffered values from oldest to newest
+func (rb *RingBuffer) Values() []float64 {
+	if rb.maxAge > 0 {
+		rb.expire(time.Now())
+	}
+	start := rb.start()
+	if start+rb.size <= rb.cap {
+		return rb.slice(start, start+rb.size)
+	}
+	return append(rb.slice(start, rb.cap), rb.slice(0, rb.head)...)
+}
+
+func (rb *RingBuffer) GetSorted() []float64 {
+	sorted := rb.Values()
+	sort.Float64s(sorted)
+	return sorted
+}
+
+// slice copies the stored values in [from, to) as float64
+func (rb *RingBuffer) slice(from, to int) []float64 {
+	if !rb.compact {
+		return append([]float64(nil), rb.data[from:to]...)
+	}
+	out := make([]float64, 0, to-from)
+	for _, v := range rb.data32[from:to] {
+		out = append(out, float64(v))
+	}
+	return out
+}
+
+// memoryUsage returns the bytes used by the stored values
+func (rb *RingBuffer) memoryUsage() int64 {
+	return int64(cap(rb.data))*8 + int64(cap(rb.data32))*4 + int64(cap(rb.times))*8
+}
+
+// DataStreamStats tracks streaming statistics. The zero value is ready to
+// use, with default Options, and can be embedded in other structs; as
+// with constructed streams, Stop releases its background worker.
+type DataStreamStats struct {
+	minMaxLock      sync.Mutex
+	heapLock        sync.Mutex
+	percentileLock  sync.Mutex
+	cachedLock      sync.Mutex
+	totalSum        float64
+	count           int64
+	minVal          float64
+	maxVal          float64
+	lower           container.MaxHeap
+	upper           container.MinHeap
+	recentData      *RingBuffer
+	balanceCounter  int
+	cached          CachedStats
+	cacheUpdated    bool
+	cachePercentile map[int]float64
+	percentileChan  chan struct{} // Signal channel for percentile calculation
+	stopChan        chan struct{} // Channel to stop background workers
+	name            string
+	limiter         *TokenBucket // Optional ingestion rate limiter
+	shedCount       int64        // Samples dropped by the limiter
+	pluginLock      sync.Mutex
+	plugins         []Statistic    // Custom statistics fed on every AddNumber
+	derived         []derivedField // Expressions evaluated with cached stats
+	nonNegative     *logBuckets    // Lifetime percentiles when the domain is non-negative
+	negativeCount   int64          // Negative samples rejected in non-negative mode
+	decayed         *DecayingQuantiles
+	stopOnce        sync.Once
+	finalized       *Summary  // Set by Finalize, further samples are rejected
+	expectedTotal   int64     // Expected sample count for finite workloads
+	firstSample     time.Time // When the first sample was accepted
+	opts            Options
+	parent          *DataStreamStats // Receives every accepted sample
+	childLock       sync.Mutex
+	children        []*DataStreamStats
+	exemplarLock    sync.Mutex
+	exemplars       map[int][]Exemplar // Recent exemplars per log-scaled bucket
+	callerLock      sync.Mutex
+	callSites       map[string]*CallSite // Sampled AddNumber callers
+	p95Bits         atomic.Uint64        // Last computed p95, readable without locks
+	p99Bits         atomic.Uint64        // Last computed p99, readable without locks
+	epochLock       sync.Mutex
+	epoch           *epochState // Receives samples since the last SetEpoch
+	closedEpochs    []Epoch
+	epochDigest     *tdigest      // Digest of the current epoch
+	closedDigests   []*tdigest    // Digests of closed epochs
+	preEpoch        Rollup        // Totals before the first SetEpoch, digest in closedDigests[0]
+	compression     float64       // Compression of new digests, see AccuracyTarget
+	baseline        *Snapshot     // Reference for normalized snapshot values
+	ratio           *RatioTracker // Included in snapshots, see AttachRatio
+	gapIntervals    int64         // Intervals that received no samples
+	health          Health
+	jitter          *DataStreamStats // Deltas between consecutive samples
+	last            float64          // Previous accepted sample, for jitter
+	skew            SkewCounts
+	skewed          *DataStreamStats // Skewed samples, with SkewSeparate
+	hibernating     bool             // Idle for IdleTTL, see hibernate
+	publishChan     chan struct{}    // Signals publishWorker, see PublishEvery
+	moments         moments          // Higher-order moments, guarded by minMaxLock
+	lanes           [LaneCritical + 1]LaneCounts
+	quantiles       QuantileEstimator // Replaces the heaps, see Options.Quantiles; guarded by percentileLock
+	published       atomic.Pointer[Snapshot]
+	id              string                    // Stream ID, see ID
+	seq             atomic.Uint64             // Last sequence number, see Source
+	generation      atomic.Uint64             // Advances on every change, see Generation
+	merged          map[string]Source         // Latest merged source per stream ID
+	histogram       atomic.Pointer[Histogram] // See Options.Histogram and WarmUp
+	warmUp          []float64                 // Samples buffered until WarmUp, guarded by minMaxLock
+	events          *eventWindows             // See Options.EventWindow
+	annotationLock  sync.Mutex
+	annotations     []Annotation // Ordered by time, see Annotate
+	initOnce        sync.Once    // See lazyInit
+}
+
+// Options configures a DataStreamStats
+type Options struct {
+	Name     string       // Stream name used in snapshots and reports
+	Capacity int          // Ring buffer size used for percentiles, default 1000
+	Limiter  *TokenBucket // Optional rate limiter, may be shared by several streams
+
+	// NonNegative asserts that samples are never negative, as for latencies
+	// and sizes. Negative samples are rejected and counted, and percentiles
+	// are answered over the whole stream from log-scaled buckets with
+	// RelativeAccuracy (default 1%) relative error instead of the ring buffer.
+	NonNegative      bool
+	RelativeAccuracy float64
+
+	// DecayHalfLife enables GetDecayedPercentile, where a sample's weight
+	// halves every DecayHalfLife
+	DecayHalfLife time.Duration
+
+	// CallerSampleRate is the fraction (0..1) of AddNumber calls whose
+	// caller is recorded for CallSites
+	CallerSampleRate float64
+
+	// GapInterval enables gap handling for rate-like streams where absence
+	// means zero: every interval without samples is handled per GapPolicy
+	GapInterval time.Duration
+	GapPolicy   GapPolicy
+
+	// TrackJitter derives a second stream of |x_i - x_(i-1)|, see Jitter
+	TrackJitter bool
+
+	// Quantum rounds samples to the nearest multiple (e.g. 0.1 for 0.1ms
+	// granularity) before they enter the median heaps, window and
+	// digests. Sum, mean, min and max stay exact.
+	Quantum float64
+
+	// Unit the samples are recorded in, enabling QueryIn
+	Unit Unit
+
+	// AccuracyTarget enables compression auto-tuning for the lifetime and
+	// decayed digests: every AccuracyInterval (default one minute) digest
+	// percentiles over the window are checked against exact ones, and the
+	// compression is adjusted to keep the relative error under the target
+	AccuracyTarget   float64
+	AccuracyInterval time.Duration
+
+	// Strict checks internal invariants (heap balance and order, window
+	// occupancy, monotone percentiles) on every sample and panics with an
+	// *InvariantError on violation, or calls OnViolation when set. It is
+	// meant for tests and canaries.
+	Strict      bool
+	OnViolation func(error)
+
+	// SkewPolicy decides what AddNumberAt does with timestamps more than
+	// MaxFutureSkew ahead of the clock or more than MaxLateness behind it.
+	// A zero MaxFutureSkew or MaxLateness disables that check.
+	SkewPolicy    SkewPolicy
+	MaxFutureSkew time.Duration
+	MaxLateness   time.Duration
+
+	// Quantiles selects a sketch, e.g. TDigest(100) or DDSketch(0.01), that
+	// answers GetMedian and GetPercentile over the whole stream in bounded
+	// memory. By default the median comes from heaps holding every sample
+	// and percentiles from the window only.
+	Quantiles func() QuantileEstimator
+
+	// TimeWindow makes the window hold the samples of the last TimeWindow,
+	// by their AddNumberAt timestamp, instead of the last Capacity samples.
+	// Capacity still bounds memory and should cover the peak rate over
+	// TimeWindow, or the window covers less time than asked.
+	TimeWindow time.Duration
+
+	// IdleTTL hibernates streams that received no samples for IdleTTL:
+	// the window is released and other structures are trimmed, keeping
+	// lifetime aggregates. The next sample wakes the stream, starting an
+	// empty window.
+	IdleTTL time.Duration
+
+	// PublishEvery and PublishInterval publish an immutable snapshot after
+	// every PublishEvery samples and every PublishInterval, which readers
+	// get from Published without taking any lock
+	PublishEvery    int
+	PublishInterval time.Duration
+
+	// Smoothing sets Snapshot.Smoothed on published snapshots to an
+	// exponential moving average of the window percentiles across
+	// publications, with Smoothing the weight of the newest value (0 < s
+	// <= 1). It damps dashboard flapping from small windows; Window keeps
+	// the raw values, and registry exports carry both. Without
+	// PublishEvery or PublishInterval the stream publishes every 10
+	// seconds. 0 disables smoothing.
+	Smoothing float64
+
+	// CompactWindow stores window samples as float32, halving the window's
+	// memory for very large capacities. Window percentiles lose precision
+	// beyond about 7 significant digits; lifetime aggregates stay float64.
+	CompactWindow bool
+
+	// Instance identifies the process in snapshots, exports and merges,
+	// ProcessInstance() by default
+	Instance Instance
+
+	// Histogram counts every sample into buckets with these upper bounds,
+	// e.g. LinearBuckets(0, 10, 20), see GetHistogram
+	Histogram []float64
+
+	// WarmUp learns the histogram bounds from the first WarmUp samples
+	// when Histogram is not set: WarmUpBuckets (default 20) bounds, linear
+	// or exponential depending on the spread of the samples. The histogram
+	// appears once warm-up completes, holding the warm-up samples too.
+	// Checkpoints taken during warm-up omit the buffered samples. Learned
+	// bounds differ between streams, so streams that are merged should
+	// share explicit Histogram bounds instead.
+	WarmUp        int
+	WarmUpBuckets int
+
+	// EventWindow aggregates samples into tumbling windows by event time,
+	// the AddNumberAt timestamp. A window closes once the watermark, the
+	// latest event time minus AllowedLateness, passes its end; later
+	// samples for it are counted as late and left out of the window.
+	// OnWindowClose receives every closed window inside AddNumber and must
+	// not call back into the stream; the last RetainWindows (default 100)
+	// are kept, and checkpointed, for ClosedWindows and QueryRange.
+	EventWindow     time.Duration
+	AllowedLateness time.Duration
+	OnWindowClose   func(Rollup)
+	RetainWindows   int
+
+	// Now replaces the system clock for snapshot, checkpoint and skew
+	// times, e.g. with a fleet-synchronized clock
+	Now func() time.Time
+}
+
+// CachedStats for quick read-heavy queries
+type CachedStats struct {
+	mean       float64
+	median     float64
+	percentile map[int]float64
+	custom     map[string]float64 // Values of registered custom statistics
+	derived    map[string]float64 // Values of derived expressions
+}
+
+// clone copies the stats so callers never share maps with the worker
+func (cs CachedStats) clone() CachedStats {
+	cs.percentile = maps.Clone(cs.percentile)
+	cs.custom = maps.Clone(cs.custom)
+	cs.derived = maps.Clone(cs.derived)
+	return cs
+}
+
+// Stats is a point-in-time summary of a stream
+type Stats struct {
+	Count  int64   `json:"count"`
+	Sum    float64 `json:"sum"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"` // Sample standard deviation
+	Median float64 `json:"median"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	P95    float64 `json:"p95"`
+	P99    float64 `json:"p99"`
+}
+
+// Stats returns the current statistics
+func (ds *DataStreamStats) Stats() Stats {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	st := Stats{
+		Count: ds.count,
+		Sum:   ds.totalSum,
+		Min:   ds.minVal,
+		Max:   ds.maxVal,
+	}
+	if ds.count > 0 {
+		st.Mean = ds.totalSum / float64(ds.count)
+	}
+	st.StdDev = math.Sqrt(ds.moments.variance(1))
+	ds.minMaxLock.Unlock()
+
+	st.Median = ds.GetMedian()
+	st.P95 = ds.GetPercentile(95)
+	st.P99 = ds.GetPercentile(99)
+	return st
+}
+
+// defaultCapacity is the window size when Options.Capacity is not set,
+// including for zero DataStreamStats
+const defaultCapacity = 1000
+
+// NewDataStreamStats initializes DataStreamStats
+func NewDataStreamStats(capacity int) *DataStreamStats {
+	return NewDataStreamStatsWithOptions(Options{Capacity: capacity})
+}
+
+// NewDataStreamStatsWithOptions initializes DataStreamStats from Options
+func NewDataStreamStatsWithOptions(opts Options) *DataStreamStats {
+	ds := &DataStreamStats{}
+	ds.initOnce.Do(func() { ds.init(opts) })
+	return ds
+}
+
+// lazyInit initializes a zero DataStreamStats with default Options on
+// first use, so the type can be declared or embedded without a
+// constructor. Every exported method calls it first.
+func (ds *DataStreamStats) lazyInit() {
+	ds.initOnce.Do(func() { ds.init(Options{}) })
+}
+
+// init sets up internal structures and starts the background workers
+func (ds *DataStreamStats) init(opts Options) {
+	if opts.Capacity <= 0 {
+		opts.Capacity = defaultCapacity
+	}
+	if opts.Instance == (Instance{}) {
+		opts.Instance = ProcessInstance()
+	}
+
+	ds.id = newStreamID()
+	ds.minVal, ds.maxVal = math.Inf(1), math.Inf(-1)
+	ds.cachePercentile = make(map[int]float64)
+	ds.percentileChan = make(chan struct{}, 1)
+	ds.stopChan = make(chan struct{})
+	ds.cached = CachedStats{
+		percentile: make(map[int]float64),
+		custom:     make(map[string]float64),
+		derived:    make(map[string]float64),
+	}
+	ds.name = opts.Name
+	ds.opts = opts
+	ds.limiter = opts.Limiter
+	if opts.Quantiles != nil {
+		ds.quantiles = opts.Quantiles()
+	}
+	ds.recentData = NewRingBuffer(opts.Capacity)
+	if opts.CompactWindow {
+		ds.recentData = NewCompactRingBuffer(opts.Capacity)
+	}
+	ds.recentData.maxAge = opts.TimeWindow
+	ds.p95Bits.Store(math.Float64bits(math.NaN()))
+	ds.p99Bits.Store(math.Float64bits(math.NaN()))
+	ds.compression = lifetimeCompression
+	ds.epochDigest = newTDigest(ds.compression)
+	if opts.NonNegative {
+		ds.nonNegative = newLogBuckets(opts.RelativeAccuracy)
+	}
+	if opts.EventWindow > 0 {
+		ds.events = newEventWindows(opts.EventWindow, opts.AllowedLateness, opts.RetainWindows, opts.OnWindowClose)
+	}
+	if len(opts.Histogram) > 0 {
+		ds.histogram.Store(NewHistogram(opts.Histogram))
+	} else if opts.WarmUp > 0 {
+		ds.warmUp = make([]float64, 0, opts.WarmUp)
+	}
+	if opts.DecayHalfLife > 0 {
+		ds.decayed = NewDecayingQuantiles(opts.DecayHalfLife, 100)
+		ds.decayed.now = ds.now // Weights follow the stream clock, see Options.Now
+		ds.decayed.landmark = ds.now()
+	}
+	go ds.percentileWorker() // Start the background worker
+	if opts.GapInterval > 0 {
+		go ds.gapWorker(opts.GapInterval)
+	}
+	if opts.IdleTTL > 0 {
+		go ds.idleWorker(opts.IdleTTL)
+	}
+	if opts.PublishEvery > 0 || opts.PublishInterval > 0 || opts.Smoothing > 0 {
+		ds.publishChan = make(chan struct{}, 1)
+		go ds.publishWorker()
+	}
+	if opts.AccuracyTarget > 0 {
+		interval := opts.AccuracyInterval
+		if interval <= 0 {
+			interval = time.Minute
+		}
+		go ds.accuracyWorker(interval)
+	}
+	if opts.SkewPolicy == SkewSeparate {
+		ds.skewed = NewDataStreamStatsWithOptions(Options{
+			Name:     opts.Name + "/skewed",
+			Capacity: opts.Capacity,
+			Unit:     opts.Unit,
+		})
+	}
+	if opts.TrackJitter {
+		ds.jitter = NewDataStreamStatsWithOptions(Options{
+			Name:        opts.Name + "/jitter",
+			Capacity:    opts.Capacity,
+			NonNegative: true,
+		})
+	}
+}
+
+// sketched reports whether a quantile sketch replaces the heaps. Restore
+// replaces ds.quantiles under percentileLock but never changes whether it
+// is set, so this needs no lock.
+func (ds *DataStreamStats) sketched() bool {
+	return ds.opts.Quantiles != nil
+}
+
+// summaryOptions returns the Options of a stream that summarizes a subset
+// of ds's samples, named name: the same window, quantile engine, units
+// and clock, but no callbacks, background workers or admission control,
+// since ds already applied them to every sample
+func (ds *DataStreamStats) summaryOptions(name string) Options {
+	opts := Options{
+		Name:             name,
+		Capacity:         ds.opts.Capacity,
+		NonNegative:      ds.opts.NonNegative,
+		RelativeAccuracy: ds.opts.RelativeAccuracy,
+		DecayHalfLife:    ds.opts.DecayHalfLife,
+		Quantum:          ds.opts.Quantum,
+		Unit:             ds.opts.Unit,
+		Quantiles:        ds.opts.Quantiles,
+		TimeWindow:       ds.opts.TimeWindow,
+		CompactWindow:    ds.opts.CompactWindow,
+		Instance:         ds.opts.Instance,
+		Now:              ds.opts.Now,
+	}
+	if h := ds.histogram.Load(); h != nil {
+		opts.Histogram = h.bounds
+	}
+	return opts
+}
+
+// percentileWorker calculates percentiles in the background
+func (ds *DataStreamStats) percentileWorker() {
+	for {
+		select {
+		case <-ds.percentileChan:
+			ds.cachedLock.Lock()
+			ds.cached.percentile[95] = ds.GetPercentile(95)
+			ds.cached.percentile[99] = ds.GetPercentile(99)
+			ds.p95Bits.Store(math.Float64bits(ds.cached.percentile[95]))
+			ds.p99Bits.Store(math.Float64bits(ds.cached.percentile[99]))
+			ds.cacheUpdated = true
+			ds.cachedLock.Unlock()
+		case <-ds.stopChan:
+			return // Stop the worker when signaled
+		}
+	}
+}
+
+// AddNumber adds a number and updates statistics
+func (ds *DataStreamStats) AddNumber(num float64) {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	ds.add(time.Time{}, num)
+}
+
+// add records a best-effort sample; callers hold minMaxLock
+func (ds *DataStreamStats) add(at time.Time, num float64) {
+	ds.addLane(at, LaneBestEffort, num)
+}
+
+// addLane records num observed at time at, or now when at is zero;
+// callers hold minMaxLock
+func (ds *DataStreamStats) addLane(at time.Time, lane Lane, num float64) {
+	// A finalized stream is sealed
+	if ds.finalized != nil {
+		return
+	}
+	ds.hibernating = false
+
+	// Shed load when the rate limiter is exhausted, except critical samples
+	if ds.limiter != nil && !ds.limiter.Allow() && lane != LaneCritical {
+		ds.shedCount++
+		ds.lanes[lane].Shed++
+		return
+	}
+	ds.accept(at, lane, num)
+}
+
+// rollUp records a sample accepted by a child stream; the child already
+// admitted it, so the limiter is skipped and the two streams agree
+func (ds *DataStreamStats) rollUp(at time.Time, num float64) {
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	if ds.finalized != nil {
+		return
+	}
+	ds.hibernating = false
+	ds.accept(at, LaneBestEffort, num)
+}
+
+// accept records a sample that passed admission control; callers hold
+// minMaxLock
+func (ds *DataStreamStats) accept(at time.Time, lane Lane, num float64) {
+	// Reject samples outside a non-negative domain, they indicate an instrumentation bug
+	if ds.nonNegative != nil && num < 0 {
+		ds.negativeCount++
+		return
+	}
+
+	// NaN would poison min/max and the heap ordering
+	if math.IsNaN(num) {
+		ds.health.NaNInputs++
+		return
+	}
+	if ds.count == math.MaxInt64 {
+		ds.health.CountOverflow = true
+		return
+	}
+
+	// Update basic stats
+	if ds.count == 0 {
+		ds.firstSample = ds.now()
+	}
+	ds.totalSum += num
+	ds.count++
+	ds.generation.Add(1)
+	ds.lanes[lane].Accepted++
+	ds.moments.add(num)
+	if math.IsInf(ds.totalSum, 0) && !math.IsInf(num, 0) {
+		ds.health.SumOverflow = true
+	}
+	if num < ds.minVal {
+		ds.minVal = num
+	}
+	if num > ds.maxVal {
+		ds.maxVal = num
+	}
+
+	// Quantile structures see the quantized value, see Options.Quantum
+	q := ds.quantize(num)
+
+	// Maintain heaps unless a sketch answers the median
+	if !ds.sketched() {
+		ds.addToHeaps(q)
+	}
+
+	// Add to recent data (for percentiles)
+	ds.percentileLock.Lock()
+	ds.recentData.AddAt(at, q)
+	if ds.quantiles != nil {
+		ds.quantiles.Add(q)
+	}
+	if ds.nonNegative != nil {
+		ds.nonNegative.add(q)
+	}
+	var windowErr *InvariantError
+	if ds.opts.Strict {
+		windowErr = ds.checkWindow()
+	}
+	ds.percentileLock.Unlock()
+	if windowErr != nil {
+		ds.violated(windowErr)
+	}
+
+	if ds.decayed != nil {
+		if at.IsZero() {
+			ds.decayed.Add(q)
+		} else {
+			ds.decayed.AddAt(at, q)
+		}
+	}
+	if h := ds.histogram.Load(); h != nil {
+		h.Add(q)
+	} else if ds.warmUp != nil {
+		ds.warmUpAdd(q)
+	}
+	if ds.events != nil {
+		eventTime := at
+		if eventTime.IsZero() {
+			eventTime = ds.now()
+		}
+		ds.events.add(eventTime, num, q)
+	}
+
+	// Feed custom statistics
+	ds.pluginLock.Lock()
+	for _, st := range ds.plugins {
+		st.Observe(num)
+	}
+	ds.pluginLock.Unlock()
+
+	// Signal percentile update
+	select {
+	case ds.percentileChan <- struct{}{}:
+	default: // Avoid blocking if the channel is full
+	}
+
+	// Snapshot takes cachedLock, which must not be taken under minMaxLock,
+	// so publication happens in publishWorker
+	if ds.opts.PublishEvery > 0 && ds.count%int64(ds.opts.PublishEvery) == 0 {
+		select {
+		case ds.publishChan <- struct{}{}:
+		default:
+		}
+	}
+
+	if ds.opts.CallerSampleRate > 0 && rand.Float64() < ds.opts.CallerSampleRate {
+		ds.recordCaller(num)
+	}
+
+	if ds.jitter != nil {
+		if ds.count > 1 {
+			ds.jitter.AddNumber(math.Abs(num - ds.last))
+		}
+		ds.last = num
+	}
+
+	ds.epochLock.Lock()
+	if ds.epoch != nil {
+		ds.epoch.stats.AddNumber(num)
+	}
+	ds.epochDigest.add(q, 1)
+	ds.epochLock.Unlock()
+
+	// Roll the sample up into the parent stream
+	if ds.parent != nil {
+		ds.parent.rollUp(at, num)
+	}
+}
+
+// quantize rounds num to the configured quantum
+func (ds *DataStreamStats) quantize(num float64) float64 {
+	if ds.opts.Quantum <= 0 {
+		return num
+	}
+	return math.Round(num/ds.opts.Quantum) * ds.opts.Quantum
+}
+
+// addToHeaps inserts q into the median heaps
+func (ds *DataStreamStats) addToHeaps(q float64) {
+	ds.heapLock.Lock()
+	if ds.lower.Len() == 0 || q <= ds.lower.Peek() {
+		ds.lower.Push(q)
+		ds.balanceCounter++
+	} else {
+		ds.upper.Push(q)
+		ds.balanceCounter--
+	}
+	ds.balanceHeaps()
+	var heapErr *InvariantError
+	if ds.opts.Strict {
+		heapErr = ds.checkHeaps()
+	}
+	ds.heapLock.Unlock()
+	if heapErr != nil {
+		ds.violated(heapErr)
+	}
+}
+
+// Balance heaps for median calculation, keeping lower the same size as
+// upper or one larger
+func (ds *DataStreamStats) balanceHeaps() {
+	if ds.balanceCounter > 1 {
+		ds.upper.Push(ds.lower.Pop())
+		ds.balanceCounter -= 2
+	} else if ds.balanceCounter < 0 {
+		ds.lower.Push(ds.upper.Pop())
+		ds.balanceCounter += 2
+	}
+}
+
+// GetMean calculates the mean
+func (ds *DataStreamStats) GetMean() float64 {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+
+	if ds.count == 0 {
+		return 0
+	}
+	return ds.totalSum / float64(ds.count)
+}
+
+// GetMedian calculates the median
+func (ds *DataStreamStats) GetMedian() float64 {
+	ds.lazyInit()
+	if ds.sketched() {
+		return ds.GetPercentile(50)
+	}
+
+	ds.heapLock.Lock()
+	defer ds.heapLock.Unlock()
+
+	// count is guarded by minMaxLock, so check the heaps instead
+	if ds.lower.Len() == 0 {
+		return 0
+	}
+	if ds.lower.Len() > ds.upper.Len() {
+		return ds.lower.Peek()
+	}
+	return (ds.lower.Peek() + ds.upper.Peek()) / 2
+}
+
+// GetMin returns the minimum value
+func (ds *DataStreamStats) GetMin() float64 {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	return ds.minVal
+}
+
+// GetMax returns the maximum value
+func (ds *DataStreamStats) GetMax() float64 {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	return ds.maxVal
+}
+
+// GetPercentile calculates a given percentile
+func (ds *DataStreamStats) GetPercentile(p float64) float64 {
+	ds.lazyInit()
+	ds.percentileLock.Lock()
+	defer ds.percentileLock.Unlock()
+	return ds.percentileLocked(p)
+}
+
+// Percentile is GetPercentile with errors: ErrInvalidPercentile unless
+// 0 <= p <= 100 and ErrEmptyStream before the first sample
+func (ds *DataStreamStats) Percentile(p float64) (float64, error) {
+	ds.lazyInit()
+	if err := checkPercentile(p); err != nil {
+		return 0, err
+	}
+	if ds.Count() == 0 {
+		return 0, fmt.Errorf("percentile of stream %q: %w", ds.name, ErrEmptyStream)
+	}
+	return ds.GetPercentile(p), nil
+}
+
+// percentileLocked answers GetPercentile; callers hold percentileLock
+func (ds *DataStreamStats) percentileLocked(p float64) float64 {
+	if ds.quantiles != nil {
+		return ds.quantiles.Quantile(p)
+	}
+	if ds.nonNegative != nil {
+		return ds.nonNegative.quantile(p)
+	}
+
+	sorted := ds.recentData.GetSorted()
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	index := int(math.Ceil((p/100)*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index]
+}
+
+// windowValues returns a copy of the recent samples, oldest first
+func (ds *DataStreamStats) windowValues() []float64 {
+	ds.percentileLock.Lock()
+	defer ds.percentileLock.Unlock()
+	return ds.recentData.Values()
+}
+
+// GetDecayedPercentile returns a recency-weighted percentile, or the
+// plain percentile when Options.DecayHalfLife is not set
+func (ds *DataStreamStats) GetDecayedPercentile(p float64) float64 {
+	ds.lazyInit()
+	if ds.decayed == nil {
+		return ds.GetPercentile(p)
+	}
+	return ds.decayed.Quantile(p)
+}
+
+// GetDecayedMean returns a recency-weighted mean, or the plain mean when
+// Options.DecayHalfLife is not set
+func (ds *DataStreamStats) GetDecayedMean() float64 {
+	ds.lazyInit()
+	if ds.decayed == nil {
+		return ds.GetMean()
+	}
+	return ds.decayed.Mean()
+}
+
+// GetCachedStats returns cached stats if available
+func (ds *DataStreamStats) GetCachedStats() CachedStats {
+	ds.lazyInit()
+	ds.cachedLock.Lock()
+	defer ds.cachedLock.Unlock()
+
+	if !ds.cacheUpdated {
+		ds.cached.mean = ds.GetMean()
+		ds.cached.median = ds.GetMedian()
+		ds.cached.percentile[95] = ds.GetPercentile(95)
+		ds.cached.percentile[99] = ds.GetPercentile(99)
+		ds.p95Bits.Store(math.Float64bits(ds.cached.percentile[95]))
+		ds.p99Bits.Store(math.Float64bits(ds.cached.percentile[99]))
+		ds.cacheUpdated = true
+	}
+
+	// The worker only refreshes percentiles, so custom statistics and
+	// derived fields are evaluated on every call
+	ds.pluginLock.Lock()
+	for _, st := range ds.plugins {
+		ds.cached.custom[st.Name()] = st.Value()
+	}
+	ds.pluginLock.Unlock()
+	ds.evalDerived()
+	return ds.cached.clone()
+}
+
+// GetShedCount returns the number of samples dropped by the rate limiter
+func (ds *DataStreamStats) GetShedCount() int64 {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	return ds.shedCount
+}
+
+// GetNegativeCount returns the number of negative samples rejected in non-negative mode
+func (ds *DataStreamStats) GetNegativeCount() int64 {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	return ds.negativeCount
+}
+
+// Stop stops background workers
+func (ds *DataStreamStats) Stop() {
+	ds.lazyInit()
+	ds.stopOnce.Do(func() { close(ds.stopChan) })
+	if ds.jitter != nil {
+		ds.jitter.Stop()
+	}
+	if ds.skewed != nil {
+		ds.skewed.Stop()
+	}
+	ds.epochLock.Lock()
+	if ds.epoch != nil {
+		ds.epoch.stats.Stop()
+	}
+	ds.epochLock.Unlock()
+}
+
+// MinHeap is a min-heap for container/heap.
+//
+// Deprecated: use container.MinHeap, which does not box values.
+type MinHeap []float64
+
+func (h MinHeap) Len() int           { return len(h) }
+func (h MinHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h MinHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h MinHeap) Peek() float64      { return h[0] }
+func (h *MinHeap) Push(x interface{}) {
+	*h = append(*h, x.(float64))
+}
+func (h *MinHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[0 : n-1]
+	return x
+}
+
+// MaxHeap is a max-heap for container/heap.
+//
+// Deprecated: use container.MaxHeap, which does not box values.
+type MaxHeap []float64
+
+func (h MaxHeap) Len() int           { return len(h) }
+func (h MaxHeap) Less(i, j int) bool { return h[i] > h[j] }
+func (h MaxHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h MaxHeap) Peek() float64      { return h[0] }
+func (h *MaxHeap) Push(x interface{}) {
+	*h = append(*h, x.(float64))
+}
+func (h *MaxHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[0 : n-1]
+	return x
+}
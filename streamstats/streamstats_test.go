@@ -0,0 +1,212 @@
+package streamstats
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func TestBasicStats(t *testing.T) {
+	ds := NewDataStreamStats(1000)
+	defer ds.Stop()
+
+	for i := 1; i <= 100; i++ {
+		ds.AddNumber(float64(i))
+	}
+
+	if got := ds.GetMean(); got != 50.5 {
+		t.Errorf("GetMean() = %v, want 50.5", got)
+	}
+	if got := ds.GetMin(); got != 1 {
+		t.Errorf("GetMin() = %v, want 1", got)
+	}
+	if got := ds.GetMax(); got != 100 {
+		t.Errorf("GetMax() = %v, want 100", got)
+	}
+	if got := ds.GetMedian(); got != 50.5 {
+		t.Errorf("GetMedian() = %v, want 50.5", got)
+	}
+	if got := ds.GetPercentile(95); got != 95 {
+		t.Errorf("GetPercentile(95) = %v, want 95", got)
+	}
+}
+
+func TestEmptyStream(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+
+	if got := ds.GetMean(); got != 0 {
+		t.Errorf("GetMean() = %v, want 0", got)
+	}
+	if got := ds.GetMedian(); got != 0 {
+		t.Errorf("GetMedian() = %v, want 0", got)
+	}
+	if got := ds.GetPercentile(50); got != 0 {
+		t.Errorf("GetPercentile(50) = %v, want 0", got)
+	}
+}
+
+func TestMedianMatchesSorted(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Strict: true})
+	defer ds.Stop()
+
+	r := rand.New(rand.NewSource(1))
+	var all []float64
+	for i := 0; i < 500; i++ {
+		v := r.NormFloat64()
+		all = append(all, v)
+		ds.AddNumber(v)
+
+		sorted := slices.Clone(all)
+		sort.Float64s(sorted)
+		n := len(sorted)
+		want := sorted[n/2]
+		if n%2 == 0 {
+			want = (sorted[n/2-1] + sorted[n/2]) / 2
+		}
+		if got := ds.GetMedian(); got != want {
+			t.Fatalf("after %d samples GetMedian() = %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestPercentileUsesWindow(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+
+	for i := 1; i <= 100; i++ {
+		ds.AddNumber(float64(i))
+	}
+	// Only 91..100 remain in the window
+	if got := ds.GetPercentile(0); got != 91 {
+		t.Errorf("GetPercentile(0) = %v, want 91", got)
+	}
+	if got := ds.GetPercentile(100); got != 100 {
+		t.Errorf("GetPercentile(100) = %v, want 100", got)
+	}
+}
+
+func TestRejectsNaN(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+
+	ds.AddNumber(1)
+	ds.AddNumber(math.NaN())
+
+	if got := ds.Count(); got != 1 {
+		t.Errorf("Count() = %d, want 1", got)
+	}
+	if h := ds.GetHealth(); h.NaNInputs != 1 || h.OK() {
+		t.Errorf("GetHealth() = %+v, want one NaN input", h)
+	}
+}
+
+func TestStats(t *testing.T) {
+	ds := NewDataStreamStats(100)
+	defer ds.Stop()
+
+	for _, v := range []float64{4, 1, 3, 2} {
+		ds.AddNumber(v)
+	}
+	want := Stats{Count: 4, Sum: 10, Mean: 2.5, StdDev: math.Sqrt(5.0 / 3), Median: 2.5, Min: 1, Max: 4, P95: 4, P99: 4}
+	if got := ds.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRingBuffer(t *testing.T) {
+	for _, rb := range []*RingBuffer{NewRingBuffer(3), NewCompactRingBuffer(3)} {
+		for i := 1; i <= 2; i++ {
+			rb.Add(float64(i))
+		}
+		if got := rb.Values(); !slices.Equal(got, []float64{1, 2}) {
+			t.Errorf("Values() before wrapping = %v, want [1 2]", got)
+		}
+
+		for i := 3; i <= 5; i++ {
+			rb.Add(float64(i))
+		}
+		if got := rb.Values(); !slices.Equal(got, []float64{3, 4, 5}) {
+			t.Errorf("Values() after wrapping = %v, want [3 4 5]", got)
+		}
+
+		rb.Add(0)
+		if got := rb.GetSorted(); !slices.Equal(got, []float64{0, 4, 5}) {
+			t.Errorf("GetSorted() = %v, want [0 4 5]", got)
+		}
+
+		rb.release()
+		rb.Add(7)
+		if got := rb.Values(); !slices.Equal(got, []float64{7}) {
+			t.Errorf("Values() after release = %v, want [7]", got)
+		}
+	}
+}
+
+func TestZeroValue(t *testing.T) {
+	// Embedded without a constructor, as in a user's struct
+	var server struct {
+		name    string
+		latency DataStreamStats
+	}
+	ds := &server.latency
+	defer ds.Stop()
+
+	for i := 1; i <= 100; i++ {
+		ds.AddNumber(float64(i))
+	}
+	if got := ds.GetMean(); got != 50.5 {
+		t.Errorf("GetMean() = %v, want 50.5", got)
+	}
+	if got := ds.GetMin(); got != 1 {
+		t.Errorf("GetMin() = %v, want 1", got)
+	}
+	if got := ds.GetMedian(); got != 50.5 {
+		t.Errorf("GetMedian() = %v, want 50.5", got)
+	}
+	if got := ds.GetPercentile(95); got != 95 {
+		t.Errorf("GetPercentile(95) = %v, want 95", got)
+	}
+	if snap := ds.Snapshot(); snap.Window.Capacity != defaultCapacity || snap.Source.Stream == "" {
+		t.Errorf("Snapshot() capacity %d, stream %q", snap.Window.Capacity, snap.Source.Stream)
+	}
+
+	// Every reader works before the first sample
+	var empty DataStreamStats
+	defer empty.Stop()
+	if got := empty.GetMean(); got != 0 {
+		t.Errorf("GetMean() of zero value = %v, want 0", got)
+	}
+	if _, err := empty.Percentile(50); !errors.Is(err, ErrEmptyStream) {
+		t.Errorf("Percentile() of zero value error = %v, want ErrEmptyStream", err)
+	}
+
+	var other DataStreamStats
+	defer other.Stop()
+	other.AddBatch([]float64{1000})
+	if err := ds.Merge(&other); err != nil {
+		t.Fatalf("Merge() of zero value: %v", err)
+	}
+	if got := ds.GetMax(); got != 1000 {
+		t.Errorf("GetMax() after merge = %v, want 1000", got)
+	}
+
+	var unused DataStreamStats
+	unused.Stop()
+}
+
+func TestZeroRingBuffer(t *testing.T) {
+	var rb RingBuffer
+	if got := rb.Values(); len(got) != 0 {
+		t.Errorf("Values() of zero value = %v, want none", got)
+	}
+	for i := 0; i < defaultCapacity+5; i++ {
+		rb.Add(float64(i))
+	}
+	if got := rb.Values(); len(got) != defaultCapacity || got[0] != 5 {
+		t.Errorf("Values() holds %d values from %v, want %d from 5", len(got), got[0], defaultCapacity)
+	}
+}
@@ -0,0 +1,191 @@
+package streamstats
+
+import (
+	"flag"
+	"io"
+	"math/rand"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+var stressDuration = flag.Duration("stress.duration", time.Second, "how long TestStressAllMethods runs")
+
+// countStat is a trivial Statistic used to exercise the plugin path
+type countStat struct {
+	mu sync.Mutex
+	n  float64
+}
+
+func (c *countStat) Name() string { return "count_stat" }
+func (c *countStat) Observe(float64) {
+	c.mu.Lock()
+	c.n++
+	c.mu.Unlock()
+}
+func (c *countStat) Value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n
+}
+func (c *countStat) Merge(Statistic) error { return nil }
+func (c *countStat) Reset() {
+	c.mu.Lock()
+	c.n = 0
+	c.mu.Unlock()
+}
+
+// TestStressAllMethods calls every public method concurrently and is meant
+// to be run under the race detector:
+//
+//	go test -race -run TestStressAllMethods -stress.duration 1m
+func TestStressAllMethods(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{
+		Name:             "stress",
+		Capacity:         500,
+		Limiter:          NewTokenBucket(1e6, 1000),
+		DecayHalfLife:    time.Second,
+		CallerSampleRate: 0.1,
+		TrackJitter:      true,
+		AccuracyTarget:   0.01,
+		Strict:           true,
+		MaxLateness:      time.Second,
+		IdleTTL:          time.Millisecond,
+		PublishEvery:     100,
+		Histogram:        ExponentialBuckets(0.01, 2, 12),
+		EventWindow:      10 * time.Millisecond,
+	})
+	defer ds.Stop()
+	ds.RegisterStatistic(&countStat{})
+	ds.DetectAnomalies(AnomalyConfig{Rule: IQR, Window: 20})
+	ds.RegisterStatistic(NewDriftDetector(200, 0.5, nil))
+	ds.DefineDerived("spread", "p99 - p50")
+	ds.SetExpectedTotal(1e9)
+	ds.SetEpoch("e0")
+	child := ds.NewChild("child")
+	defer child.Stop()
+	ratio := NewRatioTracker(10 * time.Millisecond)
+	ds.AttachRatio(ratio)
+	other := NewDataStreamStats(100)
+	defer other.Stop()
+	peer := NewDataStreamStatsWithOptions(Options{Capacity: 100, DecayHalfLife: time.Second, TrackJitter: true, Histogram: ExponentialBuckets(0.01, 2, 12)})
+	defer peer.Stop()
+
+	ops := []func(r *rand.Rand){
+		func(r *rand.Rand) { ds.AddNumber(r.ExpFloat64()) },
+		func(r *rand.Rand) { child.AddNumber(r.ExpFloat64()) },
+		func(r *rand.Rand) { ds.AddNumberWithExemplar(r.ExpFloat64(), strconv.Itoa(r.Int())) },
+		func(r *rand.Rand) { ds.GetMean() },
+		func(r *rand.Rand) { ds.GetMedian() },
+		func(r *rand.Rand) { ds.GetMin() },
+		func(r *rand.Rand) { ds.GetStdDev() },
+		func(r *rand.Rand) { ds.GetSkewness() },
+		func(r *rand.Rand) { ds.GetKurtosis() },
+		func(r *rand.Rand) { ds.GetMax() },
+		func(r *rand.Rand) { ds.GetPercentile(r.Float64() * 100) },
+		func(r *rand.Rand) { ds.GetDecayedPercentile(99) },
+		func(r *rand.Rand) { ds.GetCachedStats() },
+		func(r *rand.Rand) { ds.GetShedCount() },
+		func(r *rand.Rand) { ds.GetNegativeCount() },
+		func(r *rand.Rand) { ds.GetStatistic("count_stat") },
+		func(r *rand.Rand) { ds.MergeStatistics(other) },
+		func(r *rand.Rand) { peer.AddNumber(r.ExpFloat64()) },
+		func(r *rand.Rand) { ds.Merge(peer) },
+		func(r *rand.Rand) { ds.MarshalBinary() },
+		func(r *rand.Rand) { ds.MergedSources() },
+		func(r *rand.Rand) { ds.GetHistogram() },
+		func(r *rand.Rand) { ds.AccuracyReport() },
+		func(r *rand.Rand) { ds.Watermark() },
+		func(r *rand.Rand) { ds.ClosedWindows() },
+		func(r *rand.Rand) { ds.CountBetween(r.Float64(), 1+r.Float64()) },
+		func(r *rand.Rand) { ds.SumBetween(r.Float64(), 1+r.Float64()) },
+		func(r *rand.Rand) { ds.Generation() },
+		func(r *rand.Rand) { ds.Annotate("note") },
+		func(r *rand.Rand) {
+			for range ds.Window() {
+			}
+		},
+		func(r *rand.Rand) {
+			for range ds.Buckets() {
+			}
+		},
+		func(r *rand.Rand) { ds.QueryRange(time.Now().Add(-time.Second), time.Time{}, 99) },
+		func(r *rand.Rand) { ds.Annotations(time.Time{}, time.Now()) },
+		func(r *rand.Rand) { ds.AddBatch([]float64{r.ExpFloat64(), r.ExpFloat64()}) },
+		func(r *rand.Rand) { ds.Snapshot() },
+		func(r *rand.Rand) { ds.DebugState() },
+		func(r *rand.Rand) { ds.DumpState(io.Discard) },
+		func(r *rand.Rand) { ds.FitDistribution(LogNormal) },
+		func(r *rand.Rand) { ds.QQPoints(Exponential, map[string]float64{"lambda": 1}) },
+		func(r *rand.Rand) { ds.FitTailAbove(90) },
+		func(r *rand.Rand) { ds.GetProgress() },
+		func(r *rand.Rand) { ds.Children() },
+		func(r *rand.Rand) { ds.Exemplars() },
+		func(r *rand.Rand) { ds.ExemplarFor(99) },
+		func(r *rand.Rand) { ds.CallSites() },
+		func(r *rand.Rand) { ds.TailCallSites() },
+		func(r *rand.Rand) { ds.Epochs() },
+		func(r *rand.Rand) { ds.CompareEpochs("e0", "e0") },
+		func(r *rand.Rand) { ds.IsFinalized() },
+		func(r *rand.Rand) { ds.SetBaseline(child.Snapshot()) },
+		func(r *rand.Rand) { ds.ClearBaseline() },
+		func(r *rand.Rand) { ds.Count() },
+		func(r *rand.Rand) { ds.GetHealth() },
+		func(r *rand.Rand) { ds.GetGapCount() },
+		func(r *rand.Rand) { ds.ApproxLifetimePercentile(99) },
+		func(r *rand.Rand) { ds.GetRunStats() },
+		func(r *rand.Rand) { ds.Jitter().GetPercentile(95) },
+		func(r *rand.Rand) { ds.MemoryUsage() },
+		func(r *rand.Rand) {
+			ds.AddNumberAt(time.Now().Add(time.Duration(r.NormFloat64()*float64(time.Second))), r.ExpFloat64())
+		},
+		func(r *rand.Rand) { ds.GetSkewCounts() },
+		func(r *rand.Rand) { ds.IsHibernating() },
+		func(r *rand.Rand) { ds.GetWindowStats() },
+		func(r *rand.Rand) { ds.GetDecayedMean() },
+		func(r *rand.Rand) { ds.AddNumberLane(LaneCritical, r.ExpFloat64()) },
+		func(r *rand.Rand) { ds.GetLaneCounts(LaneCritical) },
+		func(r *rand.Rand) { ds.Published() },
+		func(r *rand.Rand) { ds.PublishedAge() },
+		func(r *rand.Rand) { MergeRollups(ds.Rollups()...).Percentile(99) },
+		func(r *rand.Rand) { ratio.Record(r.Intn(100) != 0) },
+		func(r *rand.Rand) { ds.GetCompression() },
+		func(r *rand.Rand) {
+			if r.Intn(1000) == 0 {
+				ds.tuneCompression()
+			}
+		},
+		func(r *rand.Rand) {
+			if r.Intn(1000) == 0 {
+				ds.SetEpoch(strconv.Itoa(r.Int()))
+			}
+		},
+		func(r *rand.Rand) {
+			if r.Intn(1000) == 0 {
+				ds.ResetStatistics()
+			}
+		},
+	}
+	ops = append(ops, templateOps(ds, child)...)
+
+	deadline := time.Now().Add(*stressDuration)
+	var wg sync.WaitGroup
+	for g := 0; g < 2*len(ops); g++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			for time.Now().Before(deadline) {
+				ops[r.Intn(len(ops))](r)
+			}
+		}(int64(g))
+	}
+	wg.Wait()
+
+	// Finalize last so it races with nothing but still gets covered
+	ds.Finalize()
+	if !ds.IsFinalized() {
+		t.Fatal("stream not finalized")
+	}
+}
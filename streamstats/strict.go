@@ -0,0 +1,60 @@
+package streamstats
+
+import "fmt"
+
+// strictPercentiles must be non-decreasing in Strict mode
+var strictPercentiles = []float64{50, 90, 95, 99, 100}
+
+// InvariantError describes an internal invariant violated in Strict mode
+type InvariantError struct {
+	Stream    string
+	Invariant string
+	Detail    string
+}
+
+func (e *InvariantError) Error() string {
+	return fmt.Sprintf("stream %q: invariant %q violated: %s", e.Stream, e.Invariant, e.Detail)
+}
+
+// invariant builds an InvariantError for the stream
+func (ds *DataStreamStats) invariant(name, format string, args ...any) *InvariantError {
+	return &InvariantError{Stream: ds.name, Invariant: name, Detail: fmt.Sprintf(format, args...)}
+}
+
+// checkHeaps verifies the median heaps; callers hold heapLock
+func (ds *DataStreamStats) checkHeaps() *InvariantError {
+	if diff := ds.lower.Len() - ds.upper.Len(); diff != ds.balanceCounter || diff < 0 || diff > 1 {
+		return ds.invariant("heap balance", "lower has %d values, upper %d, counter %d", ds.lower.Len(), ds.upper.Len(), ds.balanceCounter)
+	}
+	if ds.lower.Len() > 0 && ds.upper.Len() > 0 && ds.lower.Peek() > ds.upper.Peek() {
+		return ds.invariant("heap order", "lower max %v above upper min %v", ds.lower.Peek(), ds.upper.Peek())
+	}
+	return nil
+}
+
+// checkWindow verifies the window and percentile engine; callers hold
+// percentileLock
+func (ds *DataStreamStats) checkWindow() *InvariantError {
+	rb := ds.recentData
+	if rb.size > rb.cap || rb.head >= rb.cap {
+		return ds.invariant("window occupancy", "size %d head %d with capacity %d", rb.size, rb.head, rb.cap)
+	}
+	prev := 0.0
+	for i, p := range strictPercentiles {
+		v := ds.percentileLocked(p)
+		if i > 0 && v < prev {
+			return ds.invariant("monotone percentiles", "p%v = %v below p%v = %v", p, v, strictPercentiles[i-1], prev)
+		}
+		prev = v
+	}
+	return nil
+}
+
+// violated panics with err, or passes it to Options.OnViolation when set
+func (ds *DataStreamStats) violated(err *InvariantError) {
+	if ds.opts.OnViolation != nil {
+		ds.opts.OnViolation(err)
+		return
+	}
+	panic(err)
+}
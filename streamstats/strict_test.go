@@ -0,0 +1,64 @@
+package streamstats
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStrictViolation(t *testing.T) {
+	var got []*InvariantError
+	ds := NewDataStreamStatsWithOptions(Options{
+		Name:     "orders",
+		Capacity: 10,
+		Strict:   true,
+		OnViolation: func(err error) {
+			var ie *InvariantError
+			if !errors.As(err, &ie) {
+				t.Errorf("OnViolation(%v), want an *InvariantError", err)
+			}
+			got = append(got, ie)
+		},
+	})
+	defer ds.Stop()
+
+	for i := 0; i < 100; i++ {
+		ds.AddNumber(float64(i % 7))
+	}
+	if len(got) != 0 {
+		t.Fatalf("healthy stream reported %v", got)
+	}
+
+	// Corrupt the heap bookkeeping the way a bug would
+	ds.heapLock.Lock()
+	ds.balanceCounter = 5
+	ds.heapLock.Unlock()
+	ds.AddNumber(1)
+
+	if len(got) == 0 {
+		t.Fatal("corrupted heaps were not reported")
+	}
+	if got[0].Stream != "orders" || got[0].Invariant != "heap balance" {
+		t.Errorf("violation = %+v, want heap balance on orders", got[0])
+	}
+	if msg := got[0].Error(); !strings.Contains(msg, `invariant "heap balance" violated`) {
+		t.Errorf("Error() = %q", msg)
+	}
+}
+
+func TestStrictPanics(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Strict: true})
+	defer ds.Stop()
+	ds.AddNumber(1)
+	ds.heapLock.Lock()
+	ds.balanceCounter = -3
+	ds.heapLock.Unlock()
+
+	defer func() {
+		var ie *InvariantError
+		if err, ok := recover().(error); !ok || !errors.As(err, &ie) {
+			t.Errorf("AddNumber on corrupted heaps recovered %v, want an *InvariantError panic", err)
+		}
+	}()
+	ds.AddNumber(2)
+}
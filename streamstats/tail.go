@@ -0,0 +1,93 @@
+package streamstats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// minExceedances is the smallest number of samples above the threshold
+// needed for a meaningful tail fit
+const minExceedances = 10
+
+// TailFit is a generalized Pareto fit of the samples above a threshold
+// (peaks over threshold), used to extrapolate beyond the observed tail
+type TailFit struct {
+	Threshold   float64
+	Shape       float64 // xi; > 0 heavy tail, 0 exponential, < 0 bounded
+	Scale       float64 // sigma
+	Exceedances int
+	N           int
+}
+
+// FitTail fits a generalized Pareto distribution to the recent samples
+// exceeding threshold, using probability-weighted moments
+func (ds *DataStreamStats) FitTail(threshold float64) (TailFit, error) {
+	ds.lazyInit()
+	return fitTail(ds.windowValues(), threshold)
+}
+
+// FitTailAbove is FitTail with the threshold at the pth percentile of the window
+func (ds *DataStreamStats) FitTailAbove(p float64) (TailFit, error) {
+	ds.lazyInit()
+	if err := checkPercentile(p); err != nil {
+		return TailFit{}, err
+	}
+	values := ds.windowValues()
+	sort.Float64s(values)
+	return fitTail(values, sortedPercentile(values, p))
+}
+
+func fitTail(values []float64, threshold float64) (TailFit, error) {
+	var excess []float64
+	for _, v := range values {
+		if v > threshold {
+			excess = append(excess, v-threshold)
+		}
+	}
+	if len(values) == 0 {
+		return TailFit{}, fmt.Errorf("tail fit: %w", ErrEmptyStream)
+	}
+	if len(excess) < minExceedances {
+		return TailFit{}, fmt.Errorf("need at least %d samples above %v, have %d: %w", minExceedances, threshold, len(excess), ErrInsufficientSamples)
+	}
+	sort.Float64s(excess)
+
+	// Hosking & Wallis PWM estimators
+	n := float64(len(excess))
+	var a0, a1 float64
+	for i, y := range excess {
+		p := (float64(i+1) - 0.35) / n
+		a0 += y
+		a1 += (1 - p) * y
+	}
+	a0 /= n
+	a1 /= n
+	if a0 == 2*a1 {
+		return TailFit{}, fmt.Errorf("degenerate exceedances above %v", threshold)
+	}
+
+	return TailFit{
+		Threshold:   threshold,
+		Shape:       2 - a0/(a0-2*a1),
+		Scale:       2 * a0 * a1 / (a0 - 2*a1),
+		Exceedances: len(excess),
+		N:           len(values),
+	}, nil
+}
+
+// Quantile estimates the pth percentile (e.g. 99.99) from the tail model.
+// Only percentiles above the threshold are meaningful.
+func (f TailFit) Quantile(p float64) float64 {
+	// Probability of exceeding the target relative to exceeding the threshold
+	r := float64(f.N) / float64(f.Exceedances) * (1 - p/100)
+	if math.Abs(f.Shape) < 1e-9 {
+		return f.Threshold - f.Scale*math.Log(r)
+	}
+	return f.Threshold + f.Scale/f.Shape*(math.Pow(r, -f.Shape)-1)
+}
+
+// ReturnLevel estimates the value exceeded on average once every m samples
+func (f TailFit) ReturnLevel(m float64) float64 {
+	return f.Quantile(100 * (1 - 1/m))
+}
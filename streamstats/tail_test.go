@@ -0,0 +1,47 @@
+package streamstats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestFitTail(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+
+	// Exponential tails have shape 0
+	exp := fedStream(t, 50000, r.ExpFloat64)
+	fit, err := exp.FitTailAbove(90)
+	if err != nil {
+		t.Fatalf("FitTailAbove: %v", err)
+	}
+	if math.Abs(fit.Shape) > 0.1 || math.Abs(fit.Scale-1) > 0.1 {
+		t.Errorf("exponential tail shape %v, scale %v; want about 0 and 1", fit.Shape, fit.Scale)
+	}
+	if fit.N != 50000 || fit.Exceedances < 4900 || fit.Exceedances > 5100 {
+		t.Errorf("fit over %d samples with %d exceedances, want 50000 and about 5000", fit.N, fit.Exceedances)
+	}
+	if got, want := fit.Quantile(99.99), math.Log(1e4); math.Abs(got-want) > 1 {
+		t.Errorf("Quantile(99.99) = %v, want about %v", got, want)
+	}
+	if got, want := fit.ReturnLevel(1e4), fit.Quantile(99.99); math.Abs(got-want) > 1e-9 {
+		t.Errorf("ReturnLevel(1e4) = %v, want Quantile(99.99) = %v", got, want)
+	}
+
+	// Pareto tails with alpha 2 have shape 1/2
+	pareto := fedStream(t, 50000, func() float64 { return 1 / math.Sqrt(1-r.Float64()) })
+	fit, err = pareto.FitTail(2)
+	if err != nil {
+		t.Fatalf("FitTail: %v", err)
+	}
+	if math.Abs(fit.Shape-0.5) > 0.1 {
+		t.Errorf("pareto tail shape %v, want about 0.5", fit.Shape)
+	}
+}
+
+func TestFitTailErrors(t *testing.T) {
+	ds := fedStream(t, 100, func() float64 { return 1 })
+	if _, err := ds.FitTail(5); err == nil {
+		t.Error("FitTail above every sample succeeded, want error")
+	}
+}
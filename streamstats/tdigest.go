@@ -0,0 +1,138 @@
+package streamstats
+
+import (
+	"math"
+	"sort"
+)
+
+// centroid is a cluster of samples summarized by mean and total weight
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+// tdigest is a merging t-digest over weighted samples. Memory is bounded
+// by the compression parameter, and quantile error is smallest in the tails.
+type tdigest struct {
+	compression float64
+	centroids   []centroid
+	buffer      []centroid
+	total       float64
+}
+
+// newTDigest creates a digest; larger compression is more accurate
+func newTDigest(compression float64) *tdigest {
+	if compression <= 0 {
+		compression = 100
+	}
+	return &tdigest{compression: compression}
+}
+
+// add records v with the given weight
+func (td *tdigest) add(v, weight float64) {
+	td.buffer = append(td.buffer, centroid{mean: v, weight: weight})
+	td.total += weight
+	if len(td.buffer) >= int(5*td.compression) {
+		td.compress()
+	}
+}
+
+// k is the arcsine scale function bounding centroid sizes near the tails
+func (td *tdigest) k(q float64) float64 {
+	return td.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// kInv is the inverse of k
+func (td *tdigest) kInv(k float64) float64 {
+	return (math.Sin(2*math.Pi*k/td.compression) + 1) / 2
+}
+
+// compress merges buffered samples into the centroid list
+func (td *tdigest) compress() {
+	if len(td.buffer) == 0 {
+		return
+	}
+	all := append(td.centroids, td.buffer...)
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+
+	out := make([]centroid, 0, len(td.centroids)+1)
+	q0 := 0.0
+	qLimit := td.kInv(td.k(q0) + 1)
+	cur := all[0]
+	for _, next := range all[1:] {
+		q := q0 + (cur.weight+next.weight)/td.total
+		if q <= qLimit {
+			w := cur.weight + next.weight
+			cur.mean += (next.mean - cur.mean) * next.weight / w
+			cur.weight = w
+			continue
+		}
+		out = append(out, cur)
+		q0 += cur.weight / td.total
+		qLimit = td.kInv(td.k(q0) + 1)
+		cur = next
+	}
+	td.centroids = append(out, cur)
+	td.buffer = td.buffer[:0]
+}
+
+// quantile returns the pth percentile by interpolating between centroids
+func (td *tdigest) quantile(p float64) float64 {
+	td.compress()
+	if len(td.centroids) == 0 {
+		return 0
+	}
+	if len(td.centroids) == 1 {
+		return td.centroids[0].mean
+	}
+
+	target := p / 100 * td.total
+	cum := 0.0
+	for i, c := range td.centroids {
+		mid := cum + c.weight/2
+		if target < mid {
+			if i == 0 {
+				return c.mean
+			}
+			prev := td.centroids[i-1]
+			prevMid := cum - prev.weight/2
+			return prev.mean + (c.mean-prev.mean)*(target-prevMid)/(mid-prevMid)
+		}
+		cum += c.weight
+	}
+	return td.centroids[len(td.centroids)-1].mean
+}
+
+// scale multiplies every weight by f, used for decay renormalization
+func (td *tdigest) scale(f float64) {
+	for i := range td.centroids {
+		td.centroids[i].weight *= f
+	}
+	for i := range td.buffer {
+		td.buffer[i].weight *= f
+	}
+	td.total *= f
+}
+
+// merge adds every centroid of other into td
+func (td *tdigest) merge(other *tdigest) {
+	other.compress()
+	for _, c := range other.centroids {
+		td.add(c.mean, c.weight)
+	}
+}
+
+// mean returns the weighted mean of all samples
+func (td *tdigest) mean() float64 {
+	if td.total == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, c := range td.centroids {
+		sum += c.mean * c.weight
+	}
+	for _, c := range td.buffer {
+		sum += c.mean * c.weight
+	}
+	return sum / td.total
+}
@@ -0,0 +1,42 @@
+//go:build !tinygo
+
+package streamstats
+
+import (
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestTinyGoBuild builds the package the way TinyGo's wasip1 target sees
+// it and checks it leaves out the packages TinyGo cannot build
+func TestTinyGoBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the package for wasip1")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go tool")
+	}
+	env := append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	build := exec.Command(goTool, "build", "-tags", "tinygo", ".")
+	build.Env = env
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("GOOS=wasip1 GOARCH=wasm go build -tags tinygo: %v\n%s", err, out)
+	}
+
+	list := exec.Command(goTool, "list", "-tags", "tinygo", "-deps", ".")
+	list.Env = env
+	out, err := list.Output()
+	if err != nil {
+		t.Fatalf("go list -deps: %v", err)
+	}
+	deps := strings.Fields(string(out))
+	for _, pkg := range []string{"text/template", "html/template", "net/http", "runtime/metrics"} {
+		if slices.Contains(deps, pkg) {
+			t.Errorf("tinygo build depends on %s", pkg)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package streamstats
+
+import "fmt"
+
+// Unit is a measurement unit of a stream. Values convert between units
+// of the same dimension, e.g. seconds to milliseconds.
+type Unit struct {
+	Name      string
+	dimension string
+	perBase   float64 // Size of one unit in the dimension's base unit
+}
+
+var (
+	Nanosecond  = Unit{"ns", "time", 1e-9}
+	Microsecond = Unit{"us", "time", 1e-6}
+	Millisecond = Unit{"ms", "time", 1e-3}
+	Second      = Unit{"s", "time", 1}
+	Minute      = Unit{"min", "time", 60}
+
+	Byte     = Unit{"B", "size", 1}
+	Kilobyte = Unit{"kB", "size", 1e3}
+	Megabyte = Unit{"MB", "size", 1e6}
+	Gigabyte = Unit{"GB", "size", 1e9}
+	Kibibyte = Unit{"KiB", "size", 1 << 10}
+	Mebibyte = Unit{"MiB", "size", 1 << 20}
+	Gibibyte = Unit{"GiB", "size", 1 << 30}
+)
+
+// Factor returns what to multiply a value in u by to express it in to
+func (u Unit) Factor(to Unit) (float64, error) {
+	if u.dimension == "" || u.dimension != to.dimension {
+		return 0, fmt.Errorf("cannot convert %q to %q", u.Name, to.Name)
+	}
+	return u.perBase / to.perBase, nil
+}
+
+// Convert expresses v, measured in u, in the unit to
+func (u Unit) Convert(v float64, to Unit) (float64, error) {
+	f, err := u.Factor(to)
+	return v * f, err
+}
+
+// UnitQuery answers a stream's queries in another unit
+type UnitQuery struct {
+	ds     *DataStreamStats
+	factor float64
+}
+
+// QueryIn returns queries converted from the stream's Options.Unit to
+// unit, so a stream recorded in seconds can answer in milliseconds
+// without callers multiplying constants
+func (ds *DataStreamStats) QueryIn(unit Unit) (UnitQuery, error) {
+	ds.lazyInit()
+	f, err := ds.opts.Unit.Factor(unit)
+	if err != nil {
+		return UnitQuery{}, fmt.Errorf("stream %q: %w", ds.name, err)
+	}
+	return UnitQuery{ds: ds, factor: f}, nil
+}
+
+// Mean returns the converted mean
+func (uq UnitQuery) Mean() float64 { return uq.ds.GetMean() * uq.factor }
+
+// Median returns the converted median
+func (uq UnitQuery) Median() float64 { return uq.ds.GetMedian() * uq.factor }
+
+// Min returns the converted minimum
+func (uq UnitQuery) Min() float64 { return uq.ds.GetMin() * uq.factor }
+
+// Max returns the converted maximum
+func (uq UnitQuery) Max() float64 { return uq.ds.GetMax() * uq.factor }
+
+// Percentile returns the converted pth percentile
+func (uq UnitQuery) Percentile(p float64) float64 { return uq.ds.GetPercentile(p) * uq.factor }
@@ -0,0 +1,68 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUnitConvert(t *testing.T) {
+	for _, tc := range []struct {
+		v        float64
+		from, to Unit
+		want     float64
+	}{
+		{1.5, Second, Millisecond, 1500},
+		{250, Microsecond, Millisecond, 0.25},
+		{2, Minute, Second, 120},
+		{1, Mebibyte, Kibibyte, 1024},
+		{1, Gigabyte, Megabyte, 1000},
+	} {
+		got, err := tc.from.Convert(tc.v, tc.to)
+		if err != nil || math.Abs(got-tc.want) > 1e-9*tc.want {
+			t.Errorf("%v %s in %s = %v, %v; want %v", tc.v, tc.from.Name, tc.to.Name, got, err, tc.want)
+		}
+	}
+
+	if _, err := Second.Factor(Byte); err == nil {
+		t.Error("converting seconds to bytes succeeded, want error")
+	}
+	if _, err := (Unit{}).Factor(Unit{}); err == nil {
+		t.Error("converting between unitless streams succeeded, want error")
+	}
+}
+
+func TestQueryIn(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, Unit: Second})
+	defer ds.Stop()
+	for _, v := range []float64{0.001, 0.002, 0.003} {
+		ds.AddNumber(v)
+	}
+
+	ms, err := ds.QueryIn(Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"Mean", ms.Mean(), 2},
+		{"Median", ms.Median(), 2},
+		{"Min", ms.Min(), 1},
+		{"Max", ms.Max(), 3},
+		{"Percentile(100)", ms.Percentile(100), 3},
+	} {
+		if math.Abs(f.got-f.want) > 1e-9 {
+			t.Errorf("%s = %v ms, want %v", f.name, f.got, f.want)
+		}
+	}
+
+	if _, err := ds.QueryIn(Megabyte); err == nil {
+		t.Error("QueryIn(Megabyte) on a time stream succeeded, want error")
+	}
+	plain := NewDataStreamStats(10)
+	defer plain.Stop()
+	if _, err := plain.QueryIn(Millisecond); err == nil {
+		t.Error("QueryIn on a stream without a unit succeeded, want error")
+	}
+}
@@ -0,0 +1,59 @@
+package streamstats
+
+import (
+	"math"
+	"slices"
+)
+
+// defaultWarmUpBuckets is the number of bounds learned without
+// Options.WarmUpBuckets
+const defaultWarmUpBuckets = 20
+
+// warmUpAdd buffers q until Options.WarmUp samples arrived, then derives
+// the histogram from them; callers hold minMaxLock
+func (ds *DataStreamStats) warmUpAdd(q float64) {
+	ds.warmUp = append(ds.warmUp, q)
+	if len(ds.warmUp) < ds.opts.WarmUp {
+		return
+	}
+
+	n := ds.opts.WarmUpBuckets
+	if n <= 0 {
+		n = defaultWarmUpBuckets
+	}
+	h := NewHistogram(learnBuckets(ds.warmUp, n))
+	for _, v := range ds.warmUp {
+		h.Add(v)
+	}
+	ds.histogram.Store(h)
+	ds.warmUp = nil
+}
+
+// learnBuckets derives n bucket bounds from samples. Positive samples
+// spanning two or more orders of magnitude get exponential buckets from
+// the minimum, others linear buckets over the observed range; either way
+// the range is widened so later samples rarely overflow.
+func learnBuckets(samples []float64, n int) []float64 {
+	lo, hi := slices.Min(samples), slices.Max(samples)
+	if n < 2 {
+		return []float64{hi}
+	}
+
+	if lo > 0 && hi/lo >= 100 {
+		// The last bound sits at 4x the maximum seen
+		factor := math.Pow(4*hi/lo, 1/float64(n-1))
+		return ExponentialBuckets(lo, factor, n)
+	}
+
+	span := hi - lo
+	if span == 0 {
+		span = math.Max(math.Abs(lo), 1)
+	}
+	// A quarter of the range on either side
+	start := lo - span/4
+	if lo >= 0 && start < 0 {
+		start = 0
+	}
+	width := (hi + span/4 - start) / float64(n-1)
+	return LinearBuckets(start, width, n)
+}
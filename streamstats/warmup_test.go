@@ -0,0 +1,80 @@
+package streamstats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWarmUpHistogram(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, WarmUp: 100})
+	defer ds.Stop()
+
+	for i := 1; i < 100; i++ {
+		ds.AddNumber(float64(i))
+	}
+	if _, ok := ds.GetHistogram(); ok {
+		t.Fatal("histogram exists before warm-up completed")
+	}
+	ds.AddNumber(100)
+
+	hs, ok := ds.GetHistogram()
+	if !ok {
+		t.Fatal("no histogram after warm-up")
+	}
+	if hs.Total != 100 || hs.Overflow != 0 {
+		t.Errorf("histogram total %d, overflow %d, want the 100 warm-up samples in buckets", hs.Total, hs.Overflow)
+	}
+	if got := len(hs.Buckets); got != defaultWarmUpBuckets {
+		t.Errorf("learned %d buckets, want %d", got, defaultWarmUpBuckets)
+	}
+
+	ds.AddNumber(110)
+	if hs, _ := ds.GetHistogram(); hs.Total != 101 || hs.Overflow != 0 {
+		t.Errorf("sample within the widened range overflowed: %+v", hs)
+	}
+}
+
+func TestLearnBuckets(t *testing.T) {
+	// Latencies spanning orders of magnitude get exponential buckets
+	exp := learnBuckets([]float64{0.5, 3, 40, 800}, 10)
+	if exp[0] != 0.5 || math.Abs(exp[9]-3200) > 1e-6 {
+		t.Errorf("exponential bounds = %v, want 0.5 .. 3200", exp)
+	}
+	if r := exp[2] / exp[1]; math.Abs(r-exp[1]/exp[0]) > 1e-9 {
+		t.Errorf("bounds %v do not grow by a constant factor", exp)
+	}
+
+	lin := learnBuckets([]float64{-10, 0, 10}, 5)
+	if lin[0] != -15 || lin[4] != 15 {
+		t.Errorf("linear bounds = %v, want -15 .. 15", lin)
+	}
+	if nonNeg := learnBuckets([]float64{1, 5}, 5); nonNeg[0] != 0 {
+		t.Errorf("non-negative bounds = %v, want to start at 0", nonNeg)
+	}
+	if same := learnBuckets([]float64{7, 7}, 3); !(same[0] < 7 && same[2] > 7) {
+		t.Errorf("bounds for a constant = %v, want to surround 7", same)
+	}
+}
+
+func TestWarmUpCheckpoint(t *testing.T) {
+	warm := NewDataStreamStatsWithOptions(Options{Capacity: 10, WarmUp: 10})
+	defer warm.Stop()
+	for i := 1; i <= 20; i++ {
+		warm.AddNumber(float64(i))
+	}
+	data, err := warm.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewDataStreamStatsWithOptions(Options{Capacity: 10, WarmUp: 10})
+	defer restored.Stop()
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := warm.GetHistogram()
+	got, ok := restored.GetHistogram()
+	if !ok || got.Total != want.Total || len(got.Buckets) != len(want.Buckets) {
+		t.Errorf("restored histogram = %+v, want %+v", got, want)
+	}
+}
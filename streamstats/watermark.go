@@ -0,0 +1,159 @@
+package streamstats
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// defaultRetainWindows is the number of closed event-time windows kept
+// without Options.RetainWindows; Options.OnWindowClose sees every one
+const defaultRetainWindows = 100
+
+// eventWindows aggregates samples into tumbling event-time windows that
+// close once the watermark passes their end. Guarded by minMaxLock.
+type eventWindows struct {
+	size      time.Duration
+	lateness  time.Duration
+	onClose   func(Rollup)
+	retain    int       // Closed windows kept
+	maxEvent  time.Time // Latest event time seen
+	watermark time.Time // No more samples are expected before it
+	open      map[time.Time]*Rollup
+	closed    []Rollup
+	late      int64 // Samples for windows that had already closed
+}
+
+// WatermarkStatus describes event-time progress, see Options.EventWindow
+type WatermarkStatus struct {
+	Watermark   time.Time     // Zero before the first sample
+	Lag         time.Duration // How far the watermark trails the clock
+	OpenWindows int
+	Late        int64 // Samples dropped from windows that had closed
+}
+
+func newEventWindows(size, lateness time.Duration, retain int, onClose func(Rollup)) *eventWindows {
+	if retain <= 0 {
+		retain = defaultRetainWindows
+	}
+	return &eventWindows{
+		size:     size,
+		lateness: lateness,
+		retain:   retain,
+		onClose:  onClose,
+		open:     make(map[time.Time]*Rollup),
+	}
+}
+
+// add records a sample observed at t; q is the quantized value for the
+// window digest
+func (ew *eventWindows) add(t time.Time, num, q float64) {
+	start := t.Truncate(ew.size)
+	end := start.Add(ew.size)
+	if !ew.watermark.IsZero() && !end.After(ew.watermark) {
+		ew.late++
+		return
+	}
+
+	w, ok := ew.open[start]
+	if !ok {
+		w = &Rollup{
+			Label:  start.Format(time.RFC3339Nano),
+			Start:  start,
+			Min:    math.Inf(1),
+			Max:    math.Inf(-1),
+			digest: newTDigest(lifetimeCompression),
+		}
+		ew.open[start] = w
+	}
+	w.Count++
+	w.Sum += num
+	w.Min = math.Min(w.Min, num)
+	w.Max = math.Max(w.Max, num)
+	w.digest.add(q, 1)
+
+	if t.After(ew.maxEvent) {
+		ew.maxEvent = t
+		ew.advance(t.Add(-ew.lateness))
+	}
+}
+
+// advance moves the watermark to wm, never backwards, and closes the
+// windows that end at or before it, oldest first
+func (ew *eventWindows) advance(wm time.Time) {
+	if !wm.After(ew.watermark) {
+		return
+	}
+	ew.watermark = wm
+
+	var due []time.Time
+	for start := range ew.open {
+		if !start.Add(ew.size).After(wm) {
+			due = append(due, start)
+		}
+	}
+	slices.SortFunc(due, time.Time.Compare)
+	for _, start := range due {
+		w := ew.open[start]
+		delete(ew.open, start)
+		w.End = start.Add(ew.size)
+		w.digest.compress()
+		ew.closed = append(ew.closed, *w)
+		if len(ew.closed) > ew.retain {
+			ew.closed = slices.Delete(ew.closed, 0, len(ew.closed)-ew.retain)
+		}
+		if ew.onClose != nil {
+			ew.onClose(*w)
+		}
+	}
+}
+
+// AdvanceWatermark moves the watermark to t, for pipelines that carry
+// their own watermarks (e.g. the minimum committed offset time across
+// Kafka partitions), closing the windows that end at or before it.
+// It has no effect without Options.EventWindow or when t is not later
+// than the current watermark.
+func (ds *DataStreamStats) AdvanceWatermark(t time.Time) {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	if ds.events != nil {
+		ds.events.advance(t)
+	}
+}
+
+// Watermark reports event-time progress; ok is false without
+// Options.EventWindow
+func (ds *DataStreamStats) Watermark() (ws WatermarkStatus, ok bool) {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	return ds.watermarkLocked()
+}
+
+// watermarkLocked is Watermark for callers holding minMaxLock
+func (ds *DataStreamStats) watermarkLocked() (WatermarkStatus, bool) {
+	ew := ds.events
+	if ew == nil {
+		return WatermarkStatus{}, false
+	}
+	ws := WatermarkStatus{Watermark: ew.watermark, OpenWindows: len(ew.open), Late: ew.late}
+	if !ew.watermark.IsZero() {
+		ws.Lag = ds.now().Sub(ew.watermark)
+	}
+	return ws, true
+}
+
+// ClosedWindows returns the most recent closed event-time windows, oldest
+// first
+func (ds *DataStreamStats) ClosedWindows() []Rollup {
+	ds.lazyInit()
+	ds.minMaxLock.Lock()
+	defer ds.minMaxLock.Unlock()
+	if ds.events == nil {
+		return nil
+	}
+	out := slices.Clone(ds.events.closed)
+	ds.annotateRollups(out)
+	return out
+}
@@ -0,0 +1,69 @@
+package streamstats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventWindows(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time { return base.Add(time.Duration(sec) * time.Second) }
+
+	var closed []Rollup
+	ds := NewDataStreamStatsWithOptions(Options{
+		Capacity:        100,
+		EventWindow:     10 * time.Second,
+		AllowedLateness: 5 * time.Second,
+		OnWindowClose:   func(r Rollup) { closed = append(closed, r) },
+		Now:             func() time.Time { return at(60) },
+	})
+	defer ds.Stop()
+
+	ds.AddNumberAt(at(1), 1)
+	ds.AddNumberAt(at(12), 10)
+	// Out of order but within the allowed lateness: window [0, 10) is open
+	// until the watermark reaches 10s, i.e. an event at 15s
+	ds.AddNumberAt(at(9), 3)
+	if len(closed) != 0 {
+		t.Fatalf("windows closed before the watermark passed them: %+v", closed)
+	}
+
+	ds.AddNumberAt(at(15), 20)
+	if len(closed) != 1 {
+		t.Fatalf("closed %d windows after the watermark reached 10s, want 1", len(closed))
+	}
+	w := closed[0]
+	if !w.Start.Equal(at(0)) || !w.End.Equal(at(10)) || w.Count != 2 || w.Sum != 4 || w.Max != 3 {
+		t.Errorf("closed window = %+v, want [0s, 10s) with samples 1 and 3", w)
+	}
+
+	// Too late for the closed window: counted, left out of it
+	ds.AddNumberAt(at(2), 100)
+	ws, ok := ds.Watermark()
+	if !ok || !ws.Watermark.Equal(at(10)) || ws.Late != 1 || ws.OpenWindows != 1 || ws.Lag != 50*time.Second {
+		t.Errorf("Watermark() = %+v, want watermark 10s, 1 late sample, 1 open window, lag 50s", ws)
+	}
+	if got := ds.Count(); got != 5 {
+		t.Errorf("Count() = %d, want late samples in lifetime stats", got)
+	}
+
+	ds.AdvanceWatermark(at(30))
+	if got := ds.ClosedWindows(); len(got) != 2 || got[1].Count != 2 || got[1].Mean() != 15 {
+		t.Errorf("ClosedWindows() = %+v, want [10s, 20s) closed with 10 and 20", got)
+	}
+	if snap := ds.Snapshot(); snap.Watermark == nil || snap.Watermark.OpenWindows != 0 {
+		t.Errorf("Snapshot().Watermark = %+v, want no open windows", snap.Watermark)
+	}
+}
+
+func TestWatermarkDisabled(t *testing.T) {
+	ds := NewDataStreamStats(10)
+	defer ds.Stop()
+	ds.AdvanceWatermark(time.Now())
+	if _, ok := ds.Watermark(); ok {
+		t.Error("Watermark() reports status without Options.EventWindow")
+	}
+	if ds.Snapshot().Watermark != nil {
+		t.Error("Snapshot().Watermark is set without Options.EventWindow")
+	}
+}
@@ -0,0 +1,70 @@
+package streamstats
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTimedRingBuffer(t *testing.T) {
+	now := time.Now()
+	rb := NewTimedRingBuffer(4, time.Minute)
+
+	rb.AddAt(now.Add(-3*time.Minute), 1)
+	rb.AddAt(now.Add(-2*time.Minute), 2)
+	rb.AddAt(now.Add(-30*time.Second), 3)
+	if got := rb.Values(); !slices.Equal(got, []float64{3}) {
+		t.Errorf("Values() = %v, want [3]", got)
+	}
+
+	// Wrapping around the storage keeps the order
+	for i := 4; i <= 7; i++ {
+		rb.AddAt(now, float64(i))
+	}
+	if got := rb.Values(); !slices.Equal(got, []float64{4, 5, 6, 7}) {
+		t.Errorf("Values() after wrapping = %v, want [4 5 6 7]", got)
+	}
+	if got := rb.GetSorted(); !slices.Equal(got, []float64{4, 5, 6, 7}) {
+		t.Errorf("GetSorted() = %v, want [4 5 6 7]", got)
+	}
+}
+
+func TestTimeWindow(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 100, TimeWindow: time.Minute})
+	defer ds.Stop()
+
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		ds.AddNumberAt(now.Add(-10*time.Minute), 1000)
+	}
+	for i := 1; i <= 5; i++ {
+		ds.AddNumberAt(now, float64(i))
+	}
+
+	ws := ds.GetWindowStats()
+	if ws.Count != 5 || ws.Mean != 3 || ws.Min != 1 || ws.Max != 5 || ws.P50 != 3 {
+		t.Errorf("GetWindowStats() = %+v, want the 5 recent samples", ws)
+	}
+	if got := ds.GetPercentile(100); got != 5 {
+		t.Errorf("GetPercentile(100) = %v, want 5", got)
+	}
+	// Lifetime statistics still cover every sample
+	if got := ds.GetMax(); got != 1000 {
+		t.Errorf("GetMax() = %v, want 1000", got)
+	}
+}
+
+func TestDecayedMean(t *testing.T) {
+	ds := NewDataStreamStatsWithOptions(Options{Capacity: 10, DecayHalfLife: time.Second})
+	defer ds.Stop()
+
+	now := time.Now()
+	ds.AddNumberAt(now.Add(-time.Minute), 100)
+	ds.AddNumberAt(now, 1)
+	if got := ds.GetDecayedMean(); got < 1 || got > 1.01 {
+		t.Errorf("GetDecayedMean() = %v, want about 1", got)
+	}
+	if got := ds.GetMean(); got != 50.5 {
+		t.Errorf("GetMean() = %v, want 50.5", got)
+	}
+}